@@ -0,0 +1,73 @@
+// headless 无窗口运行游戏，按脚本输入按键并将画面保存为png
+//
+//	go run ./cmd/headless -ticks 600 -input inputs.txt -out frames -every 60
+//	go run ./cmd/headless -ticks 600 -replay session.rec -out frames -every 60
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/headless"
+	"github.com/kkkunny/pokemon/src/replay"
+)
+
+func main() {
+	ticks := flag.Int("ticks", 600, "执行的帧数")
+	inputPath := flag.String("input", "", "按键脚本路径，每行为 `帧 按键 [保持帧数]`")
+	out := flag.String("out", "frames", "画面输出目录，为空时不输出")
+	every := flag.Int("every", 60, "每隔多少帧输出一次画面")
+	replayPath := flag.String("replay", "", "按键记录文件路径，指定时忽略按键脚本")
+	cfgFlags := config.NewFlags(flag.CommandLine)
+	flag.Parse()
+
+	cfg, err := cfgFlags.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	var presses []headless.Press
+	if *inputPath != "" {
+		file, err := os.Open(*inputPath)
+		if err != nil {
+			panic(err)
+		}
+		presses, err = headless.ParseInputScript(file)
+		file.Close()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	var rec *replay.Recording
+	if *replayPath != "" {
+		rec, err = replay.LoadFile(*replayPath)
+		if err != nil {
+			panic(err)
+		}
+		cfg.Seed, cfg.StartTime = rec.Seed, rec.StartTime
+	}
+
+	driver, err := headless.NewDriver(cfg, presses...)
+	if err != nil {
+		panic(err)
+	}
+	defer driver.Close()
+	if rec != nil {
+		if rec.Save != nil {
+			err = driver.Game().LoadData(rec.Save)
+			if err != nil {
+				panic(err)
+			}
+		}
+		driver.Game().SetLoadLocked(true)
+		driver.SetInput(replay.NewPlayer(rec))
+	}
+	err = driver.Run(*ticks, *out, *every)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("ran %d ticks\n", driver.Tick())
+}
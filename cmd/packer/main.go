@@ -0,0 +1,77 @@
+// packer 将资源目录打包为资源包，或查看、校验已有的资源包
+//
+//	go run ./cmd/packer -src data -out base.pack -name base -version 1.0
+//	go run ./cmd/packer -list base.pack
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kkkunny/pokemon/src/assets/pack"
+)
+
+func main() {
+	src := flag.String("src", "data", "打包的资源目录")
+	out := flag.String("out", "", "输出的资源包路径")
+	name := flag.String("name", "", "资源包名称，为空时使用输出文件名")
+	version := flag.String("version", "", "资源包版本")
+	list := flag.String("list", "", "列出并校验资源包中的文件")
+	flag.Parse()
+
+	var err error
+	switch {
+	case *list != "":
+		err = listPack(*list)
+	case *out != "":
+		if *name == "" {
+			*name = strings.TrimSuffix(filepath.Base(*out), filepath.Ext(*out))
+		}
+		err = buildPack(*src, *out, *name, *version)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func buildPack(src, out, name, version string) error {
+	file, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	manifest, err := pack.Build(file, os.DirFS(src), name, version)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(out)
+		return err
+	}
+	var size, packedSize int64
+	for _, entry := range manifest.Files {
+		size, packedSize = size+entry.Size, packedSize+entry.PackedSize
+	}
+	fmt.Printf("packed %d files into %s, %d -> %d bytes\n", len(manifest.Files), out, size, packedSize)
+	return nil
+}
+
+func listPack(path string) error {
+	p, err := pack.OpenFile(path)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+	manifest := p.Manifest()
+	fmt.Printf("%s %s, created %s\n", manifest.Name, manifest.Version, manifest.Created.Format("2006-01-02 15:04:05"))
+	for _, entry := range manifest.Files {
+		fmt.Printf("%10d %10d %-8s %s %s\n", entry.Size, entry.PackedSize, entry.Compression, entry.Hash[:12], entry.Path)
+	}
+	return p.Verify()
+}
@@ -1,5 +1,11 @@
+-- 与路人交谈：转向主角，随机说一句话
+-- 精灵的text属性为翻译key前缀，实际使用 <text>.1 ~ <text>.3
 function person_random_talk(this_sprite)
+    local game = require("game")
     local sprite = require("sprite")
+
     sprite.set_movable(this_sprite, false)
-    print("Hello, Lua!")
+    sprite.face(this_sprite, sprite.self())
+    game.say(sprite.text(this_sprite) .. "." .. math.random(3))
+    sprite.set_movable(this_sprite, true)
 end
@@ -14,6 +14,11 @@
    <frame tileid="9" duration="300"/>
   </animation>
  </tile>
+ <tile id="1">
+  <properties>
+   <property name="terrain" value="tall_grass"/>
+  </properties>
+ </tile>
  <tile id="3">
   <properties>
    <property name="collision" type="bool" value="true"/>
@@ -0,0 +1,21 @@
+//go:build embed
+
+package main
+
+import (
+	"embed"
+	"io/fs"
+
+	stlerr "github.com/kkkunny/stl/error"
+
+	"github.com/kkkunny/pokemon/src/assets"
+)
+
+// 使用 `go build -tags embed` 将data目录编译进程序
+//
+//go:embed data
+var embeddedData embed.FS
+
+func init() {
+	assets.SetEmbedded(stlerr.MustWith(fs.Sub(embeddedData, "data")))
+}
@@ -1,21 +1,95 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/hajimehoshi/ebiten/v2"
 
 	"github.com/kkkunny/pokemon/src"
 	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/replay"
+	"github.com/kkkunny/pokemon/src/save"
 )
 
 func main() {
-	cfg := config.NewConfig()
+	recordPath := flag.String("record", "", "将按键记录到文件")
+	replayPath := flag.String("replay", "", "回放按键记录文件")
+	loadSlot := flag.Int("load", 0, "启动时读取的存档槽，从1开始，为0时开始新游戏")
+	cfgFlags := config.NewFlags(flag.CommandLine)
+	flag.Parse()
+
+	cfg, err := cfgFlags.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	// 开始时读取的存档，回放时使用记录中的存档
+	var data *save.Data
+	var player *replay.Player
+	if *replayPath != "" {
+		if *loadSlot > 0 {
+			fmt.Fprintln(os.Stderr, "-load cannot be used with -replay, the recording carries its own save")
+			os.Exit(2)
+		}
+		rec, err := replay.LoadFile(*replayPath)
+		if err != nil {
+			panic(err)
+		}
+		cfg.Seed, cfg.StartTime = rec.Seed, rec.StartTime
+		data = rec.Save
+		player = replay.NewPlayer(rec)
+	} else if *loadSlot > 0 {
+		data, err = save.Load(*loadSlot - 1)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
 	game, err := src.NewGame(cfg)
 	if err != nil {
 		panic(err)
 	}
+	defer game.Close()
+	if player != nil {
+		game.SetInput(player)
+	}
+	if data != nil {
+		err = game.LoadData(data)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	var recorder *replay.Recorder
+	if *recordPath != "" {
+		file, err := os.Create(*recordPath)
+		if err != nil {
+			panic(err)
+		}
+		defer file.Close()
+		recorder, err = replay.NewRecorder(game.Input(), file, cfg.Seed, cfg.StartTime, data)
+		if err != nil {
+			panic(err)
+		}
+		game.SetInput(recorder)
+	}
+	// 中途读取的存档不在记录中，无法回放
+	game.SetLoadLocked(player != nil || recorder != nil)
+
 	ebiten.SetWindowSize(cfg.ScreenWidth, cfg.ScreenHeight)
+	ebiten.SetFullscreen(cfg.Fullscreen)
 	ebiten.SetWindowTitle(game.Name())
-	if err = ebiten.RunGame(game); err != nil {
+	err = ebiten.RunGame(game)
+	if recorder != nil {
+		if flushErr := recorder.Flush(); err == nil {
+			err = flushErr
+		}
+	}
+	if err != nil {
 		panic(err)
 	}
 }
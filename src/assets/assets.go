@@ -0,0 +1,131 @@
+package assets
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	stlval "github.com/kkkunny/stl/value"
+
+	"github.com/kkkunny/pokemon/src/assets/pack"
+)
+
+// 资源文件系统，路径使用 `/` 分隔且相对于数据目录，如 `world/maps/pallet_town.tmx`
+var (
+	current  fs.FS
+	embedded fs.FS // 编译进程序的资源
+)
+
+// SetEmbedded 设置编译进程序的资源，需在Open之前调用
+func SetEmbedded(fsys fs.FS) {
+	embedded = fsys
+}
+
+// Open 打开资源，path可以是目录、zip文件或资源包，zip文件和资源包使用完后需Close
+// path为空时优先使用编译进程序的资源，否则依次查找工作目录和程序所在目录下的data目录
+func Open(path string) (fs.FS, error) {
+	if path == "" {
+		if embedded != nil {
+			return embedded, nil
+		}
+		path = defaultDataDir()
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		return os.DirFS(path), nil
+	} else if strings.EqualFold(filepath.Ext(path), ".zip") {
+		reader, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return reader, nil
+	} else if strings.EqualFold(filepath.Ext(path), pack.Ext) {
+		return pack.OpenFile(path)
+	}
+	return nil, fmt.Errorf("%s: not a directory, zip file or pack", path)
+}
+
+// OpenLayers 打开数据目录和按顺序叠加在其上的资源包，后面的覆盖前面的
+func OpenLayers(dataDir string, packs ...string) ([]Layer, error) {
+	base, err := Open(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	layers := []Layer{{Name: stlval.Ternary(dataDir == "", "base", dataDir), FS: base}}
+	for _, name := range packs {
+		fsys, err := Open(name)
+		if err != nil {
+			_ = CloseLayers(layers)
+			return nil, err
+		}
+		layers = append(layers, Layer{Name: name, FS: fsys})
+	}
+	return layers, nil
+}
+
+// 默认数据目录
+func defaultDataDir() string {
+	candidates := []string{"data"}
+	if exe, err := os.Executable(); err == nil {
+		candidates = append(candidates, filepath.Join(filepath.Dir(exe), "data"))
+	}
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return candidates[0]
+}
+
+// Mount 设置当前使用的资源，需在读取任何资源之前调用
+func Mount(fsys fs.FS) {
+	current = fsys
+}
+
+// FS 当前使用的资源
+func FS() fs.FS {
+	if current == nil {
+		panic(errors.New("assets not mounted"))
+	}
+	return current
+}
+
+// Layers 当前使用的资源的所有层，从底到顶
+func Layers() []Layer {
+	if overlay, ok := FS().(*Overlay); ok {
+		return overlay.Layers()
+	}
+	return []Layer{{Name: "base", FS: FS()}}
+}
+
+// 统一路径格式，tiled等库会返回系统分隔符的路径
+func clean(name string) string {
+	return path.Clean(filepath.ToSlash(name))
+}
+
+func OpenFile(name string) (fs.File, error) {
+	return FS().Open(clean(name))
+}
+
+func ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(FS(), clean(name))
+}
+
+func ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(FS(), clean(name))
+}
+
+func Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(FS(), clean(name))
+}
+
+func WalkDir(root string, fn fs.WalkDirFunc) error {
+	return fs.WalkDir(FS(), clean(root), fn)
+}
@@ -0,0 +1,125 @@
+package assets
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// Layer 叠加资源中的一层
+type Layer struct {
+	Name string
+	FS   fs.FS
+}
+
+// Overlay 按顺序叠加多层资源，同名文件使用最后一层的，目录内容合并
+type Overlay struct {
+	layers []Layer
+}
+
+func NewOverlay(layers ...Layer) *Overlay {
+	return &Overlay{layers: layers}
+}
+
+// Layers 所有层，从底到顶
+func (o *Overlay) Layers() []Layer {
+	return o.layers
+}
+
+func (o *Overlay) Open(name string) (fs.File, error) {
+	for i := len(o.layers) - 1; i >= 0; i-- {
+		file, err := o.layers[i].FS.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		info, err := file.Stat()
+		if err != nil || !info.IsDir() {
+			return file, err
+		}
+		// 目录需合并所有层的内容
+		entries, err := o.ReadDir(name)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &overlayDir{File: file, entries: entries}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (o *Overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries := make(map[string]fs.DirEntry)
+	var found bool
+	for _, layer := range o.layers {
+		layerEntries, err := fs.ReadDir(layer.FS, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		found = true
+		for _, entry := range layerEntries {
+			entries[entry.Name()] = entry
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	res := make([]fs.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		res = append(res, entry)
+	}
+	slices.SortFunc(res, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return res, nil
+}
+
+// Close 关闭所有层
+func (o *Overlay) Close() error {
+	return CloseLayers(o.layers)
+}
+
+// CloseLayers 关闭各层中打开的zip文件和资源包
+func CloseLayers(layers []Layer) error {
+	var errs []error
+	for _, layer := range layers {
+		if closer, ok := layer.FS.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Owners 提供文件的所有层，从底到顶，最后一个为实际使用的
+func (o *Overlay) Owners(name string) []string {
+	var owners []string
+	for _, layer := range o.layers {
+		if info, err := fs.Stat(layer.FS, name); err == nil && !info.IsDir() {
+			owners = append(owners, layer.Name)
+		}
+	}
+	return owners
+}
+
+// 合并后的目录
+type overlayDir struct {
+	fs.File
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *overlayDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	} else if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.offset += n
+	return rest[:n], nil
+}
@@ -0,0 +1,334 @@
+// Package pack 资源包格式
+//
+// 文件布局（整数均为小端序）：
+//
+//	magic[8] version:uint32   文件头
+//	data...                   各文件的数据，按清单中的偏移存放
+//	manifest                  JSON格式的清单
+//	offset:uint64 magic[8]    文件尾，offset为清单的起始位置
+package pack
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tnnmigga/enum"
+)
+
+const (
+	magic      = "PKMNPACK"
+	headerSize = len(magic) + 4
+	footerSize = 8 + len(magic)
+
+	maxDeflateRatio = 1032 // deflate的最大压缩比，用于校验原大小
+)
+
+// Version 资源包格式版本
+const Version = 1
+
+// Ext 资源包的扩展名
+const Ext = ".pack"
+
+// Compression 文件的压缩方式
+type Compression string
+
+var CompressionEnum = enum.New[struct {
+	None    Compression `enum:"none"`    // 不压缩
+	Deflate Compression `enum:"deflate"` // deflate压缩
+}]()
+
+// Entry 包内的一个文件
+type Entry struct {
+	Path        string      `json:"path"`        // 以 `/` 分隔的相对路径
+	Offset      int64       `json:"offset"`      // 数据在包内的位置
+	Size        int64       `json:"size"`        // 原大小
+	PackedSize  int64       `json:"packed_size"` // 在包内的大小
+	Compression Compression `json:"compression"`
+	Hash        string      `json:"sha256"` // 原内容的sha256
+}
+
+// Manifest 资源包清单
+type Manifest struct {
+	Name    string    `json:"name"`
+	Version string    `json:"version"`
+	Created time.Time `json:"created"`
+	Files   []Entry   `json:"files"`
+}
+
+// Build 将src下的所有文件打包写入w，每个文件压缩后更小时才压缩
+func Build(w io.Writer, src fs.FS, name, version string) (*Manifest, error) {
+	cw := &countWriter{w: w}
+	header := binary.LittleEndian.AppendUint32([]byte(magic), Version)
+	if _, err := cw.Write(header); err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{Name: name, Version: version, Created: time.Now().UTC()}
+	err := fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(src, name)
+		if err != nil {
+			return err
+		}
+		hash := sha256.Sum256(data)
+		entry := Entry{
+			Path:        name,
+			Offset:      cw.n,
+			Size:        int64(len(data)),
+			Compression: CompressionEnum.None,
+			Hash:        hex.EncodeToString(hash[:]),
+		}
+		packed, err := deflate(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		} else if len(packed) < len(data) {
+			data, entry.Compression = packed, CompressionEnum.Deflate
+		}
+		entry.PackedSize = int64(len(data))
+		if _, err = cw.Write(data); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	manifestOffset := cw.n
+	if err = json.NewEncoder(cw).Encode(manifest); err != nil {
+		return nil, err
+	}
+	footer := append(binary.LittleEndian.AppendUint64(nil, uint64(manifestOffset)), magic...)
+	if _, err = cw.Write(footer); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = writer.Write(data); err != nil {
+		return nil, err
+	} else if err = writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// Pack 只读的资源包，实现fs.FS
+type Pack struct {
+	r        io.ReaderAt
+	closer   io.Closer
+	manifest *Manifest
+	files    map[string]*Entry
+	dirs     map[string][]fs.DirEntry // 目录 -> 子项，由文件路径推出
+}
+
+// NewReader 读取资源包的清单
+func NewReader(r io.ReaderAt, size int64) (*Pack, error) {
+	if size < int64(headerSize+footerSize) {
+		return nil, errors.New("pack: file too small")
+	}
+	header := make([]byte, headerSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	} else if string(header[:len(magic)]) != magic {
+		return nil, errors.New("pack: bad magic")
+	} else if v := binary.LittleEndian.Uint32(header[len(magic):]); v != Version {
+		return nil, fmt.Errorf("pack: unsupported version %d, expect %d", v, Version)
+	}
+	footer := make([]byte, footerSize)
+	if _, err := r.ReadAt(footer, size-int64(footerSize)); err != nil {
+		return nil, err
+	} else if string(footer[8:]) != magic {
+		return nil, errors.New("pack: bad footer")
+	}
+	manifestOffset := int64(binary.LittleEndian.Uint64(footer))
+	if manifestOffset < int64(headerSize) || manifestOffset > size-int64(footerSize) {
+		return nil, errors.New("pack: bad manifest offset")
+	}
+	var manifest Manifest
+	err := json.NewDecoder(io.NewSectionReader(r, manifestOffset, size-int64(footerSize)-manifestOffset)).Decode(&manifest)
+	if err != nil {
+		return nil, fmt.Errorf("pack: manifest: %w", err)
+	}
+
+	p := &Pack{
+		r:        r,
+		manifest: &manifest,
+		files:    make(map[string]*Entry, len(manifest.Files)),
+		dirs:     map[string][]fs.DirEntry{".": nil},
+	}
+	for i := range manifest.Files {
+		entry := &manifest.Files[i]
+		if !fs.ValidPath(entry.Path) || entry.Path == "." {
+			return nil, fmt.Errorf("pack: invalid path `%s`", entry.Path)
+		} else if err := entry.validate(manifestOffset); err != nil {
+			return nil, fmt.Errorf("pack: %s: %w", entry.Path, err)
+		} else if _, ok := p.files[entry.Path]; ok {
+			return nil, fmt.Errorf("pack: duplicate file `%s`", entry.Path)
+		}
+		p.files[entry.Path] = entry
+		p.addDirEntry(entry.Path, &fileInfo{name: path.Base(entry.Path), size: entry.Size})
+	}
+	for _, entries := range p.dirs {
+		slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	}
+	return p, nil
+}
+
+// 校验清单中的大小和位置，dataEnd为数据区的结束位置
+func (e *Entry) validate(dataEnd int64) error {
+	if e.Size < 0 || e.PackedSize < 0 {
+		return errors.New("negative size")
+	} else if e.Offset < int64(headerSize) || e.Offset > dataEnd || e.PackedSize > dataEnd-e.Offset {
+		return errors.New("data out of range")
+	}
+	switch e.Compression {
+	case CompressionEnum.None:
+		if e.Size != e.PackedSize {
+			return errors.New("size mismatch")
+		}
+	case CompressionEnum.Deflate:
+		if e.Size/maxDeflateRatio > e.PackedSize {
+			return errors.New("size too large")
+		}
+	default:
+		return fmt.Errorf("unknown compression `%s`", e.Compression)
+	}
+	return nil
+}
+
+// OpenFile 打开资源包文件，使用完后需Close
+func OpenFile(name string) (*Pack, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	p, err := NewReader(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	p.closer = file
+	return p, nil
+}
+
+func (p *Pack) Close() error {
+	if p.closer == nil {
+		return nil
+	}
+	return p.closer.Close()
+}
+
+// 将文件或目录加入父目录，父目录不存在时递归创建
+func (p *Pack) addDirEntry(name string, info *fileInfo) {
+	dir := path.Dir(name)
+	_, exist := p.dirs[dir]
+	p.dirs[dir] = append(p.dirs[dir], info)
+	if !exist {
+		p.addDirEntry(dir, &fileInfo{name: path.Base(dir), dir: true})
+	}
+}
+
+// Manifest 资源包清单
+func (p *Pack) Manifest() *Manifest {
+	return p.manifest
+}
+
+func (p *Pack) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if entries, ok := p.dirs[name]; ok {
+		return &dir{info: &fileInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+	}
+	entry, ok := p.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	data, err := p.read(entry)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &file{info: &fileInfo{name: path.Base(name), size: entry.Size}, Reader: bytes.NewReader(data)}, nil
+}
+
+func (p *Pack) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	entries, ok := p.dirs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return slices.Clone(entries), nil
+}
+
+// 读取并解压文件，校验内容哈希
+func (p *Pack) read(entry *Entry) ([]byte, error) {
+	var reader io.Reader = io.NewSectionReader(p.r, entry.Offset, entry.PackedSize)
+	switch entry.Compression {
+	case CompressionEnum.None:
+	case CompressionEnum.Deflate:
+		reader = flate.NewReader(reader)
+	default:
+		return nil, fmt.Errorf("unknown compression `%s`", entry.Compression)
+	}
+	data := make([]byte, entry.Size)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(data)
+	if hex.EncodeToString(hash[:]) != entry.Hash {
+		return nil, errors.New("content hash mismatch")
+	}
+	return data, nil
+}
+
+// Verify 读取所有文件并校验哈希
+func (p *Pack) Verify() error {
+	for i := range p.manifest.Files {
+		entry := &p.manifest.Files[i]
+		if _, err := p.read(entry); err != nil {
+			return fmt.Errorf("%s: %w", entry.Path, err)
+		}
+	}
+	return nil
+}
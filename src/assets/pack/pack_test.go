@@ -0,0 +1,92 @@
+package pack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io/fs"
+	"math"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// 打包测试文件，返回资源包内容
+func buildPack(t *testing.T) []byte {
+	t.Helper()
+	src := fstest.MapFS{
+		"a.txt":     {Data: []byte("hello")},
+		"dir/b.txt": {Data: []byte(strings.Repeat("pokemon", 100))},
+	}
+	var buf bytes.Buffer
+	if _, err := Build(&buf, src, "test", "1"); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// 修改资源包的清单，数据区不变
+func rewriteManifest(t *testing.T, data []byte, mutate func(m *Manifest)) []byte {
+	t.Helper()
+	offset := binary.LittleEndian.Uint64(data[len(data)-footerSize:])
+	var manifest Manifest
+	if err := json.Unmarshal(data[offset:len(data)-footerSize], &manifest); err != nil {
+		t.Fatal(err)
+	}
+	mutate(&manifest)
+	encoded, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := append(bytes.Clone(data[:offset]), encoded...)
+	out = binary.LittleEndian.AppendUint64(out, offset)
+	return append(out, magic...)
+}
+
+func TestRoundTrip(t *testing.T) {
+	data := buildPack(t)
+	p, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	content, err := fs.ReadFile(p, "dir/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	} else if string(content) != strings.Repeat("pokemon", 100) {
+		t.Fatalf("unexpected content %q", content)
+	}
+}
+
+func TestRejectBadEntries(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(e *Entry)
+	}{
+		{"negative size", func(e *Entry) { e.Size = -1 }},
+		{"negative packed size", func(e *Entry) { e.PackedSize = -1 }},
+		{"huge size", func(e *Entry) { e.Size = math.MaxInt64 }},
+		{"offset before data", func(e *Entry) { e.Offset = 0 }},
+		{"offset after data", func(e *Entry) { e.Offset = math.MaxInt64 }},
+		{"packed size past data", func(e *Entry) { e.PackedSize = math.MaxInt64 }},
+		{"size mismatch", func(e *Entry) { e.Size++ }},
+		{"unknown compression", func(e *Entry) { e.Compression = "zstd" }},
+	}
+	data := buildPack(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corrupt := rewriteManifest(t, data, func(m *Manifest) {
+				for i := range m.Files {
+					if m.Files[i].Path == "a.txt" {
+						tt.mutate(&m.Files[i])
+					}
+				}
+			})
+			if _, err := NewReader(bytes.NewReader(corrupt), int64(len(corrupt))); err == nil {
+				t.Fatal("expect error")
+			}
+		})
+	}
+}
@@ -1,17 +1,131 @@
 package config
 
-const (
-	Scale    = 2  // 放大倍数
-	TileSize = 16 // 地图原大小
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
+const TileSize = 16 // 地图原大小
+
+// TextSpeeds 文字速度的可选值，与dialogue.TextSpeedEnum对应
+var TextSpeeds = []string{"slow", "normal", "fast"}
+
 type Config struct {
-	ScreenWidth, ScreenHeight int
+	ScreenWidth  int      `yaml:"screen_width"`   // 窗口宽度
+	ScreenHeight int      `yaml:"screen_height"`  // 窗口高度
+	Scale        int      `yaml:"scale"`          // 地图放大倍数
+	Fullscreen   bool     `yaml:"fullscreen"`     // 是否全屏
+	Language     string   `yaml:"language"`       // 语言，对应 localisation 下的目录名
+	DataDir      string   `yaml:"data_dir"`       // 数据目录、zip文件或资源包，为空时使用内置资源或data目录
+	Packs        []string `yaml:"packs"`          // 按顺序叠加在数据目录之上的资源包，后面的覆盖前面的
+	ModsDir      string   `yaml:"mods_dir"`       // mod目录，为空时不载入mod
+	MusicVolume  float64  `yaml:"music_volume"`   // 音乐音量，0~1
+	SoundVolume  float64  `yaml:"sound_volume"`   // 音效音量，0~1
+	TextSpeed    string   `yaml:"text_speed"`     // 文字速度，slow、normal 或 fast
+	StartMap     string   `yaml:"start_map"`      // 新游戏的初始地图
+	StartPos     [2]int   `yaml:"start_position"` // 新游戏在初始地图上的地块位置
+
+	Seed      uint64    `yaml:"seed"` // 随机数种子，为0时使用当前时间
+	StartTime time.Time `yaml:"-"`    // 游戏世界的初始时间，为空时使用当前时间
 }
 
 func NewConfig() *Config {
 	return &Config{
 		ScreenWidth:  720,
 		ScreenHeight: 480,
+		Scale:        2,
+		Language:     "zh_cn",
+		MusicVolume:  1,
+		SoundVolume:  1,
+		TextSpeed:    "normal",
+		ModsDir:      "mods",
+		StartMap:     "pallet_town",
+		StartPos:     [2]int{6, 8},
+	}
+}
+
+// LoadConfig 读取配置文件，文件不存在时使用默认配置，缺少的项使用默认值
+func LoadConfig(path string) (*Config, error) {
+	cfg := NewConfig()
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	err = decoder.Decode(cfg)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate 校验配置，返回所有不合法的项，资源相关的项由ValidateAssets校验
+func (cfg *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, field string, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("config: %s: %s", field, fmt.Sprintf(format, args...)))
+		}
+	}
+	check(cfg.ScreenWidth > 0, "screen_width", "must be positive, got %d", cfg.ScreenWidth)
+	check(cfg.ScreenHeight > 0, "screen_height", "must be positive, got %d", cfg.ScreenHeight)
+	check(cfg.Scale >= 1 && cfg.Scale <= 8, "scale", "must be between 1 and 8, got %d", cfg.Scale)
+	check(cfg.MusicVolume >= 0 && cfg.MusicVolume <= 1, "music_volume", "must be between 0 and 1, got %g", cfg.MusicVolume)
+	check(cfg.SoundVolume >= 0 && cfg.SoundVolume <= 1, "sound_volume", "must be between 0 and 1, got %g", cfg.SoundVolume)
+	check(slices.ContainsFunc(TextSpeeds, func(s string) bool { return strings.EqualFold(s, cfg.TextSpeed) }), "text_speed", "must be one of %s, got `%s`", strings.Join(TextSpeeds, ", "), cfg.TextSpeed)
+	check(cfg.StartPos[0] >= 0 && cfg.StartPos[1] >= 0, "start_position", "must not be negative, got %v", cfg.StartPos)
+	return errors.Join(errs...)
+}
+
+// ValidateAssets 校验配置中引用的资源是否存在
+func (cfg *Config) ValidateAssets(fsys fs.FS) error {
+	var errs []error
+	if info, err := fs.Stat(fsys, path.Join(LocalisationPath, cfg.Language)); cfg.Language == "" || err != nil || !info.IsDir() {
+		errs = append(errs, fmt.Errorf("config: language: no localisation for `%s`", cfg.Language))
+	}
+	if width, height, err := mapSize(fsys, cfg.StartMap); cfg.StartMap == "" || err != nil {
+		errs = append(errs, fmt.Errorf("config: start_map: no map named `%s`", cfg.StartMap))
+	} else if cfg.StartPos[0] >= width || cfg.StartPos[1] >= height {
+		errs = append(errs, fmt.Errorf("config: start_position: %v is outside map `%s` of size %dx%d", cfg.StartPos, cfg.StartMap, width, height))
+	}
+	return errors.Join(errs...)
+}
+
+// 读取地图的地块数，只解析根元素
+func mapSize(fsys fs.FS, name string) (width, height int, err error) {
+	file, err := fsys.Open(path.Join(MapsPath, name+".tmx"))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+	var root struct {
+		Width  int `xml:"width,attr"`
+		Height int `xml:"height,attr"`
+	}
+	decoder := xml.NewDecoder(file)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0, 0, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			err = decoder.DecodeElement(&root, &start)
+			return root.Width, root.Height, err
+		}
 	}
 }
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		edit  func(cfg *Config)
+		field string // 期望出错的项，为空时应校验通过
+	}{
+		{name: "default", edit: func(cfg *Config) {}},
+		{name: "text_speed_case", edit: func(cfg *Config) { cfg.TextSpeed = "Fast" }},
+		{name: "text_speed_unknown", edit: func(cfg *Config) { cfg.TextSpeed = "instant" }, field: "text_speed"},
+		{name: "text_speed_empty", edit: func(cfg *Config) { cfg.TextSpeed = "" }, field: "text_speed"},
+		{name: "start_position_negative", edit: func(cfg *Config) { cfg.StartPos = [2]int{-1, 0} }, field: "start_position"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			tt.edit(cfg)
+			checkFieldError(t, cfg.Validate(), tt.field)
+		})
+	}
+}
+
+func TestValidateAssets(t *testing.T) {
+	fsys := fstest.MapFS{
+		LocalisationPath + "/zh_cn/game.yml": {Data: []byte("game_name: test\n")},
+		MapsPath + "/town.tmx": {Data: []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" width="20" height="18" tilewidth="16" tileheight="16">
+</map>
+`)},
+	}
+	tests := []struct {
+		name  string
+		edit  func(cfg *Config)
+		field string
+	}{
+		{name: "inside", edit: func(cfg *Config) { cfg.StartPos = [2]int{19, 17} }},
+		{name: "x_outside", edit: func(cfg *Config) { cfg.StartPos = [2]int{20, 0} }, field: "start_position"},
+		{name: "y_outside", edit: func(cfg *Config) { cfg.StartPos = [2]int{0, 18} }, field: "start_position"},
+		{name: "unknown_map", edit: func(cfg *Config) { cfg.StartMap = "city" }, field: "start_map"},
+		{name: "unknown_language", edit: func(cfg *Config) { cfg.Language = "en" }, field: "language"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.StartMap = "town"
+			tt.edit(cfg)
+			checkFieldError(t, cfg.ValidateAssets(fsys), tt.field)
+		})
+	}
+}
+
+// 默认配置的初始位置在内置数据的初始地图内
+func TestDefaultStartPosition(t *testing.T) {
+	dataDir := filepath.Join("..", "..", "data")
+	if _, err := os.Stat(filepath.Join(dataDir, MapsPath)); err != nil {
+		t.Skip("no map data")
+	}
+	checkFieldError(t, NewConfig().ValidateAssets(os.DirFS(dataDir)), "")
+}
+
+func checkFieldError(t *testing.T, err error, field string) {
+	t.Helper()
+	if field == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil {
+		t.Fatalf("expect error on %s", field)
+	}
+	if !strings.Contains(err.Error(), "config: "+field+":") {
+		t.Fatalf("expect error on %s, got: %v", field, err)
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import "flag"
+
+// Flags 命令行参数，显式指定的参数覆盖配置文件中的值
+type Flags struct {
+	fs     *flag.FlagSet
+	path   string
+	values Config
+}
+
+// NewFlags 在参数集上注册配置相关的参数
+func NewFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{fs: fs}
+	fs.StringVar(&f.path, "config", ConfigPath, "配置文件路径")
+	fs.IntVar(&f.values.ScreenWidth, "width", 0, "窗口宽度")
+	fs.IntVar(&f.values.ScreenHeight, "height", 0, "窗口高度")
+	fs.IntVar(&f.values.Scale, "scale", 0, "地图放大倍数")
+	fs.BoolVar(&f.values.Fullscreen, "fullscreen", false, "是否全屏")
+	fs.StringVar(&f.values.Language, "lang", "", "语言")
+	fs.StringVar(&f.values.DataDir, "data", "", "数据目录、zip文件或资源包，为空时使用内置资源或data目录")
+	fs.Float64Var(&f.values.MusicVolume, "music-volume", 0, "音乐音量，0~1")
+	fs.Float64Var(&f.values.SoundVolume, "sound-volume", 0, "音效音量，0~1")
+	fs.Func("pack", "叠加的资源包，可指定多次，后面的覆盖前面的", func(s string) error {
+		f.values.Packs = append(f.values.Packs, s)
+		return nil
+	})
+	fs.StringVar(&f.values.ModsDir, "mods", "", "mod目录，为空时不载入mod")
+	fs.StringVar(&f.values.TextSpeed, "text-speed", "", "文字速度，slow、normal 或 fast")
+	fs.StringVar(&f.values.StartMap, "start-map", "", "新游戏的初始地图")
+	fs.Uint64Var(&f.values.Seed, "seed", 0, "随机数种子")
+	return f
+}
+
+// Load 读取配置文件并应用显式指定的参数，需在解析参数之后调用
+func (f *Flags) Load() (*Config, error) {
+	cfg, err := LoadConfig(f.path)
+	if err != nil {
+		return nil, err
+	}
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "width":
+			cfg.ScreenWidth = f.values.ScreenWidth
+		case "height":
+			cfg.ScreenHeight = f.values.ScreenHeight
+		case "scale":
+			cfg.Scale = f.values.Scale
+		case "fullscreen":
+			cfg.Fullscreen = f.values.Fullscreen
+		case "lang":
+			cfg.Language = f.values.Language
+		case "data":
+			cfg.DataDir = f.values.DataDir
+		case "music-volume":
+			cfg.MusicVolume = f.values.MusicVolume
+		case "sound-volume":
+			cfg.SoundVolume = f.values.SoundVolume
+		case "pack":
+			cfg.Packs = f.values.Packs
+		case "mods":
+			cfg.ModsDir = f.values.ModsDir
+		case "text-speed":
+			cfg.TextSpeed = f.values.TextSpeed
+		case "start-map":
+			cfg.StartMap = f.values.StartMap
+		case "seed":
+			cfg.Seed = f.values.Seed
+		}
+	})
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -8,20 +8,32 @@ import (
 )
 
 var RootPath = string(stlerr.MustWith(stlos.GetWorkDirectory()))
-var DataPath = filepath.Join(RootPath, "data")
 
+// 用户文件，位于工作目录下
 var (
-	FontsPath         = filepath.Join(DataPath, "fonts")
-	LocalisationPath  = filepath.Join(DataPath, "localisation")
-	WorldPath         = filepath.Join(DataPath, "world")
-	MapsPath          = filepath.Join(WorldPath, "maps")
-	VoicePath         = filepath.Join(DataPath, "voice")
-	ScriptsPath       = filepath.Join(DataPath, "scripts")
-	PokemonDefinePath = filepath.Join(DataPath, "pokemons")
+	SavesPath  = filepath.Join(RootPath, "saves")
+	KeymapPath = filepath.Join(RootPath, "keymap.yml")
+	ConfigPath = filepath.Join(RootPath, "config.yml")
+	// ModReportPath mod载入顺序和冲突报告
+	ModReportPath = filepath.Join(RootPath, "mods_report.txt")
 )
 
-var (
-	GFXPath            = filepath.Join(DataPath, "gfx")
-	GFXMapPath         = filepath.Join(GFXPath, "map")
-	GFXBattleSitesPath = filepath.Join(GFXPath, "battle_sites")
+// 资源路径，相对于资源文件系统的根目录，见assets包
+const (
+	FontsPath         = "fonts"
+	LocalisationPath  = "localisation"
+	WorldPath         = "world"
+	MapsPath          = WorldPath + "/maps"
+	VoicePath         = "voice"
+	ScriptsPath       = "scripts"
+	PokemonDefinePath = "pokemons"
+	MovesPath         = "moves.yml"
+	ItemsPath         = "items.yml"
+	TypeChartPath     = "type_restraint_relationship.csv"
+)
+
+const (
+	GFXPath            = "gfx"
+	GFXMapPath         = GFXPath + "/map"
+	GFXBattleSitesPath = GFXPath + "/battle_sites"
 )
@@ -2,42 +2,181 @@ package src
 
 import (
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 
+	"github.com/kkkunny/pokemon/src/assets"
 	"github.com/kkkunny/pokemon/src/config"
 	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/mod"
+	"github.com/kkkunny/pokemon/src/pokemon"
+	"github.com/kkkunny/pokemon/src/save"
+	"github.com/kkkunny/pokemon/src/state"
 	"github.com/kkkunny/pokemon/src/system"
 	"github.com/kkkunny/pokemon/src/system/context"
+	"github.com/kkkunny/pokemon/src/util"
 	"github.com/kkkunny/pokemon/src/util/draw"
 	"github.com/kkkunny/pokemon/src/util/i18n"
 	imgutil "github.com/kkkunny/pokemon/src/util/image"
 )
 
 type Game struct {
-	cfg   *config.Config
-	loc   *i18n.Localisation
-	input *input.System
-	sys   *system.System
+	assets *assets.Overlay
+	cfg    *config.Config
+	ctx    context.Context
+	loc    *i18n.Localisation
+	input  input.Source
+	sys    *system.System
 }
 
-func NewGame(cfg *config.Config) (*Game, error) {
+func NewGame(cfg *config.Config) (g *Game, err error) {
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	// 资源
+	overlay, err := loadAssets(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = overlay.Close()
+		}
+	}()
+	// 启动时读取属性克制表和字体，有误时不进入游戏
+	err = pokemon.LoadTypeChart()
+	if err != nil {
+		return nil, err
+	}
+	err = util.LoadFonts()
+	if err != nil {
+		return nil, fmt.Errorf("fonts: %w", err)
+	}
 	// 翻译
-	loc, err := i18n.LoadLocalisation(i18n.LanguageEnum.ZH_CN)
+	loc, err := i18n.LoadLocalisation(i18n.Language(cfg.Language))
+	if err != nil {
+		return nil, err
+	}
+	// 未指定时使用当前时间，写回配置以便记录
+	if cfg.Seed == 0 {
+		cfg.Seed = uint64(time.Now().UnixNano())
+	}
+	if cfg.StartTime.IsZero() {
+		cfg.StartTime = time.Now()
+	}
+	ctx := context.NewContext(cfg, loc, state.NewStore())
+	sys, err := system.NewSystem(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 按键
+	bindings, err := input.LoadBindings(config.KeymapPath)
+	if err != nil {
+		return nil, err
+	}
+	keys := input.NewSystem(bindings)
+	sys.SetKeyBinder(keys)
+	g = &Game{
+		assets: overlay,
+		cfg:    cfg,
+		ctx:    ctx,
+		loc:    loc,
+		input:  keys,
+		sys:    sys,
+	}
+	sys.SetHeldFunc(func(action input.KeyInputAction) bool { return g.input.Held(action) })
+	return g, err
+}
+
+// Close 关闭打开的zip文件和资源包，退出时调用
+func (g *Game) Close() error {
+	return g.assets.Close()
+}
+
+// LoadGame 从存档槽读取游戏，slot从0开始
+func (g *Game) LoadGame(slot int) error {
+	return g.sys.LoadGame(slot)
+}
+
+// LoadData 恢复存档内容，如按键记录中保存的初始存档
+func (g *Game) LoadData(data *save.Data) error {
+	return g.sys.LoadData(data)
+}
+
+// SetLoadLocked 录制或回放按键时禁止从菜单读取存档
+func (g *Game) SetLoadLocked(locked bool) {
+	g.sys.SetLoadLocked(locked)
+}
+
+// Context 游戏上下文，可以取得配置、翻译、剧情状态和随机数流
+func (g *Game) Context() context.Context {
+	return g.ctx
+}
+
+// Snapshot 当前的游戏状态，即存档的内容
+func (g *Game) Snapshot() *save.Data {
+	return g.sys.Snapshot()
+}
+
+// Input 当前的按键来源
+func (g *Game) Input() input.Source {
+	return g.input
+}
+
+// 依次叠加数据目录、资源包和mod，载入了mod时写入冲突报告
+func loadAssets(cfg *config.Config) (_ *assets.Overlay, err error) {
+	layers, err := assets.OpenLayers(cfg.DataDir, cfg.Packs...)
+	if err != nil {
+		return nil, fmt.Errorf("assets: %w", err)
+	}
+	var mods []*mod.Mod
+	if cfg.ModsDir != "" {
+		mods, err = mod.Load(cfg.ModsDir)
+		if err != nil {
+			_ = assets.CloseLayers(layers)
+			return nil, fmt.Errorf("mods: %w", err)
+		}
+	}
+	overlay := assets.NewOverlay(append(layers, mod.Layers(mods)...)...)
+	defer func() {
+		if err != nil {
+			_ = overlay.Close()
+		}
+	}()
+	err = cfg.ValidateAssets(overlay)
 	if err != nil {
 		return nil, err
 	}
-	sys, err := system.NewSystem(context.NewContext(cfg, loc))
+	assets.Mount(overlay)
+	if len(mods) == 0 {
+		return overlay, nil
+	}
+
+	conflicts, err := mod.Conflicts(overlay, i18n.Language(cfg.Language))
+	if err != nil {
+		return nil, fmt.Errorf("mods: %w", err)
+	}
+	file, err := os.Create(config.ModReportPath)
 	if err != nil {
 		return nil, err
 	}
-	return &Game{
-		cfg:   cfg,
-		loc:   loc,
-		input: input.NewSystem(),
-		sys:   sys,
-	}, err
+	err = mod.WriteReport(file, mods, conflicts)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return overlay, nil
+}
+
+// SetInput 替换按键来源
+func (g *Game) SetInput(src input.Source) {
+	g.input = src
 }
 
 func (g *Game) Name() string {
@@ -59,13 +198,18 @@ func (g *Game) Update() error {
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	err := g.sys.OnDraw(draw.NewDrawerFromImage(imgutil.WrapImage(screen)))
+	err := g.DrawTo(imgutil.WrapImage(screen))
 	if err != nil {
 		panic(err)
 	}
 	ebitenutil.DebugPrint(screen, fmt.Sprintf("FPS: %0.2f, TPS: %0.2f", ebiten.ActualFPS(), ebiten.ActualTPS()))
 }
 
+// DrawTo 绘制一帧到图像上
+func (g *Game) DrawTo(screen imgutil.Image) error {
+	return g.sys.OnDraw(draw.NewDrawerFromImage(screen))
+}
+
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
 	return outsideWidth, outsideHeight
 }
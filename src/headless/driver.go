@@ -0,0 +1,114 @@
+package headless
+
+import (
+	"fmt"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/kkkunny/pokemon/src"
+	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/output/voice"
+	imgutil "github.com/kkkunny/pokemon/src/util/image"
+)
+
+// Driver 无窗口驱动，不依赖GPU和音频设备，逐帧执行游戏逻辑和绘制
+type Driver struct {
+	game   *src.Game
+	screen imgutil.Image
+	tick   int
+}
+
+// NewDriver 创建无窗口驱动，需要在载入任何图像资源之前调用
+func NewDriver(cfg *config.Config, presses ...Press) (*Driver, error) {
+	imgutil.SetBackend(imgutil.BackendEnum.RGBA)
+	voice.SetEnabled(false)
+
+	game, err := src.NewGame(cfg)
+	if err != nil {
+		return nil, err
+	}
+	game.SetInput(NewScriptedInput(presses...))
+	return &Driver{
+		game:   game,
+		screen: imgutil.NewImage(cfg.ScreenWidth, cfg.ScreenHeight),
+	}, nil
+}
+
+// Game 驱动的游戏
+func (d *Driver) Game() *src.Game {
+	return d.game
+}
+
+// Close 关闭游戏打开的资源文件
+func (d *Driver) Close() error {
+	return d.game.Close()
+}
+
+// SetInput 替换按键来源，如回放按键记录
+func (d *Driver) SetInput(source input.Source) {
+	d.game.SetInput(source)
+}
+
+// Tick 已执行的帧数
+func (d *Driver) Tick() int {
+	return d.tick
+}
+
+// Screen 最近一帧的画面
+func (d *Driver) Screen() imgutil.Image {
+	return d.screen
+}
+
+// Step 执行一帧逻辑并绘制
+func (d *Driver) Step() error {
+	err := d.game.Update()
+	if err != nil {
+		return fmt.Errorf("tick %d: %w", d.tick, err)
+	}
+	d.screen.Fill(color.Black)
+	err = d.game.DrawTo(d.screen)
+	if err != nil {
+		return fmt.Errorf("tick %d: %w", d.tick, err)
+	}
+	d.tick++
+	return nil
+}
+
+// Run 执行若干帧，dir不为空时每隔every帧将画面保存为 dir/frame_<帧>.png
+func (d *Driver) Run(ticks int, dir string, every int) error {
+	if dir != "" {
+		err := os.MkdirAll(dir, 0o755)
+		if err != nil {
+			return err
+		}
+	}
+	for range ticks {
+		err := d.Step()
+		if err != nil {
+			return err
+		}
+		if dir != "" && every > 0 && d.tick%every == 0 {
+			err = d.SaveFrame(filepath.Join(dir, fmt.Sprintf("frame_%05d.png", d.tick)))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SaveFrame 将最近一帧的画面保存为png
+func (d *Driver) SaveFrame(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(file, d.screen)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,159 @@
+package headless
+
+import (
+	"flag"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/image/font/gofont/goregular"
+
+	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/input"
+)
+
+var update = flag.Bool("update", false, "重新生成testdata下的基准画面")
+
+// 固定种子、时间和字体，画面只由按键决定
+// 数据目录不附带normal字体，用Go字体叠加在数据目录之上
+func newTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+	pack := t.TempDir()
+	err := os.MkdirAll(filepath.Join(pack, config.FontsPath), 0o755)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(pack, config.FontsPath, "normal.ttf"), goregular.TTF, 0o644)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.NewConfig()
+	cfg.DataDir = filepath.Join("..", "..", "data")
+	cfg.Packs = []string{pack}
+	cfg.ModsDir = ""
+	cfg.Seed = 1
+	cfg.StartTime = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	return cfg
+}
+
+// 与testdata下的基准画面逐像素比较，-update时写入基准画面
+func checkGolden(t *testing.T, name string, got image.Image) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".png")
+	if *update {
+		err := os.MkdirAll("testdata", 0o755)
+		if err == nil {
+			err = writePNG(path, got)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("no golden image %s, run with -update to create it", path)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	want, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("%s: size %v, want %v", name, got.Bounds(), want.Bounds())
+	}
+	var diff int
+	var first image.Point
+	for y := got.Bounds().Min.Y; y < got.Bounds().Max.Y; y++ {
+		for x := got.Bounds().Min.X; x < got.Bounds().Max.X; x++ {
+			r1, g1, b1, a1 := got.At(x, y).RGBA()
+			r2, g2, b2, a2 := want.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				if diff == 0 {
+					first = image.Pt(x, y)
+				}
+				diff++
+			}
+		}
+	}
+	if diff > 0 {
+		actual := filepath.Join(t.TempDir(), name+".png")
+		_ = writePNG(actual, got)
+		t.Fatalf("%s: %d pixels differ, first at %v, actual frame saved to %s", name, diff, first, actual)
+	}
+}
+
+func writePNG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(file, img)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// 新游戏开始时显示欢迎提示，按住A加速显示文字，再按一次关闭
+func dismissWelcome() []Press {
+	return []Press{
+		{Tick: 5, Action: input.KeyInputActionEnum.A, Hold: 30},
+		{Tick: 45, Action: input.KeyInputActionEnum.A, Hold: 1},
+	}
+}
+
+// 在1号道路的草丛中来回走动，种子为1时第21步遭遇野生宝可梦
+func walkInGrass(cfg *config.Config) {
+	cfg.StartMap = "Route_1"
+	cfg.StartPos = [2]int{12, 36}
+}
+
+func grassWalkPresses() []Press {
+	presses := dismissWelcome()
+	for i := range 6 {
+		presses = append(presses,
+			Press{Tick: 60 + i*80, Action: input.KeyInputActionEnum.MoveDown, Hold: 32},
+			Press{Tick: 100 + i*80, Action: input.KeyInputActionEnum.MoveUp, Hold: 32},
+		)
+	}
+	return presses
+}
+
+func TestDriverGolden(t *testing.T) {
+	tests := []struct {
+		name    string
+		ticks   int
+		edit    func(cfg *config.Config) // 修改测试配置，为空时不修改
+		presses []Press
+	}{
+		{name: "welcome_label", ticks: 30},
+		{name: "world_walk", ticks: 100, presses: append(dismissWelcome(), Press{Tick: 60, Action: input.KeyInputActionEnum.MoveDown, Hold: 16})},
+		{name: "start_menu", ticks: 80, presses: append(dismissWelcome(), Press{Tick: 60, Action: input.KeyInputActionEnum.Start, Hold: 1})},
+		{name: "wild_battle", ticks: 600, edit: walkInGrass, presses: grassWalkPresses()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t)
+			if tt.edit != nil {
+				tt.edit(cfg)
+			}
+			driver, err := NewDriver(cfg, tt.presses...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = driver.Run(tt.ticks, "", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name, driver.Screen())
+		})
+	}
+}
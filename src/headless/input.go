@@ -0,0 +1,89 @@
+package headless
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	stlval "github.com/kkkunny/stl/value"
+
+	"github.com/kkkunny/pokemon/src/input"
+)
+
+// Press 在某一帧按下按键并保持若干帧
+type Press struct {
+	Tick   int                  // 按下的帧
+	Action input.KeyInputAction // 按键
+	Hold   int                  // 保持的帧数，至少为1
+}
+
+// ScriptedInput 按脚本回放的按键来源
+type ScriptedInput struct {
+	presses []Press
+	tick    int
+}
+
+func NewScriptedInput(presses ...Press) *ScriptedInput {
+	return &ScriptedInput{presses: presses}
+}
+
+// ParseInputScript 解析按键脚本
+// 每行格式为 `帧 按键 [保持帧数]`，如 `30 a`、`60 move_up 32`，`#` 开头的行为注释
+func ParseInputScript(r io.Reader) ([]Press, error) {
+	var presses []Press
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("line %d: expect `tick key [hold]`", line)
+		}
+		tick, err := strconv.Atoi(fields[0])
+		if err != nil || tick < 0 {
+			return nil, fmt.Errorf("line %d: invalid tick `%s`", line, fields[0])
+		}
+		action, ok := input.ParseKeyInputAction(fields[1])
+		if !ok {
+			return nil, fmt.Errorf("line %d: unknown key `%s`", line, fields[1])
+		}
+		hold := 1
+		if len(fields) == 3 {
+			hold, err = strconv.Atoi(fields[2])
+			if err != nil || hold < 1 {
+				return nil, fmt.Errorf("line %d: invalid hold `%s`", line, fields[2])
+			}
+		}
+		presses = append(presses, Press{Tick: tick, Action: action, Hold: hold})
+	}
+	return presses, scanner.Err()
+}
+
+// KeyInputAction 每帧调用一次，返回当前帧的按键
+func (s *ScriptedInput) KeyInputAction() (*input.KeyInputAction, error) {
+	tick := s.tick
+	s.tick++
+	for _, p := range s.presses {
+		if tick == p.Tick {
+			return stlval.Ptr(p.Action.Pressed()), nil
+		} else if p.Tick < tick && tick < p.Tick+max(p.Hold, 1) {
+			return &p.Action, nil
+		}
+	}
+	return nil, nil
+}
+
+// Held 当前帧按键是否被按住，需在KeyInputAction之后调用
+func (s *ScriptedInput) Held(action input.KeyInputAction) bool {
+	tick := s.tick - 1
+	for _, p := range s.presses {
+		if p.Action == action && p.Tick <= tick && tick < p.Tick+max(p.Hold, 1) {
+			return true
+		}
+	}
+	return false
+}
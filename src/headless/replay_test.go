@@ -0,0 +1,173 @@
+package headless
+
+import (
+	"bytes"
+	"image"
+	"reflect"
+	"testing"
+
+	"github.com/tnnmigga/enum"
+
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/replay"
+	"github.com/kkkunny/pokemon/src/save"
+	"github.com/kkkunny/pokemon/src/system/context"
+)
+
+const replayTicks = 320
+
+// 录制时的按键：关闭欢迎提示后四处走动，打开再关闭菜单
+func replayPresses() []Press {
+	return append(dismissWelcome(),
+		Press{Tick: 60, Action: input.KeyInputActionEnum.MoveDown, Hold: 40},
+		Press{Tick: 110, Action: input.KeyInputActionEnum.MoveLeft, Hold: 32},
+		Press{Tick: 150, Action: input.KeyInputActionEnum.MoveUp, Hold: 16},
+		Press{Tick: 200, Action: input.KeyInputActionEnum.Start, Hold: 1},
+		Press{Tick: 215, Action: input.KeyInputActionEnum.MoveDown, Hold: 1},
+		Press{Tick: 230, Action: input.KeyInputActionEnum.B, Hold: 1},
+		Press{Tick: 250, Action: input.KeyInputActionEnum.MoveRight, Hold: 48},
+	)
+}
+
+// 各随机数流接下来的值，调用次数不同时会不一致
+func nextRandValues(ctx context.Context) map[context.RandStream]uint64 {
+	values := make(map[context.RandStream]uint64)
+	for _, stream := range enum.Values[context.RandStream](context.RandStreamEnum) {
+		values[stream] = ctx.Rand(stream).Uint64()
+	}
+	return values
+}
+
+// 录制一次游戏，data不为空时从该存档开始
+func recordSession(t *testing.T, data *save.Data) (*Driver, *replay.Recording) {
+	t.Helper()
+	recorded, err := NewDriver(newTestConfig(t), replayPresses()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data != nil {
+		err = recorded.Game().LoadData(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := recorded.Game().Context().Config()
+	var buf bytes.Buffer
+	recorder, err := replay.NewRecorder(recorded.Game().Input(), &buf, cfg.Seed, cfg.StartTime, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded.SetInput(recorder)
+	err = recorded.Run(replayTicks, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = recorder.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := replay.Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return recorded, rec
+}
+
+// 只根据记录文件回放
+func replaySession(t *testing.T, rec *replay.Recording) *Driver {
+	t.Helper()
+	cfg := newTestConfig(t)
+	cfg.Seed, cfg.StartTime = rec.Seed, rec.StartTime
+	replayed, err := NewDriver(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Save != nil {
+		err = replayed.Game().LoadData(rec.Save)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	player := replay.NewPlayer(rec)
+	replayed.SetInput(player)
+	err = replayed.Run(replayTicks, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !player.Done() {
+		t.Fatal("recording not fully replayed")
+	}
+	return replayed
+}
+
+// 主角位置、队伍、背包、剧情状态、游戏时间、画面和随机数流都一致
+func checkSameSession(t *testing.T, recorded, replayed *Driver) {
+	t.Helper()
+	want, got := recorded.Game().Snapshot(), replayed.Game().Snapshot()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed state differs\n got: %+v\nwant: %+v", got, want)
+	}
+	// 画面，包含NPC的位置
+	if !sameImage(replayed.Screen(), recorded.Screen()) {
+		t.Fatal("replayed frame differs")
+	}
+	wantRand, gotRand := nextRandValues(recorded.Game().Context()), nextRandValues(replayed.Game().Context())
+	if !reflect.DeepEqual(gotRand, wantRand) {
+		t.Fatalf("random streams differ\n got: %v\nwant: %v", gotRand, wantRand)
+	}
+}
+
+func TestReplayReproducesSession(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the game twice")
+	}
+	recorded, rec := recordSession(t, nil)
+	if rec.Save != nil {
+		t.Fatal("new game recorded with a save")
+	}
+	replayed := replaySession(t, rec)
+
+	cfg := recorded.Game().Context().Config()
+	if want := recorded.Game().Snapshot(); want.Map == "" || (want.X == cfg.StartPos[0] && want.Y == cfg.StartPos[1]) {
+		t.Fatalf("recorded session did not move, at %s (%d, %d)", want.Map, want.X, want.Y)
+	}
+	checkSameSession(t, recorded, replayed)
+}
+
+// 从存档开始录制时，回放前恢复记录中的存档
+func TestReplayFromSave(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the game three times")
+	}
+	start, err := NewDriver(newTestConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := start.Game().Snapshot()
+	data.Money += 1000
+	data.Bag = map[string]int{"potion": 3}
+
+	recorded, rec := recordSession(t, data)
+	if rec.Save == nil || rec.Save.Money != data.Money {
+		t.Fatalf("recording header save %+v", rec.Save)
+	}
+	replayed := replaySession(t, rec)
+	if got := replayed.Game().Snapshot(); got.Money != data.Money || got.Bag["potion"] != 3 {
+		t.Fatalf("save not restored: money %d, bag %v", got.Money, got.Bag)
+	}
+	checkSameSession(t, recorded, replayed)
+}
+
+func sameImage(a, b image.Image) bool {
+	if a.Bounds() != b.Bounds() {
+		return false
+	}
+	for y := a.Bounds().Min.Y; y < a.Bounds().Max.Y; y++ {
+		for x := a.Bounds().Min.X; x < a.Bounds().Max.X; x++ {
+			if a.At(x, y) != b.At(x, y) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,230 @@
+package input
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	input "github.com/quasilyte/ebitengine-input"
+	"github.com/tnnmigga/enum"
+	"gopkg.in/yaml.v3"
+)
+
+// Device 输入设备
+type Device string
+
+var DeviceEnum = enum.New[struct {
+	Keyboard Device `enum:"keyboard"` // 键盘
+	Gamepad  Device `enum:"gamepad"`  // 手柄
+}]()
+
+// 手柄键名的前缀，其余键名都属于键盘
+const gamepadKeyPrefix = "gamepad_"
+
+// 键名所属的设备
+func keyDevice(name string) Device {
+	if strings.HasPrefix(name, gamepadKeyPrefix) {
+		return DeviceEnum.Gamepad
+	}
+	return DeviceEnum.Keyboard
+}
+
+// Name 按键名，如 `a`、`move_up`
+func (a KeyInputAction) Name() string {
+	for i, v := range enum.Values[KeyInputAction](KeyInputActionEnum) {
+		if v != a {
+			continue
+		}
+		var name []rune
+		for j, ch := range enum.Keys(KeyInputActionEnum)[i] {
+			if unicode.IsUpper(ch) && j > 0 {
+				name = append(name, '_')
+			}
+			name = append(name, unicode.ToLower(ch))
+		}
+		return string(name)
+	}
+	return ""
+}
+
+// Bindings 按键绑定，每个设备上按键名 -> 键名列表
+// 键名使用 ebitengine-input 的格式，如 `w`、`ctrl+s`、`gamepad_a`
+type Bindings struct {
+	Keyboard map[string][]string `yaml:"keyboard"`
+	Gamepad  map[string][]string `yaml:"gamepad"`
+}
+
+// DefaultBindings 默认按键绑定
+func DefaultBindings() *Bindings {
+	return &Bindings{
+		Keyboard: map[string][]string{
+			"move_up":    {"w"},
+			"move_down":  {"s"},
+			"move_left":  {"a"},
+			"move_right": {"d"},
+			"a":          {"j"},
+			"b":          {"k"},
+			"start":      {"enter"},
+			"select":     {"backspace"},
+			"l":          {"q"},
+			"r":          {"e"},
+		},
+		Gamepad: map[string][]string{
+			"move_up":    {"gamepad_up"},
+			"move_down":  {"gamepad_down"},
+			"move_left":  {"gamepad_left"},
+			"move_right": {"gamepad_right"},
+			"a":          {"gamepad_a"},
+			"b":          {"gamepad_b"},
+			"start":      {"gamepad_start"},
+			"select":     {"gamepad_back"},
+			"l":          {"gamepad_l1"},
+			"r":          {"gamepad_r1"},
+		},
+	}
+}
+
+// LoadBindings 读取按键绑定文件，文件不存在时使用默认绑定，缺少的按键使用默认值
+func LoadBindings(path string) (*Bindings, error) {
+	b := DefaultBindings()
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var loaded Bindings
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	err = decoder.Decode(&loaded)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for name, keys := range loaded.Keyboard {
+		b.Keyboard[name] = keys
+	}
+	for name, keys := range loaded.Gamepad {
+		b.Gamepad[name] = keys
+	}
+	err = b.validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+// Save 写入按键绑定文件，先写入临时文件再重命名
+func (b *Bindings) Save(path string) error {
+	content, err := yaml.Marshal(b)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// 设备上的绑定
+func (b *Bindings) device(device Device) map[string][]string {
+	if device == DeviceEnum.Gamepad {
+		return b.Gamepad
+	}
+	return b.Keyboard
+}
+
+// 校验按键名、键名，以及同一设备上是否有键被绑定到多个按键
+func (b *Bindings) validate() error {
+	for _, device := range enum.Values[Device](DeviceEnum) {
+		owners := make(map[string]string)
+		for name, keys := range b.device(device) {
+			if _, ok := ParseKeyInputAction(name); !ok {
+				return fmt.Errorf("%s: unknown action `%s`", device, name)
+			}
+			for _, key := range keys {
+				if _, err := input.ParseKey(key); err != nil {
+					return fmt.Errorf("%s.%s: %w", device, name, err)
+				} else if keyDevice(key) != device {
+					return fmt.Errorf("%s.%s: key `%s` belongs to %s", device, name, key, keyDevice(key))
+				} else if owner, ok := owners[key]; ok {
+					return fmt.Errorf("%s: key `%s` bound to both `%s` and `%s`", device, key, owner, name)
+				}
+				owners[key] = name
+			}
+		}
+	}
+	return nil
+}
+
+// Keys 按键在设备上绑定的键名
+func (b *Bindings) Keys(device Device, action KeyInputAction) []string {
+	return b.device(device)[action.Name()]
+}
+
+// Bind 将键绑定到按键，替换该按键在此设备上原有的键
+// 键已被其他按键使用时，两个按键交换绑定，返回被交换的按键
+func (b *Bindings) Bind(device Device, action KeyInputAction, key string) (KeyInputAction, bool, error) {
+	if _, err := input.ParseKey(key); err != nil {
+		return 0, false, err
+	} else if keyDevice(key) != device {
+		return 0, false, fmt.Errorf("key `%s` belongs to %s", key, keyDevice(key))
+	}
+	bindings := b.device(device)
+	name := action.Name()
+	old := bindings[name]
+	for otherName, keys := range bindings {
+		if otherName == name || !slices.Contains(keys, key) {
+			continue
+		}
+		other, _ := ParseKeyInputAction(otherName)
+		bindings[otherName] = append(slices.DeleteFunc(slices.Clone(keys), func(k string) bool { return k == key }), old...)
+		bindings[name] = []string{key}
+		return other, true, nil
+	}
+	bindings[name] = []string{key}
+	return 0, false, nil
+}
+
+// Reset 恢复设备上的默认绑定
+func (b *Bindings) Reset(device Device) {
+	if device == DeviceEnum.Gamepad {
+		b.Gamepad = DefaultBindings().Gamepad
+	} else {
+		b.Keyboard = DefaultBindings().Keyboard
+	}
+}
+
+// 转为 ebitengine-input 的键位表，键名已在载入时校验
+func (b *Bindings) keymap() input.Keymap {
+	keymap := make(input.Keymap)
+	for _, action := range enum.Values[KeyInputAction](KeyInputActionEnum) {
+		for _, device := range enum.Values[Device](DeviceEnum) {
+			for _, name := range b.Keys(device, action) {
+				if key, err := input.ParseKey(name); err == nil {
+					keymap[action.action()] = append(keymap[action.action()], key)
+				}
+			}
+		}
+	}
+	return keymap
+}
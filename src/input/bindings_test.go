@@ -0,0 +1,189 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeBindings(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bindings.yml")
+	err := os.WriteFile(path, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadBindings(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string // 期望错误包含的内容，为空时应载入成功
+	}{
+		{name: "empty"},
+		{name: "override", content: "keyboard:\n  a: [space, j]\n"},
+		{name: "unknown_action", content: "keyboard:\n  jump: [space]\n", err: "keyboard: unknown action `jump`"},
+		{name: "unknown_key", content: "keyboard:\n  a: [no_such_key]\n", err: "keyboard.a:"},
+		{name: "wrong_device", content: "gamepad:\n  a: [space]\n", err: "gamepad.a: key `space` belongs to keyboard"},
+		{name: "gamepad_on_keyboard", content: "keyboard:\n  a: [gamepad_x]\n", err: "keyboard.a: key `gamepad_x` belongs to gamepad"},
+		// 与默认绑定冲突
+		{name: "conflict_default", content: "keyboard:\n  a: [k]\n", err: "keyboard: key `k` bound to both"},
+		{name: "conflict_same_action", content: "gamepad:\n  b: [gamepad_x, gamepad_x]\n", err: "gamepad: key `gamepad_x` bound to both `b` and `b`"},
+		{name: "unknown_field", content: "mouse:\n  a: [left]\n", err: "field mouse not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := LoadBindings(writeBindings(t, tt.content))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 未写出的按键使用默认值
+			if got := b.Keys(DeviceEnum.Keyboard, KeyInputActionEnum.MoveUp); !reflect.DeepEqual(got, []string{"w"}) {
+				t.Fatalf("move_up keys %v, want default", got)
+			}
+		})
+	}
+
+	b, err := LoadBindings(filepath.Join(t.TempDir(), "missing.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(b, DefaultBindings()) {
+		t.Fatalf("bindings %+v, want default", b)
+	}
+}
+
+func TestBind(t *testing.T) {
+	b := DefaultBindings()
+	// 未被占用的键直接替换
+	_, swapped, err := b.Bind(DeviceEnum.Keyboard, KeyInputActionEnum.A, "space")
+	if err != nil || swapped {
+		t.Fatalf("bind free key: swapped %v, error %v", swapped, err)
+	}
+	if got := b.Keys(DeviceEnum.Keyboard, KeyInputActionEnum.A); !reflect.DeepEqual(got, []string{"space"}) {
+		t.Fatalf("a keys %v, want [space]", got)
+	}
+
+	// 被B使用的键，B换成A原来的键
+	other, swapped, err := b.Bind(DeviceEnum.Keyboard, KeyInputActionEnum.A, "k")
+	if err != nil || !swapped || other != KeyInputActionEnum.B {
+		t.Fatalf("bind used key: other %v, swapped %v, error %v", other.Name(), swapped, err)
+	}
+	if got := b.Keys(DeviceEnum.Keyboard, KeyInputActionEnum.A); !reflect.DeepEqual(got, []string{"k"}) {
+		t.Fatalf("a keys %v, want [k]", got)
+	}
+	if got := b.Keys(DeviceEnum.Keyboard, KeyInputActionEnum.B); !reflect.DeepEqual(got, []string{"space"}) {
+		t.Fatalf("b keys %v, want [space]", got)
+	}
+	// 另一个设备不受影响
+	if got := b.Keys(DeviceEnum.Gamepad, KeyInputActionEnum.A); !reflect.DeepEqual(got, []string{"gamepad_a"}) {
+		t.Fatalf("gamepad a keys %v", got)
+	}
+	if err = b.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 绑定已有的键不改变
+	_, swapped, err = b.Bind(DeviceEnum.Keyboard, KeyInputActionEnum.A, "k")
+	if err != nil || swapped {
+		t.Fatalf("bind own key: swapped %v, error %v", swapped, err)
+	}
+
+	for _, tt := range []struct {
+		device Device
+		key    string
+	}{
+		{device: DeviceEnum.Keyboard, key: "gamepad_a"},
+		{device: DeviceEnum.Gamepad, key: "j"},
+		{device: DeviceEnum.Keyboard, key: "no_such_key"},
+	} {
+		if _, _, err = b.Bind(tt.device, KeyInputActionEnum.Start, tt.key); err == nil {
+			t.Errorf("bound `%s` on %s", tt.key, tt.device)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	b := DefaultBindings()
+	for _, device := range []Device{DeviceEnum.Keyboard, DeviceEnum.Gamepad} {
+		_, _, err := b.Bind(device, KeyInputActionEnum.A, b.Keys(device, KeyInputActionEnum.B)[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.Reset(DeviceEnum.Keyboard)
+	if !reflect.DeepEqual(b.Keyboard, DefaultBindings().Keyboard) {
+		t.Fatalf("keyboard %v, want default", b.Keyboard)
+	}
+	if got := b.Keys(DeviceEnum.Gamepad, KeyInputActionEnum.A); !reflect.DeepEqual(got, []string{"gamepad_b"}) {
+		t.Fatalf("gamepad reset with keyboard, a keys %v", got)
+	}
+	b.Reset(DeviceEnum.Gamepad)
+	if !reflect.DeepEqual(b, DefaultBindings()) {
+		t.Fatalf("bindings %+v, want default", b)
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "config")
+	path := filepath.Join(dir, "bindings.yml")
+	b := DefaultBindings()
+	_, _, err := b.Bind(DeviceEnum.Keyboard, KeyInputActionEnum.Start, "space")
+	if err == nil {
+		err = b.Save(path)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadBindings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, b) {
+		t.Fatalf("loaded %+v, want %+v", loaded, b)
+	}
+
+	// 覆盖原文件，不留下临时文件
+	b.Reset(DeviceEnum.Keyboard)
+	err = b.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "bindings.yml" {
+		t.Fatalf("config directory contains %v", entries)
+	}
+
+	// 重命名失败时删除临时文件，目标不变
+	blocked := filepath.Join(t.TempDir(), "bindings.yml")
+	err = os.MkdirAll(filepath.Join(blocked, "keep"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = b.Save(blocked); err == nil {
+		t.Fatal("saved over a directory")
+	}
+	entries, err = os.ReadDir(filepath.Dir(blocked))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !entries[0].IsDir() {
+		t.Fatalf("directory contains %v after failed save", entries)
+	}
+	if _, err = os.Stat(filepath.Join(blocked, "keep")); err != nil {
+		t.Fatal(err)
+	}
+}
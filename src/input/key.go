@@ -1,6 +1,8 @@
 package input
 
 import (
+	"strings"
+
 	stlval "github.com/kkkunny/stl/value"
 	input "github.com/quasilyte/ebitengine-input"
 	"github.com/tnnmigga/enum"
@@ -26,30 +28,113 @@ var KeyInputActionEnum = enum.New[struct {
 	MoveLeft  KeyInputAction
 	MoveRight KeyInputAction
 
-	A KeyInputAction
+	A      KeyInputAction
+	B      KeyInputAction
+	Start  KeyInputAction
+	Select KeyInputAction
+	L      KeyInputAction
+	R      KeyInputAction
 }]()
 
+// ParseKeyInputAction 按名字解析按键，如 `a`、`move_up`，不区分大小写
+func ParseKeyInputAction(name string) (KeyInputAction, bool) {
+	name = strings.ReplaceAll(name, "_", "")
+	for i, key := range enum.Keys(KeyInputActionEnum) {
+		if strings.EqualFold(key, name) {
+			return enum.Values[KeyInputAction](KeyInputActionEnum)[i], true
+		}
+	}
+	return 0, false
+}
+
+// Source 按键来源
+type Source interface {
+	KeyInputAction() (*KeyInputAction, error)
+	// Held 按键当前是否被按住，用于和方向键同时按下的键，如按住B跑步
+	Held(action KeyInputAction) bool
+}
+
+// 可以绑定的手柄键，键盘键由 ebitengine-input 的 KeyScanner 扫描
+var gamepadKeys = []input.Key{
+	input.KeyGamepadUp, input.KeyGamepadDown, input.KeyGamepadLeft, input.KeyGamepadRight,
+	input.KeyGamepadA, input.KeyGamepadB, input.KeyGamepadX, input.KeyGamepadY,
+	input.KeyGamepadL1, input.KeyGamepadR1, input.KeyGamepadL2, input.KeyGamepadR2,
+	input.KeyGamepadStart, input.KeyGamepadBack, input.KeyGamepadHome,
+}
+
 type System struct {
 	inputSystem   input.System
 	actionHandler *input.Handler
+	bindings      *Bindings
+	// 改键时扫描新按下的键
+	keyScanner      *input.KeyScanner
+	gamepadHandler  *input.Handler
+	gamepadReleased bool // 手柄按键是否已全部松开，和键盘一样松开后才接受新按下的键
 }
 
-func NewSystem() *System {
-	s := &System{}
+func NewSystem(bindings *Bindings) *System {
+	s := &System{bindings: bindings}
 	s.inputSystem.Init(input.SystemConfig{
 		DevicesEnabled: input.AnyDevice,
 	})
-	keymap := input.Keymap{
-		KeyInputActionEnum.MoveUp.action():    {input.KeyGamepadUp, input.KeyW},
-		KeyInputActionEnum.MoveDown.action():  {input.KeyGamepadDown, input.KeyS},
-		KeyInputActionEnum.MoveLeft.action():  {input.KeyGamepadLeft, input.KeyA},
-		KeyInputActionEnum.MoveRight.action(): {input.KeyGamepadRight, input.KeyD},
-		KeyInputActionEnum.A.action():         {input.KeyGamepadA, input.KeyJ},
+	s.actionHandler = s.inputSystem.NewHandler(0, bindings.keymap())
+	s.keyScanner = input.NewKeyScanner(s.actionHandler)
+	gamepadKeymap := make(input.Keymap, len(gamepadKeys))
+	for i, key := range gamepadKeys {
+		gamepadKeymap[input.Action(i)] = []input.Key{key}
 	}
-	s.actionHandler = s.inputSystem.NewHandler(0, keymap)
+	s.gamepadHandler = s.inputSystem.NewHandler(0, gamepadKeymap)
 	return s
 }
 
+// Bindings 当前的按键绑定
+func (s *System) Bindings() *Bindings {
+	return s.bindings
+}
+
+// Rebind 修改按键绑定并立即生效，返回因冲突被交换绑定的按键
+func (s *System) Rebind(device Device, action KeyInputAction, key string) (KeyInputAction, bool, error) {
+	other, swapped, err := s.bindings.Bind(device, action, key)
+	if err != nil {
+		return 0, false, err
+	}
+	s.actionHandler.Remap(s.bindings.keymap())
+	return other, swapped, nil
+}
+
+// ResetBindings 恢复设备上的默认绑定并立即生效
+func (s *System) ResetBindings(device Device) {
+	s.bindings.Reset(device)
+	s.actionHandler.Remap(s.bindings.keymap())
+}
+
+// ScanKey 每帧调用，返回设备上新按下的键名
+func (s *System) ScanKey(device Device) (string, bool) {
+	if device == DeviceEnum.Gamepad {
+		// 打开改键提示的A键此时仍被按住，需等所有键松开
+		var pressed bool
+		for i, key := range gamepadKeys {
+			if !s.gamepadHandler.ActionIsPressed(input.Action(i)) {
+				continue
+			}
+			pressed = true
+			if s.gamepadReleased && s.gamepadHandler.ActionIsJustPressed(input.Action(i)) {
+				s.gamepadReleased = false
+				return key.String(), true
+			}
+		}
+		if !pressed {
+			s.gamepadReleased = true
+		}
+		return "", false
+	}
+	key, status := s.keyScanner.Scan()
+	if status != input.KeyScanCompleted {
+		return "", false
+	}
+	return key.String(), true
+}
+
 func (s *System) KeyInputAction() (*KeyInputAction, error) {
 	for _, a := range enum.Values[KeyInputAction](KeyInputActionEnum) {
 		if s.actionHandler.ActionIsJustPressed(a.action()) {
@@ -60,3 +145,7 @@ func (s *System) KeyInputAction() (*KeyInputAction, error) {
 	}
 	return nil, nil
 }
+
+func (s *System) Held(action KeyInputAction) bool {
+	return s.actionHandler.ActionIsPressed(action.action())
+}
@@ -0,0 +1,122 @@
+package item
+
+import (
+	"slices"
+	"sort"
+)
+
+// Slot 背包中的一格
+type Slot struct {
+	Item  *Item
+	Count int
+}
+
+// Bag 背包，按口袋分类存放道具
+type Bag struct {
+	pockets map[Pocket][]*Slot
+}
+
+func NewBag() *Bag {
+	return &Bag{pockets: make(map[Pocket][]*Slot)}
+}
+
+// NewBagFromCounts 从道具id和数量还原背包，按道具id排序
+func NewBagFromCounts(counts map[string]int) (*Bag, error) {
+	bag := NewBag()
+	ids := make([]string, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		_, err := bag.Add(id, counts[id])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bag, nil
+}
+
+// Pocket 口袋中的道具，按放入顺序
+func (b *Bag) Pocket(pocket Pocket) []Slot {
+	slots := make([]Slot, len(b.pockets[pocket]))
+	for i, slot := range b.pockets[pocket] {
+		slots[i] = *slot
+	}
+	return slots
+}
+
+func (b *Bag) find(item *Item) (*Slot, bool) {
+	index := slices.IndexFunc(b.pockets[item.Pocket], func(slot *Slot) bool { return slot.Item == item })
+	if index < 0 {
+		return nil, false
+	}
+	return b.pockets[item.Pocket][index], true
+}
+
+// Count 道具数量
+func (b *Bag) Count(id string) int {
+	item, err := GetItem(id)
+	if err != nil {
+		return 0
+	}
+	slot, ok := b.find(item)
+	if !ok {
+		return 0
+	}
+	return slot.Count
+}
+
+// Add 放入道具，受堆叠上限和口袋容量约束
+// @return: 实际放入的数量
+func (b *Bag) Add(id string, count int) (int, error) {
+	item, err := GetItem(id)
+	if err != nil {
+		return 0, err
+	}
+	if count <= 0 {
+		return 0, nil
+	}
+	slot, ok := b.find(item)
+	if !ok {
+		if len(b.pockets[item.Pocket]) >= item.Pocket.Capacity() {
+			return 0, nil
+		}
+		slot = &Slot{Item: item}
+		b.pockets[item.Pocket] = append(b.pockets[item.Pocket], slot)
+	}
+	added := min(count, item.MaxStack-slot.Count)
+	slot.Count += added
+	return added, nil
+}
+
+// Remove 取出道具，数量不足或数量不为正时不取出并返回false
+func (b *Bag) Remove(id string, count int) bool {
+	if count <= 0 {
+		return false
+	}
+	item, err := GetItem(id)
+	if err != nil {
+		return false
+	}
+	slot, ok := b.find(item)
+	if !ok || slot.Count < count {
+		return false
+	}
+	slot.Count -= count
+	if slot.Count == 0 {
+		b.pockets[item.Pocket] = slices.DeleteFunc(b.pockets[item.Pocket], func(s *Slot) bool { return s == slot })
+	}
+	return true
+}
+
+// Counts 所有道具的id和数量，用于存档
+func (b *Bag) Counts() map[string]int {
+	counts := make(map[string]int)
+	for _, slots := range b.pockets {
+		for _, slot := range slots {
+			counts[slot.Item.ID] = slot.Count
+		}
+	}
+	return counts
+}
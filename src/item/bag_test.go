@@ -0,0 +1,180 @@
+package item
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kkkunny/pokemon/src/assets"
+	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/pokemon"
+)
+
+// 使用测试道具表
+func useTestItems(t *testing.T) {
+	t.Helper()
+	var items strings.Builder
+	items.WriteString(`
+potion: {pocket: items, field: heal_hp, amount: 20}
+antidote: {pocket: items, field: cure_status, status: poison}
+awakening: {pocket: items, field: cure_status, status: sleep}
+full_heal: {pocket: items, field: cure_status, status: all}
+bicycle: {pocket: key_items, stack: 1}
+`)
+	// 比精灵球口袋的容量多一种
+	for i := range PocketEnum.PokeBalls.Capacity() + 1 {
+		fmt.Fprintf(&items, "ball_%02d: {pocket: poke_balls}\n", i)
+	}
+	assets.Mount(fstest.MapFS{config.ItemsPath: {Data: []byte(items.String())}})
+	itemCache = nil
+	t.Cleanup(func() { itemCache = nil })
+}
+
+func pocketIDs(bag *Bag, pocket Pocket) []string {
+	var ids []string
+	for _, slot := range bag.Pocket(pocket) {
+		ids = append(ids, slot.Item.ID)
+	}
+	return ids
+}
+
+func TestBagStack(t *testing.T) {
+	useTestItems(t)
+	bag := NewBag()
+	tests := []struct {
+		id           string
+		count, added int
+	}{
+		{id: "potion", count: 990, added: 990},
+		{id: "potion", count: 20, added: 9},
+		{id: "potion", count: 1, added: 0},
+		{id: "potion", count: -1, added: 0},
+		{id: "bicycle", count: 2, added: 1},
+		{id: "bicycle", count: 1, added: 0},
+	}
+	for _, tt := range tests {
+		added, err := bag.Add(tt.id, tt.count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if added != tt.added {
+			t.Fatalf("add %d %s: added %d, want %d", tt.count, tt.id, added, tt.added)
+		}
+	}
+	if bag.Count("potion") != 999 || bag.Count("bicycle") != 1 {
+		t.Fatalf("counts %v", bag.Counts())
+	}
+	if _, err := bag.Add("master_ball", 1); err == nil {
+		t.Fatal("added unknown item")
+	}
+}
+
+func TestBagRemove(t *testing.T) {
+	useTestItems(t)
+	bag := NewBag()
+	_, err := bag.Add("potion", 3)
+	if err == nil {
+		_, err = bag.Add("antidote", 1)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, count := range []int{0, -1, 4} {
+		if bag.Remove("potion", count) {
+			t.Fatalf("removed %d potions", count)
+		}
+	}
+	if bag.Remove("bicycle", 1) || bag.Remove("master_ball", 1) {
+		t.Fatal("removed missing item")
+	}
+	if !bag.Remove("potion", 2) || bag.Count("potion") != 1 {
+		t.Fatalf("potion count %d, want 1", bag.Count("potion"))
+	}
+	// 取完后腾出格子
+	if !bag.Remove("potion", 1) {
+		t.Fatal("remove last potion failed")
+	}
+	if ids := pocketIDs(bag, PocketEnum.Items); !reflect.DeepEqual(ids, []string{"antidote"}) {
+		t.Fatalf("items pocket %v, want [antidote]", ids)
+	}
+}
+
+func TestBagCapacity(t *testing.T) {
+	useTestItems(t)
+	bag := NewBag()
+	capacity := PocketEnum.PokeBalls.Capacity()
+	for i := range capacity {
+		added, err := bag.Add(fmt.Sprintf("ball_%02d", i), 1)
+		if err != nil || added != 1 {
+			t.Fatalf("add ball %d: added %d, error %v", i, added, err)
+		}
+	}
+	full := fmt.Sprintf("ball_%02d", capacity)
+	if added, _ := bag.Add(full, 1); added != 0 {
+		t.Fatal("added item to full pocket")
+	}
+	// 已有的道具仍能堆叠，其他口袋不受影响
+	if added, _ := bag.Add("ball_00", 5); added != 5 {
+		t.Fatalf("stacked %d balls, want 5", added)
+	}
+	if added, _ := bag.Add("potion", 1); added != 1 {
+		t.Fatal("items pocket affected by full poke balls pocket")
+	}
+	if !bag.Remove("ball_01", 1) {
+		t.Fatal("remove ball failed")
+	}
+	if added, _ := bag.Add(full, 1); added != 1 {
+		t.Fatal("pocket still full after removing an item")
+	}
+}
+
+func TestNewBagFromCounts(t *testing.T) {
+	useTestItems(t)
+	counts := map[string]int{"potion": 2, "full_heal": 1, "antidote": 5, "bicycle": 1, "ball_03": 10}
+	bag, err := NewBagFromCounts(counts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := pocketIDs(bag, PocketEnum.Items); !reflect.DeepEqual(ids, []string{"antidote", "full_heal", "potion"}) {
+		t.Fatalf("items pocket %v, want sorted by id", ids)
+	}
+	if ids := pocketIDs(bag, PocketEnum.KeyItems); !reflect.DeepEqual(ids, []string{"bicycle"}) {
+		t.Fatalf("key items pocket %v", ids)
+	}
+	if got := bag.Counts(); !reflect.DeepEqual(got, counts) {
+		t.Fatalf("counts %v, want %v", got, counts)
+	}
+
+	if _, err = NewBagFromCounts(map[string]int{"potion": 1, "master_ball": 1}); err == nil {
+		t.Fatal("restored unknown item")
+	}
+}
+
+func TestCurable(t *testing.T) {
+	useTestItems(t)
+	tests := []struct {
+		id     string
+		status pokemon.StatusCondition
+		ok     bool
+	}{
+		{id: "antidote", status: pokemon.StatusConditionEnum.Poison, ok: true},
+		{id: "antidote", status: pokemon.StatusConditionEnum.BadPoison, ok: true},
+		{id: "antidote", status: pokemon.StatusConditionEnum.Sleep},
+		{id: "antidote", status: pokemon.StatusConditionEnum.None},
+		{id: "awakening", status: pokemon.StatusConditionEnum.Sleep, ok: true},
+		{id: "awakening", status: pokemon.StatusConditionEnum.BadPoison},
+		{id: "full_heal", status: pokemon.StatusConditionEnum.BadPoison, ok: true},
+		{id: "full_heal", status: pokemon.StatusConditionEnum.None},
+	}
+	for _, tt := range tests {
+		item, err := GetItem(tt.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := curable(item, tt.status); got != tt.ok {
+			t.Errorf("%s on %s: got %v, want %v", tt.id, tt.status, got, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package item
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/tnnmigga/enum"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kkkunny/pokemon/src/assets"
+	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/pokemon"
+	"github.com/kkkunny/pokemon/src/util/i18n"
+)
+
+// Pocket 背包口袋
+type Pocket string
+
+var PocketEnum = enum.New[struct {
+	Items     Pocket `enum:"items"`      // 道具
+	KeyItems  Pocket `enum:"key_items"`  // 重要物品
+	PokeBalls Pocket `enum:"poke_balls"` // 精灵球
+	TMsHMs    Pocket `enum:"tms_hms"`    // 招式学习器
+	Berries   Pocket `enum:"berries"`    // 树果
+}]()
+
+// Capacity 口袋能放的道具种类数
+func (p Pocket) Capacity() int {
+	switch p {
+	case PocketEnum.Items:
+		return 42
+	case PocketEnum.KeyItems:
+		return 30
+	case PocketEnum.PokeBalls:
+		return 13
+	case PocketEnum.TMsHMs:
+		return 58
+	case PocketEnum.Berries:
+		return 43
+	default:
+		return 0
+	}
+}
+
+// Item 道具
+type Item struct {
+	ID       string `yaml:"-"`
+	Pocket   Pocket `yaml:"pocket"`
+	Price    int    `yaml:"price"`    // 商店价格，0表示不能出售
+	MaxStack int    `yaml:"stack"`    // 单格堆叠上限，为空时为999
+	Field    string `yaml:"field"`    // 在野外使用的效果，为空表示不能在野外使用
+	Battle   string `yaml:"battle"`   // 在战斗中使用的效果，为空表示不能在战斗中使用
+	Amount   int    `yaml:"amount"`   // 效果数值，如回复量、捕获倍率
+	Status   string `yaml:"status"`   // 治愈的异常状态，all表示全部
+	Reusable bool   `yaml:"reusable"` // 使用后不消耗
+}
+
+// Name 道具名
+func (i *Item) Name(loc *i18n.Localisation) string {
+	return loc.Get("item."+i.ID, i.ID)
+}
+
+// Description 道具说明
+func (i *Item) Description(loc *i18n.Localisation) string {
+	return loc.Get("item." + i.ID + ".desc")
+}
+
+var itemCache map[string]*Item
+
+// 首次使用时载入道具表
+func loadItemCache() error {
+	if itemCache != nil {
+		return nil
+	}
+	items, err := LoadItems(config.ItemsPath)
+	if err != nil {
+		return err
+	}
+	itemCache = items
+	return nil
+}
+
+// GetItem 获取道具
+func GetItem(id string) (*Item, error) {
+	err := loadItemCache()
+	if err != nil {
+		return nil, err
+	}
+	item, ok := itemCache[id]
+	if !ok {
+		return nil, fmt.Errorf("not exist item, id=%s", id)
+	}
+	return item, nil
+}
+
+// Items 所有道具
+func Items() (map[string]*Item, error) {
+	err := loadItemCache()
+	if err != nil {
+		return nil, err
+	}
+	return itemCache, nil
+}
+
+// LoadItems 载入并校验道具表
+func LoadItems(path string) (map[string]*Item, error) {
+	file, err := assets.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	items := make(map[string]*Item)
+	err = decoder.Decode(&items)
+	if err != nil {
+		return nil, &pokemon.DefineError{File: path, Err: err}
+	}
+	for id, item := range items {
+		item.ID = id
+		err = item.validate()
+		if err != nil {
+			defineErr := err.(*pokemon.DefineError)
+			defineErr.File, defineErr.Field = path, id+"."+defineErr.Field
+			return nil, defineErr
+		}
+	}
+	return items, nil
+}
+
+func fieldError(field string, format string, a ...any) error {
+	return &pokemon.DefineError{Field: field, Err: fmt.Errorf(format, a...)}
+}
+
+func (i *Item) validate() error {
+	if !slices.Contains(enum.Values[Pocket](PocketEnum), i.Pocket) {
+		return fieldError("pocket", "unknown pocket `%s`", i.Pocket)
+	}
+	if i.Price < 0 {
+		return fieldError("price", "expect non-negative, got %d", i.Price)
+	}
+	if i.MaxStack == 0 {
+		i.MaxStack = 999
+	} else if i.MaxStack < 0 || i.MaxStack > 999 {
+		return fieldError("stack", "expect 1~999, got %d", i.MaxStack)
+	}
+	if _, ok := useHandlers[i.Field]; i.Field != "" && !ok {
+		return fieldError("field", "unknown effect `%s`", i.Field)
+	}
+	if _, ok := useHandlers[i.Battle]; i.Battle != "" && !ok {
+		return fieldError("battle", "unknown effect `%s`", i.Battle)
+	}
+	if i.Status != "" && i.Status != "all" {
+		if _, ok := pokemon.ParseStatusCondition(i.Status); !ok {
+			return fieldError("status", "unknown status `%s`", i.Status)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package item
+
+import (
+	"github.com/kkkunny/pokemon/src/pokemon"
+)
+
+func init() {
+	RegisterUseHandler("heal_hp", UseHandler{
+		NeedTarget: true,
+		Message:    "item.used.heal_hp",
+		CanUse: func(ctx *UseContext) bool {
+			return !ctx.Target.Fainted() && ctx.Target.HP < ctx.Target.MaxHP()
+		},
+		Use: func(ctx *UseContext) {
+			maxHP := ctx.Target.MaxHP()
+			if ctx.Item.Amount <= 0 {
+				ctx.Target.HP = maxHP
+			} else {
+				ctx.Target.HP = min(ctx.Target.HP+ctx.Item.Amount, maxHP)
+			}
+		},
+	})
+	RegisterUseHandler("cure_status", UseHandler{
+		NeedTarget: true,
+		Message:    "item.used.cure_status",
+		CanUse: func(ctx *UseContext) bool {
+			return !ctx.Target.Fainted() && curable(ctx.Item, ctx.Target.Status)
+		},
+		Use: func(ctx *UseContext) {
+			ctx.Target.Status = pokemon.StatusConditionEnum.None
+		},
+	})
+	RegisterUseHandler("full_restore", UseHandler{
+		NeedTarget: true,
+		Message:    "item.used.heal_hp",
+		CanUse: func(ctx *UseContext) bool {
+			return !ctx.Target.Fainted() && (ctx.Target.HP < ctx.Target.MaxHP() || ctx.Target.Status != pokemon.StatusConditionEnum.None)
+		},
+		Use: func(ctx *UseContext) {
+			ctx.Target.HP = ctx.Target.MaxHP()
+			ctx.Target.Status = pokemon.StatusConditionEnum.None
+		},
+	})
+	RegisterUseHandler("revive", UseHandler{
+		NeedTarget: true,
+		Message:    "item.used.revive",
+		CanUse: func(ctx *UseContext) bool {
+			return ctx.Target.Fainted()
+		},
+		Use: func(ctx *UseContext) {
+			ctx.Target.HP = max(ctx.Target.MaxHP()*ctx.Item.Amount/100, 1)
+			ctx.Target.Status = pokemon.StatusConditionEnum.None
+		},
+	})
+	RegisterUseHandler("restore_pp", UseHandler{
+		NeedTarget: true,
+		Message:    "item.used.restore_pp",
+		CanUse: func(ctx *UseContext) bool {
+			for _, slot := range ctx.Target.Moves {
+				if move, err := pokemon.GetMove(slot.Move); !slot.Empty() && err == nil && slot.PP < move.MaxPP(slot.PPUp) {
+					return true
+				}
+			}
+			return false
+		},
+		Use: func(ctx *UseContext) {
+			for i, slot := range ctx.Target.Moves {
+				move, err := pokemon.GetMove(slot.Move)
+				if slot.Empty() || err != nil {
+					continue
+				}
+				maxPP := move.MaxPP(slot.PPUp)
+				if ctx.Item.Amount <= 0 {
+					ctx.Target.Moves[i].PP = maxPP
+				} else {
+					ctx.Target.Moves[i].PP = min(slot.PP+ctx.Item.Amount, maxPP)
+				}
+			}
+		},
+	})
+}
+
+// UseContext 道具使用上下文
+type UseContext struct {
+	Item   *Item
+	Target *pokemon.Pokemon // 目标宝可梦，不需要目标时为空
+	Battle bool             // 是否在战斗中
+}
+
+// UseHandler 道具效果处理器
+type UseHandler struct {
+	NeedTarget bool                       // 是否需要选择队伍中的宝可梦
+	Message    string                     // 生效后的提示，参数为目标宝可梦的名字
+	CanUse     func(ctx *UseContext) bool // 能否生效，为空表示总能生效
+	Use        func(ctx *UseContext)      // 生效，为空表示由使用方自行处理，如战斗中投掷精灵球
+}
+
+var useHandlers = make(map[string]UseHandler)
+
+// RegisterUseHandler 注册道具效果
+func RegisterUseHandler(name string, handler UseHandler) {
+	useHandlers[name] = handler
+}
+
+// GetUseHandler 获取道具在野外或战斗中的效果
+func GetUseHandler(item *Item, battle bool) (UseHandler, bool) {
+	name := item.Field
+	if battle {
+		name = item.Battle
+	}
+	if name == "" {
+		return UseHandler{}, false
+	}
+	handler, ok := useHandlers[name]
+	return handler, ok
+}
+
+// CanUse 检查道具能否对目标生效
+func CanUse(ctx *UseContext) bool {
+	handler, ok := GetUseHandler(ctx.Item, ctx.Battle)
+	if !ok || handler.NeedTarget && ctx.Target == nil {
+		return false
+	}
+	return handler.CanUse == nil || handler.CanUse(ctx)
+}
+
+// Use 使用道具，未生效时返回false
+func Use(ctx *UseContext) bool {
+	if !CanUse(ctx) {
+		return false
+	}
+	handler, _ := GetUseHandler(ctx.Item, ctx.Battle)
+	if handler.Use != nil {
+		handler.Use(ctx)
+	}
+	return true
+}
+
+// 道具能否治愈某种异常状态
+func curable(item *Item, status pokemon.StatusCondition) bool {
+	if status == pokemon.StatusConditionEnum.None {
+		return false
+	} else if item.Status == "all" {
+		return true
+	}
+	target, _ := pokemon.ParseStatusCondition(item.Status)
+	// 解毒药也能治愈剧毒
+	return target == status || target == pokemon.StatusConditionEnum.Poison && status == pokemon.StatusConditionEnum.BadPoison
+}
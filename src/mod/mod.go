@@ -0,0 +1,161 @@
+package mod
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kkkunny/pokemon/src/assets"
+)
+
+// ManifestFileName mod根目录下的清单文件
+const ManifestFileName = "mod.yml"
+
+var idRegexp = regexp.MustCompile(`^[a-z0-9_\-.]+$`)
+
+// Manifest mod清单
+type Manifest struct {
+	ID           string   `yaml:"id"`           // 唯一标识，只能包含小写字母、数字、`_`、`-`、`.`
+	Name         string   `yaml:"name"`         // 显示名称
+	Version      string   `yaml:"version"`      // 版本
+	Dependencies []string `yaml:"dependencies"` // 依赖的mod，依赖会先于本mod载入
+	LoadOrder    int      `yaml:"load_order"`   // 载入顺序，越大越晚载入，晚载入的覆盖早载入的
+}
+
+// Mod 一个mod，内容与数据目录的结构相同，可以新增或覆盖地图、人物图像、宝可梦、脚本和翻译
+type Mod struct {
+	Manifest
+	Path string // mod所在的目录、zip文件或资源包
+	FS   fs.FS
+}
+
+// Scan 扫描mods目录，每个子目录、zip文件或资源包为一个mod，目录不存在时返回空
+func Scan(dir string) ([]*Mod, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var mods []*Mod
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		fsys, err := assets.Open(path)
+		if err != nil {
+			_ = Close(mods)
+			return nil, err
+		}
+		manifest, err := loadManifest(fsys)
+		if err != nil {
+			_ = assets.CloseLayers([]assets.Layer{{Name: path, FS: fsys}})
+			_ = Close(mods)
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		mods = append(mods, &Mod{Manifest: *manifest, Path: path, FS: fsys})
+	}
+	return mods, nil
+}
+
+func loadManifest(fsys fs.FS) (*Manifest, error) {
+	file, err := fsys.Open(ManifestFileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	var manifest Manifest
+	err = decoder.Decode(&manifest)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ManifestFileName, err)
+	} else if !idRegexp.MatchString(manifest.ID) {
+		return nil, fmt.Errorf("%s: invalid id `%s`", ManifestFileName, manifest.ID)
+	} else if manifest.Version == "" {
+		return nil, fmt.Errorf("%s: missing version", ManifestFileName)
+	}
+	return &manifest, nil
+}
+
+// Sort 按载入顺序排列mod，依赖总是先于依赖它的mod，其余按load_order和ID排列
+func Sort(mods []*Mod) ([]*Mod, error) {
+	byID := make(map[string]*Mod, len(mods))
+	for _, m := range mods {
+		if other, ok := byID[m.ID]; ok {
+			return nil, fmt.Errorf("mod `%s` is defined by both %s and %s", m.ID, other.Path, m.Path)
+		}
+		byID[m.ID] = m
+	}
+	for _, m := range mods {
+		for _, dep := range m.Dependencies {
+			if _, ok := byID[dep]; !ok {
+				return nil, fmt.Errorf("mod `%s` depends on missing mod `%s`", m.ID, dep)
+			}
+		}
+	}
+
+	pending := slices.Clone(mods)
+	slices.SortFunc(pending, func(a, b *Mod) int {
+		if a.LoadOrder != b.LoadOrder {
+			return a.LoadOrder - b.LoadOrder
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	loaded := make(map[string]bool, len(mods))
+	sorted := make([]*Mod, 0, len(mods))
+	for len(pending) > 0 {
+		i := slices.IndexFunc(pending, func(m *Mod) bool {
+			return !slices.ContainsFunc(m.Dependencies, func(dep string) bool { return !loaded[dep] })
+		})
+		if i < 0 {
+			ids := make([]string, len(pending))
+			for j, m := range pending {
+				ids[j] = m.ID
+			}
+			return nil, fmt.Errorf("dependency cycle among mods: %s", strings.Join(ids, ", "))
+		}
+		loaded[pending[i].ID] = true
+		sorted = append(sorted, pending[i])
+		pending = slices.Delete(pending, i, i+1)
+	}
+	return sorted, nil
+}
+
+// Load 扫描并排列mods目录下的mod，使用完后需Close
+func Load(dir string) ([]*Mod, error) {
+	mods, err := Scan(dir)
+	if err != nil {
+		return nil, err
+	}
+	sorted, err := Sort(mods)
+	if err != nil {
+		_ = Close(mods)
+		return nil, err
+	}
+	return sorted, nil
+}
+
+// Close 关闭mod打开的zip文件和资源包
+func Close(mods []*Mod) error {
+	return assets.CloseLayers(Layers(mods))
+}
+
+// Layers mod对应的资源层
+func Layers(mods []*Mod) []assets.Layer {
+	layers := make([]assets.Layer, len(mods))
+	for i, m := range mods {
+		layers[i] = assets.Layer{Name: m.ID, FS: m.FS}
+	}
+	return layers
+}
@@ -0,0 +1,196 @@
+package mod
+
+import (
+	"archive/zip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newTestMod(id string, loadOrder int, deps ...string) *Mod {
+	return &Mod{Manifest: Manifest{ID: id, Version: "1.0", Dependencies: deps, LoadOrder: loadOrder}, Path: id}
+}
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		name  string
+		mods  []*Mod
+		order []string // 期望的载入顺序
+		err   string   // 期望错误包含的内容
+	}{
+		{
+			name:  "load_order_and_id",
+			mods:  []*Mod{newTestMod("c", 0), newTestMod("b", 1), newTestMod("a", 0), newTestMod("z", -1)},
+			order: []string{"z", "a", "c", "b"},
+		},
+		{
+			// 依赖先于依赖它的mod，即使load_order更大
+			name:  "dependency_first",
+			mods:  []*Mod{newTestMod("base", 5), newTestMod("addon", 0, "base"), newTestMod("other", 1)},
+			order: []string{"other", "base", "addon"},
+		},
+		{
+			name:  "chain",
+			mods:  []*Mod{newTestMod("c", 0, "b"), newTestMod("b", 0, "a"), newTestMod("a", 0)},
+			order: []string{"a", "b", "c"},
+		},
+		{
+			name:  "shared_dependency",
+			mods:  []*Mod{newTestMod("x", 2, "lib"), newTestMod("y", 1, "lib"), newTestMod("lib", 3)},
+			order: []string{"lib", "y", "x"},
+		},
+		{name: "empty"},
+		{
+			name: "cycle",
+			mods: []*Mod{newTestMod("a", 0, "b"), newTestMod("b", 0, "a"), newTestMod("c", 0)},
+			err:  "dependency cycle among mods: a, b",
+		},
+		{
+			name: "self_dependency",
+			mods: []*Mod{newTestMod("a", 0, "a")},
+			err:  "dependency cycle among mods: a",
+		},
+		{
+			name: "missing_dependency",
+			mods: []*Mod{newTestMod("a", 0, "lib")},
+			err:  "mod `a` depends on missing mod `lib`",
+		},
+		{
+			name: "duplicate_id",
+			mods: []*Mod{newTestMod("a", 0), newTestMod("a", 1)},
+			err:  "mod `a` is defined by both",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted, err := Sort(tt.mods)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]string, len(sorted))
+			for i, m := range sorted {
+				ids[i] = m.ID
+			}
+			if !slices.Equal(ids, tt.order) {
+				t.Fatalf("order %v, want %v", ids, tt.order)
+			}
+		})
+	}
+}
+
+func writeZipMod(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(file)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		if err == nil {
+			_, err = fw.Write([]byte(content))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = w.Close()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(dir, "base"), 0o755)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "base", ManifestFileName), []byte("id: base\nversion: '1.0'\nload_order: 1\n"), 0o644)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeZipMod(t, filepath.Join(dir, "addon.zip"), map[string]string{
+		ManifestFileName:   "id: addon\nversion: '0.1'\ndependencies: [base]\n",
+		"script/hello.lua": "print('hello')\n",
+	})
+
+	mods, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mods) != 2 || mods[0].ID != "base" || mods[1].ID != "addon" {
+		t.Fatalf("loaded %v", mods)
+	}
+	if _, err = fs.ReadFile(mods[1].FS, "script/hello.lua"); err != nil {
+		t.Fatal(err)
+	}
+	// 关闭后zip文件不能再读取
+	err = Close(mods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.ReadFile(mods[1].FS, "script/hello.lua"); err == nil {
+		t.Fatal("zip mod still readable after close")
+	}
+
+	// 缺少依赖时不返回mod
+	err = os.Remove(filepath.Join(dir, "base", ManifestFileName))
+	if err == nil {
+		err = os.Remove(filepath.Join(dir, "base"))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Load(dir); err == nil || !strings.Contains(err.Error(), "missing mod `base`") {
+		t.Fatalf("error %v, want missing dependency", err)
+	}
+
+	if mods, err = Load(filepath.Join(dir, "missing")); err != nil || len(mods) != 0 {
+		t.Fatalf("loaded %v, error %v from missing directory", mods, err)
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		err      string
+	}{
+		{name: "valid", manifest: "id: my-mod.v2\nname: My Mod\nversion: '2'\n"},
+		{name: "invalid_id", manifest: "id: My Mod\nversion: '1'\n", err: "invalid id `My Mod`"},
+		{name: "missing_version", manifest: "id: a\n", err: "missing version"},
+		{name: "unknown_field", manifest: "id: a\nversion: '1'\nauthor: me\n", err: "field author not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			err := os.MkdirAll(filepath.Join(dir, "a"), 0o755)
+			if err == nil {
+				err = os.WriteFile(filepath.Join(dir, "a", ManifestFileName), []byte(tt.manifest), 0o644)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Scan(dir)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
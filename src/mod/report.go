@@ -0,0 +1,77 @@
+package mod
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kkkunny/pokemon/src/assets"
+	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/util/i18n"
+)
+
+// Conflict 被多个层提供的资源，最后一层生效
+type Conflict struct {
+	Asset  string   // 资源路径，翻译为 `<语言>:<键>`
+	Owners []string // 提供该资源的层，从底到顶
+}
+
+// Winner 生效的层
+func (c Conflict) Winner() string {
+	return c.Owners[len(c.Owners)-1]
+}
+
+// Conflicts 列出被mod覆盖的资源，翻译文件按键合并，因此按键列出
+func Conflicts(overlay *assets.Overlay, lang i18n.Language) ([]Conflict, error) {
+	layers := overlay.Layers()
+	owners := make(map[string][]string)
+	for _, layer := range layers {
+		err := fs.WalkDir(layer.FS, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || name == ManifestFileName || strings.HasPrefix(name, config.LocalisationPath+"/") {
+				return err
+			}
+			owners[name] = append(owners[name], layer.Name)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", layer.Name, err)
+		}
+
+		kvs, err := i18n.LoadKeys(layer.FS, lang)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%s: %w", layer.Name, err)
+		}
+		for key := range kvs {
+			asset := fmt.Sprintf("%s:%s", lang, key)
+			owners[asset] = append(owners[asset], layer.Name)
+		}
+	}
+
+	var conflicts []Conflict
+	for _, asset := range slices.Sorted(maps.Keys(owners)) {
+		if len(owners[asset]) > 1 {
+			conflicts = append(conflicts, Conflict{Asset: asset, Owners: owners[asset]})
+		}
+	}
+	return conflicts, nil
+}
+
+// WriteReport 写入mod载入顺序和冲突报告
+func WriteReport(w io.Writer, mods []*Mod, conflicts []Conflict) error {
+	var b strings.Builder
+	b.WriteString("# load order\n")
+	for i, m := range mods {
+		fmt.Fprintf(&b, "%d. %s %s (%s)\n", i+1, m.ID, m.Version, filepath.Base(m.Path))
+	}
+	fmt.Fprintf(&b, "\n# conflicts (%d)\n", len(conflicts))
+	for _, c := range conflicts {
+		fmt.Fprintf(&b, "%s: %s wins over %s\n", c.Asset, c.Winner(), strings.Join(c.Owners[:len(c.Owners)-1], ", "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
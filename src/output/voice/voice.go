@@ -1,24 +1,42 @@
 package voice
 
 import (
+	"bytes"
 	"io"
-	"os"
 
 	"github.com/hajimehoshi/ebiten/v2/audio"
 	"github.com/hajimehoshi/ebiten/v2/audio/vorbis"
+
+	"github.com/kkkunny/pokemon/src/assets"
 )
 
+var enabled = true
+
+// SetEnabled 设置是否启用声音，无窗口运行时关闭以免打开音频设备，需要在创建播放器之前调用
+func SetEnabled(v bool) {
+	enabled = v
+}
+
 type Player struct {
 	ctx     *audio.Context
 	path    string
-	file    *os.File
 	decoder io.Reader
 	player  *audio.Player
+	volume  float64
 }
 
 func NewPlayer() *Player {
+	if !enabled {
+		return &Player{volume: 1}
+	}
+	// 一个进程只能创建一个音频上下文，多个播放器共用
+	ctx := audio.CurrentContext()
+	if ctx == nil {
+		ctx = audio.NewContext(44100)
+	}
 	return &Player{
-		ctx: audio.NewContext(44100),
+		ctx:    ctx,
+		volume: 1,
 	}
 }
 
@@ -30,16 +48,12 @@ func (p *Player) Close() error {
 	if err != nil {
 		return err
 	}
-	err = p.file.Close()
-	if err != nil {
-		return err
-	}
 	p.path = ""
 	return nil
 }
 
 func (p *Player) LoadFile(path string) error {
-	if p.path == path {
+	if p.ctx == nil || p.path == path {
 		return nil
 	}
 	err := p.Close()
@@ -47,11 +61,12 @@ func (p *Player) LoadFile(path string) error {
 		return err
 	}
 
-	file, err := os.Open(path)
+	// 资源可能位于不支持Seek的zip中，读入内存以便重新播放
+	data, err := assets.ReadFile(path)
 	if err != nil {
 		return err
 	}
-	decoder, err := vorbis.DecodeWithoutResampling(file)
+	decoder, err := vorbis.DecodeWithoutResampling(bytes.NewReader(data))
 	if err != nil {
 		return err
 	}
@@ -59,16 +74,29 @@ func (p *Player) LoadFile(path string) error {
 	if err != nil {
 		return err
 	}
-	p.file, p.decoder, p.player, p.path = file, decoder, player, path
+	player.SetVolume(p.volume)
+	p.decoder, p.player, p.path = decoder, player, path
 	return nil
 }
 
+// SetVolume 设置音量，范围0~1，之后载入的文件也使用该音量
+func (p *Player) SetVolume(v float64) {
+	p.volume = min(max(v, 0), 1)
+	if p.player != nil {
+		p.player.SetVolume(p.volume)
+	}
+}
+
+func (p *Player) Volume() float64 {
+	return p.volume
+}
+
 func (p *Player) IsPlaying() bool {
 	return p.player != nil && p.player.IsPlaying()
 }
 
 func (p *Player) Play() error {
-	if p.IsPlaying() {
+	if p.path == "" || p.IsPlaying() {
 		return nil
 	}
 	p.ContinuePlay()
@@ -0,0 +1,200 @@
+package pokemon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/tnnmigga/enum"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kkkunny/pokemon/src/assets"
+)
+
+// 种族定义文件名
+const defineFileName = "define.yml"
+
+// 蛋群
+var eggGroups = []string{
+	"monster", "water1", "bug", "flying", "field", "fairy", "grass", "human_like",
+	"water3", "mineral", "amorphous", "water2", "ditto", "dragon", "undiscovered",
+}
+
+// EvolutionMethod 进化方式
+type EvolutionMethod string
+
+var EvolutionMethodEnum = enum.New[struct {
+	Level      EvolutionMethod `enum:"level"`      // 升级
+	Item       EvolutionMethod `enum:"item"`       // 使用道具
+	Trade      EvolutionMethod `enum:"trade"`      // 通信交换
+	Friendship EvolutionMethod `enum:"friendship"` // 亲密度
+}]()
+
+// LearnsetEntry 升级习得的技能
+type LearnsetEntry struct {
+	Level int    `yaml:"level"`
+	Move  string `yaml:"move"`
+}
+
+// Evolution 进化规则
+type Evolution struct {
+	Method EvolutionMethod `yaml:"method"`
+	Into   int16           `yaml:"into"`  // 进化后的图鉴编号
+	Level  int             `yaml:"level"` // 升级进化所需等级
+	Item   string          `yaml:"item"`  // 进化所需道具
+}
+
+// SpeciesDefine 种族定义文件内容
+type SpeciesDefine struct {
+	Types       []string        `yaml:"types"`
+	BaseStats   Stats           `yaml:"base_stats"`
+	CatchRate   int             `yaml:"catch_rate"`
+	BaseExp     int             `yaml:"base_exp"`
+	GrowthRate  GrowthRate      `yaml:"growth_rate"`
+	GenderRatio *float64        `yaml:"gender_ratio"` // 雌性比例（百分比），为空表示无性别
+	EggGroups   []string        `yaml:"egg_groups"`
+	Abilities   []string        `yaml:"abilities"`
+	Learnset    []LearnsetEntry `yaml:"learnset"`
+	Evolutions  []Evolution     `yaml:"evolutions"`
+}
+
+// DefineError 种族定义文件错误
+type DefineError struct {
+	File  string
+	Field string
+	Err   error
+}
+
+func (e *DefineError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %s", e.File, e.Err)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.File, e.Field, e.Err)
+}
+
+func (e *DefineError) Unwrap() error {
+	return e.Err
+}
+
+// LoadSpeciesDefine 载入并校验种族定义文件
+func LoadSpeciesDefine(path string) (*SpeciesDefine, error) {
+	file, err := assets.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return decodeSpeciesDefine(path, file)
+}
+
+func decodeSpeciesDefine(path string, r io.Reader) (*SpeciesDefine, error) {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	var define SpeciesDefine
+	err := decoder.Decode(&define)
+	if err != nil {
+		return nil, &DefineError{File: path, Err: err}
+	}
+	err = define.validate()
+	if err != nil {
+		var defineErr *DefineError
+		if errors.As(err, &defineErr) {
+			defineErr.File = path
+		}
+		return nil, err
+	}
+	return &define, nil
+}
+
+func fieldError(field string, format string, a ...any) error {
+	return &DefineError{Field: field, Err: fmt.Errorf(format, a...)}
+}
+
+func (d *SpeciesDefine) validate() error {
+	// 属性
+	if len(d.Types) == 0 || len(d.Types) > 2 {
+		return fieldError("types", "expect 1 or 2 types, got %d", len(d.Types))
+	}
+	for i, name := range d.Types {
+		t, ok := ParseType(name)
+		if !ok || t == TypeEnum.Unknown || t == TypeEnum.None {
+			return fieldError(fmt.Sprintf("types[%d]", i), "unknown type `%s`", name)
+		}
+	}
+	// 种族值
+	for _, stat := range enum.Values[Stat](StatEnum) {
+		if v := d.BaseStats.Get(stat); v <= 0 || v > 255 {
+			return fieldError("base_stats."+stat.String(), "expect 1~255, got %d", v)
+		}
+	}
+	if d.CatchRate <= 0 || d.CatchRate > 255 {
+		return fieldError("catch_rate", "expect 1~255, got %d", d.CatchRate)
+	}
+	if d.BaseExp <= 0 {
+		return fieldError("base_exp", "expect positive, got %d", d.BaseExp)
+	}
+	if !slices.Contains(enum.Values[GrowthRate](GrowthRateEnum), d.GrowthRate) {
+		return fieldError("growth_rate", "unknown growth rate `%s`", d.GrowthRate)
+	}
+	if d.GenderRatio != nil && (*d.GenderRatio < 0 || *d.GenderRatio > 100) {
+		return fieldError("gender_ratio", "expect 0~100, got %v", *d.GenderRatio)
+	}
+	if len(d.EggGroups) == 0 || len(d.EggGroups) > 2 {
+		return fieldError("egg_groups", "expect 1 or 2 egg groups, got %d", len(d.EggGroups))
+	}
+	for i, group := range d.EggGroups {
+		if !slices.Contains(eggGroups, group) {
+			return fieldError(fmt.Sprintf("egg_groups[%d]", i), "unknown egg group `%s`", group)
+		}
+	}
+	if len(d.Abilities) == 0 || len(d.Abilities) > 2 {
+		return fieldError("abilities", "expect 1 or 2 abilities, got %d", len(d.Abilities))
+	}
+	for i, ability := range d.Abilities {
+		if ability == "" {
+			return fieldError(fmt.Sprintf("abilities[%d]", i), "empty ability")
+		}
+	}
+	// 技能表
+	for i, entry := range d.Learnset {
+		if entry.Level < 1 || entry.Level > MaxLevel {
+			return fieldError(fmt.Sprintf("learnset[%d].level", i), "expect 1~%d, got %d", MaxLevel, entry.Level)
+		} else if entry.Move == "" {
+			return fieldError(fmt.Sprintf("learnset[%d].move", i), "empty move")
+		}
+		if i > 0 && entry.Level < d.Learnset[i-1].Level {
+			return fieldError(fmt.Sprintf("learnset[%d].level", i), "learnset must be sorted by level")
+		}
+	}
+	// 进化
+	for i, evo := range d.Evolutions {
+		field := fmt.Sprintf("evolutions[%d]", i)
+		if evo.Into <= 0 {
+			return fieldError(field+".into", "expect positive id, got %d", evo.Into)
+		}
+		switch evo.Method {
+		case EvolutionMethodEnum.Level:
+			if evo.Level < 1 || evo.Level > MaxLevel {
+				return fieldError(field+".level", "expect 1~%d, got %d", MaxLevel, evo.Level)
+			}
+		case EvolutionMethodEnum.Item:
+			if evo.Item == "" {
+				return fieldError(field+".item", "empty item")
+			}
+		case EvolutionMethodEnum.Trade, EvolutionMethodEnum.Friendship:
+		default:
+			return fieldError(field+".method", "unknown evolution method `%s`", evo.Method)
+		}
+	}
+	return nil
+}
+
+// Type 合并后的属性
+func (d *SpeciesDefine) Type() Type {
+	var t Type
+	for _, name := range d.Types {
+		v, _ := ParseType(name)
+		t |= v
+	}
+	return t
+}
@@ -0,0 +1,83 @@
+package pokemon
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kkkunny/pokemon/src/assets"
+)
+
+const testDefine = `types: [grass, poison]
+base_stats: {hp: 45, attack: 49, defense: 49, sp_attack: 65, sp_defense: 65, speed: 45}
+catch_rate: 45
+base_exp: 64
+growth_rate: medium_slow
+gender_ratio: 12.5
+egg_groups: [monster, grass]
+abilities: [overgrow]
+learnset:
+  - {level: 1, move: tackle}
+  - {level: 4, move: growl}
+evolutions:
+  - {method: level, level: 16, into: 2}
+`
+
+func TestLoadSpeciesDefine(t *testing.T) {
+	const path = "pokemons/1/define.yml"
+	tests := []struct {
+		name     string
+		old, new string // 在合法定义上的替换
+		field    string // 期望出错的项，为空时表示解析错误
+		err      string // 期望错误包含的内容，为空时应载入成功
+	}{
+		{name: "valid"},
+		{name: "genderless", old: "gender_ratio: 12.5\n"},
+		{name: "unknown_type", old: "[grass, poison]", new: "[grass, wood]", field: "types[1]", err: "unknown type `wood`"},
+		{name: "too_many_types", old: "[grass, poison]", new: "[grass, poison, fire]", field: "types", err: "expect 1 or 2 types, got 3"},
+		{name: "base_stat_zero", old: "speed: 45}", new: "speed: 0}", field: "base_stats.speed", err: "expect 1~255, got 0"},
+		{name: "catch_rate", old: "catch_rate: 45", new: "catch_rate: 256", field: "catch_rate"},
+		{name: "growth_rate", old: "medium_slow", new: "very_slow", field: "growth_rate", err: "unknown growth rate `very_slow`"},
+		{name: "gender_ratio", old: "12.5", new: "101", field: "gender_ratio"},
+		{name: "egg_group", old: "[monster, grass]", new: "[monster, plant]", field: "egg_groups[1]"},
+		{name: "no_ability", old: "[overgrow]", new: "[]", field: "abilities"},
+		{name: "learnset_level", old: "{level: 4,", new: "{level: 101,", field: "learnset[1].level", err: "expect 1~100, got 101"},
+		{name: "learnset_unsorted", old: "{level: 1,", new: "{level: 5,", field: "learnset[1].level", err: "sorted by level"},
+		{name: "learnset_move", old: "move: growl", new: "move: ''", field: "learnset[1].move"},
+		{name: "evolution_method", old: "method: level", new: "method: stone", field: "evolutions[0].method", err: "unknown evolution method `stone`"},
+		{name: "evolution_level", old: "level: 16,", new: "level: 0,", field: "evolutions[0].level"},
+		{name: "evolution_into", old: "into: 2", new: "into: 0", field: "evolutions[0].into"},
+		{name: "unknown_field", old: "abilities:", new: "ability:", err: "field ability not found"},
+		{name: "wrong_kind", old: "catch_rate: 45", new: "catch_rate: high", err: "cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := strings.Replace(testDefine, tt.old, tt.new, 1)
+			if content == testDefine && tt.old != "" {
+				t.Fatalf("`%s` not in define", tt.old)
+			}
+			assets.Mount(fstest.MapFS{path: {Data: []byte(content)}})
+			define, err := LoadSpeciesDefine(path)
+			if tt.field == "" && tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if define.Type() != TypeEnum.Grass|TypeEnum.Poison || define.BaseStats.SpAttack != 65 {
+					t.Fatalf("loaded %+v", define)
+				}
+				return
+			}
+			var defineErr *DefineError
+			if !errors.As(err, &defineErr) {
+				t.Fatalf("error %v, want DefineError", err)
+			}
+			if defineErr.File != path || defineErr.Field != tt.field {
+				t.Fatalf("error on %s: %s, want %s: %s", defineErr.File, defineErr.Field, path, tt.field)
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package pokemon
+
+import "github.com/tnnmigga/enum"
+
+// Gender 性别
+type Gender uint8
+
+var GenderEnum = enum.New[struct {
+	Genderless Gender // 无性别
+	Male       Gender // 雄性
+	Female     Gender // 雌性
+}]()
+
+// Symbol 性别符号
+func (g Gender) Symbol() string {
+	switch g {
+	case GenderEnum.Male:
+		return "♂"
+	case GenderEnum.Female:
+		return "♀"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,63 @@
+package pokemon
+
+import "github.com/tnnmigga/enum"
+
+// GrowthRate 经验成长类型
+type GrowthRate string
+
+var GrowthRateEnum = enum.New[struct {
+	Fast        GrowthRate `enum:"fast"`        // 快
+	MediumFast  GrowthRate `enum:"medium_fast"` // 较快
+	MediumSlow  GrowthRate `enum:"medium_slow"` // 较慢
+	Slow        GrowthRate `enum:"slow"`        // 慢
+	Erratic     GrowthRate `enum:"erratic"`     // 最快
+	Fluctuating GrowthRate `enum:"fluctuating"` // 最慢
+}]()
+
+// ExpForLevel 升到某等级所需的总经验值
+func (g GrowthRate) ExpForLevel(level int) int {
+	if level <= 1 {
+		return 0
+	}
+	n := level
+	n3 := n * n * n
+	switch g {
+	case GrowthRateEnum.Fast:
+		return 4 * n3 / 5
+	case GrowthRateEnum.MediumSlow:
+		return 6*n3/5 - 15*n*n + 100*n - 140
+	case GrowthRateEnum.Slow:
+		return 5 * n3 / 4
+	case GrowthRateEnum.Erratic:
+		switch {
+		case n <= 50:
+			return n3 * (100 - n) / 50
+		case n <= 68:
+			return n3 * (150 - n) / 100
+		case n <= 98:
+			return n3 * ((1911 - 10*n) / 3) / 500
+		default:
+			return n3 * (160 - n) / 100
+		}
+	case GrowthRateEnum.Fluctuating:
+		switch {
+		case n <= 15:
+			return n3 * ((n+1)/3 + 24) / 50
+		case n <= 36:
+			return n3 * (n + 14) / 50
+		default:
+			return n3 * (n/2 + 32) / 50
+		}
+	default:
+		return n3
+	}
+}
+
+// LevelForExp 某经验值对应的等级
+func (g GrowthRate) LevelForExp(exp int) int {
+	level := 1
+	for level < MaxLevel && g.ExpForLevel(level+1) <= exp {
+		level++
+	}
+	return level
+}
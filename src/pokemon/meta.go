@@ -1,33 +1,90 @@
 package pokemon
 
 import (
+	"errors"
 	"fmt"
 	"image/gif"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
 
+	"github.com/kkkunny/pokemon/src/assets"
 	"github.com/kkkunny/pokemon/src/config"
 	"github.com/kkkunny/pokemon/src/util/animation"
 )
 
 // PokemonRace 宝可梦种族
 type PokemonRace struct {
-	ID    int16                // 图鉴编号
-	Type  Type                 // 属性
-	Front *animation.Animation // 战斗正面图
-	Back  *animation.Animation // 战斗背面图
+	ID          int16                // 图鉴编号
+	Type        Type                 // 属性
+	BaseStats   Stats                // 种族值
+	CatchRate   int                  // 捕获率
+	BaseExp     int                  // 基础经验值
+	GrowthRate  GrowthRate           // 经验成长类型
+	GenderRatio float64              // 雌性比例（百分比），小于0表示无性别
+	EggGroups   []string             // 蛋群
+	Abilities   []string             // 特性
+	Learnset    []LearnsetEntry      // 升级技能表
+	Evolutions  []Evolution          // 进化规则
+	Front       *animation.Animation // 战斗正面图
+	Back        *animation.Animation // 战斗背面图
+}
+
+var raceCache = make(map[int16]*PokemonRace)
+
+// GetPokemonRace 获取种族，已载入的种族会被缓存
+func GetPokemonRace(id int16) (*PokemonRace, error) {
+	if race, ok := raceCache[id]; ok {
+		return race, nil
+	}
+	race, err := NewPokemonRace(id)
+	if err != nil {
+		return nil, err
+	}
+	raceCache[id] = race
+	return race, nil
+}
+
+// SpeciesIDs 所有已定义种族的图鉴编号，从小到大排列
+func SpeciesIDs() ([]int16, error) {
+	entries, err := assets.ReadDir(config.PokemonDefinePath)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int16, 0, len(entries))
+	for _, entry := range entries {
+		id, err := strconv.ParseInt(entry.Name(), 10, 16)
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		ids = append(ids, int16(id))
+	}
+	slices.Sort(ids)
+	return ids, nil
 }
 
 func NewPokemonRace(id int16) (*PokemonRace, error) {
-	dirpath := filepath.Join(config.PokemonDefinePath, fmt.Sprintf("%d", id))
-	dirinfo, err := os.Stat(dirpath)
-	if (err != nil && os.IsNotExist(err)) || (err == nil && !dirinfo.IsDir()) {
+	dirpath := path.Join(config.PokemonDefinePath, fmt.Sprintf("%d", id))
+	dirinfo, err := assets.Stat(dirpath)
+	if (err != nil && errors.Is(err, fs.ErrNotExist)) || (err == nil && !dirinfo.IsDir()) {
 		return nil, fmt.Errorf("not exist pokemon, id=%d", id)
 	} else if err != nil {
 		return nil, err
 	}
 
-	frontFile, err := os.Open(filepath.Join(dirpath, "front.gif"))
+	definePath := path.Join(dirpath, defineFileName)
+	define, err := LoadSpeciesDefine(definePath)
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range define.Learnset {
+		if _, err = GetMove(entry.Move); err != nil {
+			return nil, &DefineError{File: definePath, Field: fmt.Sprintf("learnset[%d].move", i), Err: err}
+		}
+	}
+
+	frontFile, err := assets.OpenFile(path.Join(dirpath, "front.gif"))
 	if err != nil {
 		return nil, err
 	}
@@ -37,7 +94,7 @@ func NewPokemonRace(id int16) (*PokemonRace, error) {
 		return nil, err
 	}
 
-	backFile, err := os.Open(filepath.Join(dirpath, "back.gif"))
+	backFile, err := assets.OpenFile(path.Join(dirpath, "back.gif"))
 	if err != nil {
 		return nil, err
 	}
@@ -46,9 +103,54 @@ func NewPokemonRace(id int16) (*PokemonRace, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	genderRatio := float64(-1)
+	if define.GenderRatio != nil {
+		genderRatio = *define.GenderRatio
+	}
 	return &PokemonRace{
-		ID:    id,
-		Front: animation.NewAnimationFromGIF(frontGif),
-		Back:  animation.NewAnimationFromGIF(backGif),
+		ID:          id,
+		Type:        define.Type(),
+		BaseStats:   define.BaseStats,
+		CatchRate:   define.CatchRate,
+		BaseExp:     define.BaseExp,
+		GrowthRate:  define.GrowthRate,
+		GenderRatio: genderRatio,
+		EggGroups:   define.EggGroups,
+		Abilities:   define.Abilities,
+		Learnset:    define.Learnset,
+		Evolutions:  define.Evolutions,
+		Front:       animation.NewAnimationFromGIF(frontGif),
+		Back:        animation.NewAnimationFromGIF(backGif),
 	}, nil
 }
+
+// Genderless 是否无性别
+func (r *PokemonRace) Genderless() bool {
+	return r.GenderRatio < 0
+}
+
+// MovesAtLevel 到达某等级时能记住的最后几个技能
+func (r *PokemonRace) MovesAtLevel(level int) []string {
+	var moves []string
+	for _, entry := range r.Learnset {
+		if entry.Level > level {
+			break
+		}
+		moves = append(moves, entry.Move)
+	}
+	if len(moves) > MaxMoves {
+		moves = moves[len(moves)-MaxMoves:]
+	}
+	return moves
+}
+
+// EvolutionAtLevel 升到某等级时触发的进化
+func (r *PokemonRace) EvolutionAtLevel(level int) (Evolution, bool) {
+	for _, evo := range r.Evolutions {
+		if evo.Method == EvolutionMethodEnum.Level && level >= evo.Level {
+			return evo, true
+		}
+	}
+	return Evolution{}, false
+}
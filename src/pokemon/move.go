@@ -0,0 +1,234 @@
+package pokemon
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/tnnmigga/enum"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kkkunny/pokemon/src/assets"
+	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/util/i18n"
+)
+
+// MoveCategory 技能分类
+type MoveCategory string
+
+var MoveCategoryEnum = enum.New[struct {
+	Physical MoveCategory `enum:"physical"` // 物理
+	Special  MoveCategory `enum:"special"`  // 特殊
+	Status   MoveCategory `enum:"status"`   // 变化
+}]()
+
+// MoveTarget 技能目标
+type MoveTarget string
+
+var MoveTargetEnum = enum.New[struct {
+	Opponent MoveTarget `enum:"opponent"` // 对手
+	Self     MoveTarget `enum:"self"`     // 自己
+	All      MoveTarget `enum:"all"`      // 场上全体
+}]()
+
+// 能力等级可变化的项，在六项能力之外还有命中和闪避
+var stageStats = []string{"attack", "defense", "sp_attack", "sp_defense", "speed", "accuracy", "evasion"}
+
+// MoveEffect 技能追加效果，具体行为由战斗中注册的效果处理器决定
+type MoveEffect struct {
+	Type       string          `yaml:"type"`     // 效果类型，对应战斗中注册的处理器
+	Chance     int             `yaml:"chance"`   // 触发概率（百分比），0表示必定触发
+	Target     MoveTarget      `yaml:"target"`   // 效果目标，为空时同技能目标
+	StatusName string          `yaml:"status"`   // 异常状态名
+	Status     StatusCondition `yaml:"-"`        // 异常状态
+	Stat       string          `yaml:"stat"`     // 能力项
+	Stages     int             `yaml:"stages"`   // 能力等级变化
+	Ratio      int             `yaml:"ratio"`    // 比例（百分比），用于反伤、吸取、回复
+	MinHits    int             `yaml:"min_hits"` // 最少攻击次数
+	MaxHits    int             `yaml:"max_hits"` // 最多攻击次数
+}
+
+// Move 技能
+type Move struct {
+	ID       string       `yaml:"-"`
+	TypeName string       `yaml:"type"` // 属性名
+	Type     Type         `yaml:"-"`
+	Category MoveCategory `yaml:"category"`
+	Power    int          `yaml:"power"`
+	Accuracy int          `yaml:"accuracy"` // 命中，0表示必中
+	PP       int          `yaml:"pp"`
+	Priority int          `yaml:"priority"`
+	Target   MoveTarget   `yaml:"target"`
+	Effects  []MoveEffect `yaml:"effects"`
+}
+
+// Name 技能名
+func (m *Move) Name(loc *i18n.Localisation) string {
+	return loc.Get("move."+m.ID, m.ID)
+}
+
+// Description 技能说明
+func (m *Move) Description(loc *i18n.Localisation) string {
+	return loc.Get("move." + m.ID + ".desc")
+}
+
+// MaxPP 使用若干次PP提升剂后的PP上限
+func (m *Move) MaxPP(ppUp int) int {
+	return m.PP * (5 + ppUp) / 5
+}
+
+// EffectTarget 效果的实际目标
+func (m *Move) EffectTarget(effect MoveEffect) MoveTarget {
+	if effect.Target != "" {
+		return effect.Target
+	}
+	return m.Target
+}
+
+var moveCache map[string]*Move
+
+// 首次使用时载入技能表
+func loadMoveCache() error {
+	if moveCache != nil {
+		return nil
+	}
+	moves, err := LoadMoves(config.MovesPath)
+	if err != nil {
+		return err
+	}
+	moveCache = moves
+	return nil
+}
+
+// GetMove 获取技能
+func GetMove(id string) (*Move, error) {
+	err := loadMoveCache()
+	if err != nil {
+		return nil, err
+	}
+	move, ok := moveCache[id]
+	if !ok {
+		return nil, fmt.Errorf("not exist move, id=%s", id)
+	}
+	return move, nil
+}
+
+// Moves 所有技能
+func Moves() (map[string]*Move, error) {
+	err := loadMoveCache()
+	if err != nil {
+		return nil, err
+	}
+	return moveCache, nil
+}
+
+// LoadMoves 载入并校验技能表
+func LoadMoves(path string) (map[string]*Move, error) {
+	file, err := assets.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	moves := make(map[string]*Move)
+	err = decoder.Decode(&moves)
+	if err != nil {
+		return nil, &DefineError{File: path, Err: err}
+	}
+	for id, move := range moves {
+		move.ID = id
+		err = move.validate()
+		if err != nil {
+			defineErr := err.(*DefineError)
+			defineErr.File, defineErr.Field = path, id+"."+defineErr.Field
+			return nil, defineErr
+		}
+	}
+	return moves, nil
+}
+
+// ParseStatusCondition 按名字解析异常状态
+func ParseStatusCondition(s string) (StatusCondition, bool) {
+	switch s {
+	case "sleep":
+		return StatusConditionEnum.Sleep, true
+	case "poison":
+		return StatusConditionEnum.Poison, true
+	case "bad_poison":
+		return StatusConditionEnum.BadPoison, true
+	case "burn":
+		return StatusConditionEnum.Burn, true
+	case "freeze":
+		return StatusConditionEnum.Freeze, true
+	case "paralysis":
+		return StatusConditionEnum.Paralysis, true
+	default:
+		return StatusConditionEnum.None, false
+	}
+}
+
+func (m *Move) validate() error {
+	t, ok := ParseType(m.TypeName)
+	if !ok || t == TypeEnum.Unknown || t == TypeEnum.None {
+		return fieldError("type", "unknown type `%s`", m.TypeName)
+	}
+	m.Type = t
+	if !slices.Contains(enum.Values[MoveCategory](MoveCategoryEnum), m.Category) {
+		return fieldError("category", "unknown category `%s`", m.Category)
+	}
+	if m.Category == MoveCategoryEnum.Status && m.Power != 0 {
+		return fieldError("power", "status move must not have power")
+	} else if m.Category != MoveCategoryEnum.Status && m.Power <= 0 {
+		return fieldError("power", "expect positive, got %d", m.Power)
+	}
+	if m.Accuracy < 0 || m.Accuracy > 100 {
+		return fieldError("accuracy", "expect 0~100, got %d", m.Accuracy)
+	}
+	if m.PP <= 0 || m.PP > 64 {
+		return fieldError("pp", "expect 1~64, got %d", m.PP)
+	}
+	if m.Priority < -7 || m.Priority > 5 {
+		return fieldError("priority", "expect -7~5, got %d", m.Priority)
+	}
+	if m.Target == "" {
+		m.Target = MoveTargetEnum.Opponent
+	} else if !slices.Contains(enum.Values[MoveTarget](MoveTargetEnum), m.Target) {
+		return fieldError("target", "unknown target `%s`", m.Target)
+	}
+	for i := range m.Effects {
+		effect := &m.Effects[i]
+		field := fmt.Sprintf("effects[%d]", i)
+		if effect.Type == "" {
+			return fieldError(field+".type", "empty effect type")
+		}
+		if effect.Chance < 0 || effect.Chance > 100 {
+			return fieldError(field+".chance", "expect 0~100, got %d", effect.Chance)
+		}
+		if effect.Target != "" && !slices.Contains(enum.Values[MoveTarget](MoveTargetEnum), effect.Target) {
+			return fieldError(field+".target", "unknown target `%s`", effect.Target)
+		}
+		if effect.StatusName != "" {
+			status, ok := ParseStatusCondition(effect.StatusName)
+			if !ok {
+				return fieldError(field+".status", "unknown status `%s`", effect.StatusName)
+			}
+			effect.Status = status
+		}
+		if effect.Stat != "" && !slices.Contains(stageStats, effect.Stat) {
+			return fieldError(field+".stat", "unknown stat `%s`", effect.Stat)
+		}
+		if effect.Stages < -6 || effect.Stages > 6 {
+			return fieldError(field+".stages", "expect -6~6, got %d", effect.Stages)
+		}
+		if effect.Ratio < 0 || effect.Ratio > 100 {
+			return fieldError(field+".ratio", "expect 0~100, got %d", effect.Ratio)
+		}
+		if effect.MinHits < 0 || effect.MaxHits < effect.MinHits {
+			return fieldError(field+".max_hits", "expect min_hits <= max_hits")
+		} else if effect.Type == "multi_hit" && effect.MinHits < 1 {
+			return fieldError(field+".min_hits", "expect at least 1, got %d", effect.MinHits)
+		}
+	}
+	return nil
+}
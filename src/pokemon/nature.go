@@ -0,0 +1,72 @@
+package pokemon
+
+import (
+	"strings"
+
+	"github.com/tnnmigga/enum"
+)
+
+// Nature 性格
+type Nature uint8
+
+var NatureEnum = enum.New[struct {
+	Hardy   Nature // 勤奋
+	Lonely  Nature // 怕寂寞
+	Brave   Nature // 勇敢
+	Adamant Nature // 固执
+	Naughty Nature // 顽皮
+	Bold    Nature // 大胆
+	Docile  Nature // 坦率
+	Relaxed Nature // 悠闲
+	Impish  Nature // 淘气
+	Lax     Nature // 乐天
+	Timid   Nature // 胆小
+	Hasty   Nature // 急躁
+	Serious Nature // 认真
+	Jolly   Nature // 爽朗
+	Naive   Nature // 天真
+	Modest  Nature // 内敛
+	Mild    Nature // 慢吞吞
+	Quiet   Nature // 冷静
+	Bashful Nature // 害羞
+	Rash    Nature // 马虎
+	Calm    Nature // 温和
+	Gentle  Nature // 温顺
+	Sassy   Nature // 自大
+	Careful Nature // 慎重
+	Quirky  Nature // 浮躁
+}]()
+
+// 性格影响的能力项顺序
+var natureStats = [5]Stat{StatEnum.Attack, StatEnum.Defense, StatEnum.Speed, StatEnum.SpAttack, StatEnum.SpDefense}
+
+// Increased 提升的能力项，无修正时返回false
+func (n Nature) Increased() (Stat, bool) {
+	up, down := natureStats[int(n)/5], natureStats[int(n)%5]
+	return up, up != down
+}
+
+// Decreased 降低的能力项，无修正时返回false
+func (n Nature) Decreased() (Stat, bool) {
+	up, down := natureStats[int(n)/5], natureStats[int(n)%5]
+	return down, up != down
+}
+
+// Modifier 对某能力项的修正倍数（单位：1/10）
+func (n Nature) Modifier(stat Stat) int {
+	if up, ok := n.Increased(); ok && up == stat {
+		return 11
+	} else if down, ok := n.Decreased(); ok && down == stat {
+		return 9
+	}
+	return 10
+}
+
+// String 性格名，用作翻译key `nature.<name>`
+func (n Nature) String() string {
+	keys := enum.Keys(NatureEnum)
+	if int(n) >= len(keys) {
+		return "unknown"
+	}
+	return strings.ToLower(keys[n])
+}
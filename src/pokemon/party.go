@@ -0,0 +1,84 @@
+package pokemon
+
+import "slices"
+
+const MaxPartySize = 6 // 队伍最多容纳的宝可梦数量
+
+// Party 玩家的队伍
+type Party struct {
+	members []*Pokemon
+}
+
+func NewParty(members ...*Pokemon) *Party {
+	return &Party{members: slices.Clone(members[:min(len(members), MaxPartySize)])}
+}
+
+// Len 宝可梦数量
+func (p *Party) Len() int {
+	return len(p.members)
+}
+
+// Full 队伍是否已满
+func (p *Party) Full() bool {
+	return len(p.members) >= MaxPartySize
+}
+
+// Get 获取某个位置的宝可梦
+func (p *Party) Get(index int) (*Pokemon, bool) {
+	if index < 0 || index >= len(p.members) {
+		return nil, false
+	}
+	return p.members[index], true
+}
+
+// Members 所有宝可梦，按队伍顺序
+func (p *Party) Members() []*Pokemon {
+	return slices.Clone(p.members)
+}
+
+// Add 加入队伍，队伍已满时返回false
+func (p *Party) Add(pok *Pokemon) bool {
+	if p.Full() {
+		return false
+	}
+	p.members = append(p.members, pok)
+	return true
+}
+
+// Remove 移出队伍，队伍中至少保留一只
+func (p *Party) Remove(index int) (*Pokemon, bool) {
+	if index < 0 || index >= len(p.members) || len(p.members) <= 1 {
+		return nil, false
+	}
+	pok := p.members[index]
+	p.members = slices.Delete(p.members, index, index+1)
+	return pok, true
+}
+
+// Swap 交换两个位置
+func (p *Party) Swap(i, j int) bool {
+	if i < 0 || i >= len(p.members) || j < 0 || j >= len(p.members) {
+		return false
+	}
+	p.members[i], p.members[j] = p.members[j], p.members[i]
+	return true
+}
+
+// Lead 第一只还能战斗的宝可梦的位置
+func (p *Party) Lead() (int, bool) {
+	index := slices.IndexFunc(p.members, func(pok *Pokemon) bool { return !pok.Fainted() })
+	return index, index >= 0
+}
+
+// AllFainted 是否全部无法战斗
+func (p *Party) AllFainted() bool {
+	_, ok := p.Lead()
+	return !ok
+}
+
+// HealAll 全部回复
+func (p *Party) HealAll() {
+	for _, pok := range p.members {
+		pok.Heal()
+	}
+}
@@ -1,4 +1,152 @@
 package pokemon
 
+import (
+	"fmt"
+	"math/rand/v2"
+
+	stlval "github.com/kkkunny/stl/value"
+	"github.com/tnnmigga/enum"
+
+	"github.com/kkkunny/pokemon/src/util/i18n"
+)
+
+const (
+	MaxLevel   = 100 // 最高等级
+	MaxIV      = 31  // 个体值上限
+	MaxEV      = 255 // 单项努力值上限
+	MaxTotalEV = 510 // 努力值总和上限
+	MaxMoves   = 4   // 技能数
+)
+
+// MoveSlot 技能槽
+type MoveSlot struct {
+	Move string // 技能id，为空表示空槽
+	PP   int    // 剩余PP
+	PPUp int    // 使用PP提升剂的次数
+}
+
+// Empty 是否是空槽
+func (s MoveSlot) Empty() bool {
+	return s.Move == ""
+}
+
+// Pokemon 宝可梦个体
 type Pokemon struct {
+	Race     *PokemonRace       // 种族
+	Nickname string             // 昵称，为空时使用种族名
+	Level    int                // 等级
+	Exp      int                // 经验值
+	Nature   Nature             // 性格
+	Gender   Gender             // 性别
+	IV       Stats              // 个体值
+	EV       Stats              // 努力值
+	HP       int                // 当前体力
+	Status   StatusCondition    // 异常状态
+	HeldItem string             // 携带道具
+	OTID     uint32             // 初训家id
+	Moves    [MaxMoves]MoveSlot // 技能
+}
+
+// NewPokemon 生成一只随机个体
+func NewPokemon(race *PokemonRace, level int, r *rand.Rand) *Pokemon {
+	p := &Pokemon{
+		Race:   race,
+		Level:  min(max(level, 1), MaxLevel),
+		Nature: Nature(r.IntN(25)),
+		Gender: GenderEnum.Genderless,
+	}
+	p.Exp = race.GrowthRate.ExpForLevel(p.Level)
+	if !race.Genderless() {
+		p.Gender = stlval.Ternary(r.Float64()*100 < race.GenderRatio, GenderEnum.Female, GenderEnum.Male)
+	}
+	for _, stat := range enum.Values[Stat](StatEnum) {
+		p.IV.Set(stat, r.IntN(MaxIV+1))
+	}
+	for _, id := range race.MovesAtLevel(p.Level) {
+		if move, err := GetMove(id); err == nil {
+			p.LearnMove(id, move.PP)
+		}
+	}
+	p.HP = p.Stats().HP
+	return p
+}
+
+// Name 显示的名字
+func (p *Pokemon) Name(loc *i18n.Localisation) string {
+	if p.Nickname != "" {
+		return p.Nickname
+	}
+	return loc.Get(fmt.Sprintf("pokemon.%d", p.Race.ID))
+}
+
+// Stats 根据种族值、个体值、努力值、等级和性格计算能力值，使用第三世代公式
+func (p *Pokemon) Stats() Stats {
+	var stats Stats
+	base := p.Race.BaseStats
+	for _, stat := range enum.Values[Stat](StatEnum) {
+		v := (2*base.Get(stat) + p.IV.Get(stat) + p.EV.Get(stat)/4) * p.Level / 100
+		if stat == StatEnum.HP {
+			v += p.Level + 10
+		} else {
+			v = (v + 5) * p.Nature.Modifier(stat) / 10
+		}
+		stats.Set(stat, v)
+	}
+	return stats
+}
+
+// MaxHP 体力上限
+func (p *Pokemon) MaxHP() int {
+	return p.Stats().HP
+}
+
+// Fainted 是否已濒死
+func (p *Pokemon) Fainted() bool {
+	return p.HP <= 0
+}
+
+// Heal 完全恢复
+func (p *Pokemon) Heal() {
+	p.HP = p.MaxHP()
+	p.Status = StatusConditionEnum.None
+	for i, slot := range p.Moves {
+		if move, err := GetMove(slot.Move); !slot.Empty() && err == nil {
+			p.Moves[i].PP = move.MaxPP(slot.PPUp)
+		}
+	}
+}
+
+// AddEV 增加努力值，受单项和总和上限约束
+// @return: 实际增加的值
+func (p *Pokemon) AddEV(stat Stat, v int) int {
+	v = min(v, MaxEV-p.EV.Get(stat), MaxTotalEV-p.EV.Total())
+	if v <= 0 {
+		return 0
+	}
+	p.EV.Set(stat, p.EV.Get(stat)+v)
+	return v
+}
+
+// LearnMove 学会技能，放到第一个空槽
+// @return: 是否学会
+func (p *Pokemon) LearnMove(move string, pp int) bool {
+	for i, slot := range p.Moves {
+		if slot.Move == move {
+			return false
+		} else if slot.Empty() {
+			p.Moves[i] = MoveSlot{Move: move, PP: pp}
+			return true
+		}
+	}
+	return false
+}
+
+// KnowsMove 是否学会了技能
+func (p *Pokemon) KnowsMove(move string) bool {
+	for _, slot := range p.Moves {
+		if slot.Move == move {
+			return true
+		}
+	}
+	return false
 }
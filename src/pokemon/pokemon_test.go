@@ -0,0 +1,64 @@
+package pokemon
+
+import "testing"
+
+// 与第三世代公式的已知结果对照
+func TestStats(t *testing.T) {
+	tests := []struct {
+		name   string
+		base   Stats
+		level  int
+		nature Nature
+		iv, ev Stats
+		want   Stats
+	}{
+		{
+			// 烈咬陆鲨 Lv.78 固执
+			name:   "garchomp",
+			base:   Stats{HP: 108, Attack: 130, Defense: 95, SpAttack: 80, SpDefense: 85, Speed: 102},
+			level:  78,
+			nature: NatureEnum.Adamant,
+			iv:     Stats{HP: 24, Attack: 12, Defense: 30, SpAttack: 16, SpDefense: 23, Speed: 5},
+			ev:     Stats{HP: 74, Attack: 190, Defense: 91, SpAttack: 48, SpDefense: 84, Speed: 23},
+			want:   Stats{HP: 289, Attack: 278, Defense: 193, SpAttack: 135, SpDefense: 171, Speed: 171},
+		},
+		{
+			// 种族值全为100，Lv.100 满个体值
+			name:   "level_100",
+			base:   Stats{HP: 100, Attack: 100, Defense: 100, SpAttack: 100, SpDefense: 100, Speed: 100},
+			level:  100,
+			nature: NatureEnum.Hardy,
+			iv:     Stats{HP: 31, Attack: 31, Defense: 31, SpAttack: 31, SpDefense: 31, Speed: 31},
+			ev:     Stats{HP: 252, Speed: 252},
+			want:   Stats{HP: 404, Attack: 236, Defense: 236, SpAttack: 236, SpDefense: 236, Speed: 299},
+		},
+		{
+			// 妙蛙种子 Lv.5 胆小，个体值和努力值为0
+			name:   "bulbasaur",
+			base:   Stats{HP: 45, Attack: 49, Defense: 49, SpAttack: 65, SpDefense: 65, Speed: 45},
+			level:  5,
+			nature: NatureEnum.Timid,
+			want:   Stats{HP: 19, Attack: 8, Defense: 9, SpAttack: 11, SpDefense: 11, Speed: 9},
+		},
+		{
+			// Lv.1 勇敢，能力值取整后接近下限
+			name:   "level_1",
+			base:   Stats{HP: 1, Attack: 90, Defense: 45, SpAttack: 30, SpDefense: 30, Speed: 40},
+			level:  1,
+			nature: NatureEnum.Brave,
+			iv:     Stats{HP: 31, Attack: 31, Defense: 31, SpAttack: 31, SpDefense: 31, Speed: 31},
+			want:   Stats{HP: 11, Attack: 7, Defense: 6, SpAttack: 5, SpDefense: 5, Speed: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pokemon{Race: &PokemonRace{BaseStats: tt.base}, Level: tt.level, Nature: tt.nature, IV: tt.iv, EV: tt.ev}
+			if got := p.Stats(); got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			if got := p.MaxHP(); got != tt.want.HP {
+				t.Fatalf("max hp %d, want %d", got, tt.want.HP)
+			}
+		})
+	}
+}
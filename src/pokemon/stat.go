@@ -0,0 +1,76 @@
+package pokemon
+
+import "github.com/tnnmigga/enum"
+
+// Stat 能力项
+type Stat uint8
+
+var StatEnum = enum.New[struct {
+	HP        Stat // 体力
+	Attack    Stat // 攻击
+	Defense   Stat // 防御
+	SpAttack  Stat // 特攻
+	SpDefense Stat // 特防
+	Speed     Stat // 速度
+}]()
+
+// Stats 六项能力值
+type Stats struct {
+	HP        int `yaml:"hp"`
+	Attack    int `yaml:"attack"`
+	Defense   int `yaml:"defense"`
+	SpAttack  int `yaml:"sp_attack"`
+	SpDefense int `yaml:"sp_defense"`
+	Speed     int `yaml:"speed"`
+}
+
+func (s Stat) String() string {
+	switch s {
+	case StatEnum.HP:
+		return "hp"
+	case StatEnum.Attack:
+		return "attack"
+	case StatEnum.Defense:
+		return "defense"
+	case StatEnum.SpAttack:
+		return "sp_attack"
+	case StatEnum.SpDefense:
+		return "sp_defense"
+	case StatEnum.Speed:
+		return "speed"
+	default:
+		return ""
+	}
+}
+
+func (s *Stats) field(stat Stat) *int {
+	switch stat {
+	case StatEnum.HP:
+		return &s.HP
+	case StatEnum.Attack:
+		return &s.Attack
+	case StatEnum.Defense:
+		return &s.Defense
+	case StatEnum.SpAttack:
+		return &s.SpAttack
+	case StatEnum.SpDefense:
+		return &s.SpDefense
+	case StatEnum.Speed:
+		return &s.Speed
+	default:
+		panic("unknown stat")
+	}
+}
+
+func (s Stats) Get(stat Stat) int {
+	return *s.field(stat)
+}
+
+func (s *Stats) Set(stat Stat, v int) {
+	*s.field(stat) = v
+}
+
+// Total 总和
+func (s Stats) Total() int {
+	return s.HP + s.Attack + s.Defense + s.SpAttack + s.SpDefense + s.Speed
+}
@@ -0,0 +1,36 @@
+package pokemon
+
+import "github.com/tnnmigga/enum"
+
+// StatusCondition 异常状态
+type StatusCondition uint8
+
+var StatusConditionEnum = enum.New[struct {
+	None      StatusCondition // 无
+	Sleep     StatusCondition // 睡眠
+	Poison    StatusCondition // 中毒
+	BadPoison StatusCondition // 剧毒
+	Burn      StatusCondition // 灼伤
+	Freeze    StatusCondition // 冰冻
+	Paralysis StatusCondition // 麻痹
+}]()
+
+// String 异常状态名，用作翻译key `status.<name>`
+func (s StatusCondition) String() string {
+	switch s {
+	case StatusConditionEnum.Sleep:
+		return "sleep"
+	case StatusConditionEnum.Poison:
+		return "poison"
+	case StatusConditionEnum.BadPoison:
+		return "bad_poison"
+	case StatusConditionEnum.Burn:
+		return "burn"
+	case StatusConditionEnum.Freeze:
+		return "freeze"
+	case StatusConditionEnum.Paralysis:
+		return "paralysis"
+	default:
+		return "none"
+	}
+}
@@ -2,13 +2,14 @@ package pokemon
 
 import (
 	"encoding/csv"
-	"os"
-	"path/filepath"
+	"errors"
+	"fmt"
 	"reflect"
 	"strings"
 
 	"github.com/tnnmigga/enum"
 
+	"github.com/kkkunny/pokemon/src/assets"
 	"github.com/kkkunny/pokemon/src/config"
 )
 
@@ -22,40 +23,69 @@ func init() {
 		}
 		field.SetUint(uint64(v))
 	}
+}
+
+// 属性克制关系，由LoadTypeChart读取
+var typeRestraintRelationship map[Type]map[Type]SkillEffect
 
-	file, err := os.Open(filepath.Join(config.DataPath, "type_restraint_relationship.csv"))
+// LoadTypeChart 从数据目录读取并校验属性克制表，需在挂载资源后调用
+func LoadTypeChart() error {
+	chart, err := loadTypeChart(config.TypeChartPath)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("%s: %w", config.TypeChartPath, err)
+	}
+	typeRestraintRelationship = chart
+	return nil
+}
+
+// 第一行为防守方属性，第一列为攻击方属性，值为0、0.5、1、2
+func loadTypeChart(path string) (map[Type]map[Type]SkillEffect, error) {
+	file, err := assets.OpenFile(path)
+	if err != nil {
+		return nil, err
 	}
 	defer file.Close()
-	csvReader := csv.NewReader(file)
-	data, err := csvReader.ReadAll()
+	data, err := csv.NewReader(file).ReadAll()
 	if err != nil {
-		panic(err)
+		return nil, err
+	} else if len(data) == 0 {
+		return nil, errors.New("empty type chart")
 	}
-	header := data[0][1:]
-	for _, line := range data[1:] {
-		ht := parseChineseType(line[0])
-		typeRestraintRelationship[ht] = make(map[Type]SkillEffect)
-		for i, v := range line[1:] {
-			vt := parseChineseType(header[i])
-			switch strings.TrimSpace(v) {
-			case "0":
-				typeRestraintRelationship[ht][vt] = SkillEffectEnum.Null
-			case "0.5":
-				typeRestraintRelationship[ht][vt] = SkillEffectEnum.Bad
-			case "1":
-				typeRestraintRelationship[ht][vt] = SkillEffectEnum.Normal
-			case "2":
-				typeRestraintRelationship[ht][vt] = SkillEffectEnum.Excellent
+	header := make([]Type, len(data[0]))
+	for i, name := range data[0][1:] {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		header[i+1] = parseChineseType(name)
+		if header[i+1] == TypeEnum.Unknown {
+			return nil, fmt.Errorf("line 1: unknown type `%s`", name)
+		}
+	}
+	chart := make(map[Type]map[Type]SkillEffect)
+	for row, line := range data[1:] {
+		name := strings.TrimSpace(line[0])
+		if name == "" {
+			continue
+		}
+		from := parseChineseType(name)
+		if from == TypeEnum.Unknown {
+			return nil, fmt.Errorf("line %d: unknown type `%s`", row+2, name)
+		}
+		chart[from] = make(map[Type]SkillEffect)
+		for i, to := range header {
+			if to == 0 {
+				continue
 			}
+			effect, ok := parseSkillEffect(strings.TrimSpace(line[i]))
+			if !ok {
+				return nil, fmt.Errorf("line %d: %s -> %s: unknown multiplier `%s`", row+2, name, strings.TrimSpace(data[0][i]), line[i])
+			}
+			chart[from][to] = effect
 		}
 	}
+	return chart, nil
 }
 
-// 属性克制关系
-var typeRestraintRelationship = make(map[Type]map[Type]SkillEffect)
-
 // Type 属性
 type Type uint32
 
@@ -125,6 +155,18 @@ func parseChineseType(s string) Type {
 	}
 }
 
+// ParseType 解析英文属性名，如 grass
+func ParseType(s string) (Type, bool) {
+	names := enum.Keys(TypeEnum)
+	values := enum.Values[Type](TypeEnum)
+	for i, name := range names {
+		if strings.EqualFold(name, s) {
+			return values[i], true
+		}
+	}
+	return TypeEnum.Unknown, false
+}
+
 // Contain 是否包含某属性
 func (t Type) Contain(dst Type) bool {
 	return t&dst == dst
@@ -143,8 +185,11 @@ func (t Type) Flatten() []Type {
 	return res
 }
 
-// GetEffectTo 当目标是指定属性时，获取效果
+// GetEffectTo 当目标是指定属性时，获取效果，属性克制表未载入时总是效果一般
 func (t Type) GetEffectTo(dst Type) float64 {
+	if typeRestraintRelationship == nil {
+		return 1
+	}
 	fromType, toTypes := t.Flatten()[0], dst.Flatten()
 	if len(toTypes) == 1 {
 		// 如果目标是单属性，直接用属性相克表的值
@@ -169,6 +214,21 @@ var SkillEffectEnum = enum.New[struct {
 	Excellent SkillEffect // 效果绝佳
 }]()
 
+func parseSkillEffect(s string) (SkillEffect, bool) {
+	switch s {
+	case "0":
+		return SkillEffectEnum.Null, true
+	case "0.5":
+		return SkillEffectEnum.Bad, true
+	case "1":
+		return SkillEffectEnum.Normal, true
+	case "2":
+		return SkillEffectEnum.Excellent, true
+	default:
+		return 0, false
+	}
+}
+
 // Multiples 倍数
 func (e SkillEffect) Multiples() float64 {
 	switch e {
@@ -0,0 +1,51 @@
+package pokemon
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kkkunny/pokemon/src/assets"
+	"github.com/kkkunny/pokemon/src/config"
+)
+
+func TestLoadTypeChart(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		err  string // 期望错误包含的内容，为空时应载入成功
+	}{
+		{name: "valid", csv: "\ufeff,水,火,,\n水,0.5,2,,\n火,0.5,0.5,,\n"},
+		{name: "unknown_column", csv: ",水,岩浆\n水,0.5,2\n", err: "line 1: unknown type `岩浆`"},
+		{name: "unknown_row", csv: ",水,火\n岩浆,0.5,2\n", err: "line 2: unknown type `岩浆`"},
+		{name: "unknown_multiplier", csv: ",水,火\n水,0.5,3\n", err: "line 2: 水 -> 火: unknown multiplier `3`"},
+		{name: "empty_multiplier", csv: ",水,火\n水,0.5,\n", err: "unknown multiplier"},
+		{name: "empty", csv: "", err: "empty type chart"},
+	}
+	defer func() { typeRestraintRelationship = nil }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typeRestraintRelationship = nil
+			assets.Mount(fstest.MapFS{config.TypeChartPath: {Data: []byte(tt.csv)}})
+			err := LoadTypeChart()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want %q", err, tt.err)
+				}
+				if typeRestraintRelationship != nil {
+					t.Fatal("chart replaced by an invalid one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := TypeEnum.Water.GetEffectTo(TypeEnum.Fire); got != 2 {
+				t.Fatalf("water -> fire: %v, want 2", got)
+			}
+			if got := TypeEnum.Fire.GetEffectTo(TypeEnum.Water | TypeEnum.Fire); got != 0.25 {
+				t.Fatalf("fire -> water/fire: %v, want 0.25", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tnnmigga/enum"
+
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/save"
+)
+
+// Version 记录文件格式版本
+const Version = 1
+
+// Header 记录文件的第一行，回放前需使用相同的种子和初始时间创建游戏，并恢复相同的存档
+type Header struct {
+	Version   int        `json:"version"`
+	Seed      uint64     `json:"seed"`
+	StartTime time.Time  `json:"start_time"`
+	Save      *save.Data `json:"save,omitempty"` // 开始录制时读取的存档，为空时为新游戏
+}
+
+// Frame 有输入的一帧，没有输入的帧不记录
+type Frame struct {
+	Tick   int                   `json:"t"`
+	Action *input.KeyInputAction `json:"a,omitempty"` // 当前帧的按键
+	Held   uint32                `json:"h,omitempty"` // 被按住的按键，第i位对应第i个按键
+}
+
+// Recording 一次游戏的按键记录
+type Recording struct {
+	Header
+	Frames []Frame
+}
+
+// 按键在Held中的位
+func heldBit(action input.KeyInputAction) uint32 {
+	for i, v := range enum.Values[input.KeyInputAction](input.KeyInputActionEnum) {
+		if v == action {
+			return 1 << i
+		}
+	}
+	return 0
+}
+
+// Load 读取记录文件，每行为一个JSON对象，第一行为Header
+func Load(r io.Reader) (*Recording, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("empty recording")
+	}
+	var rec Recording
+	err := json.Unmarshal(scanner.Bytes(), &rec.Header)
+	if err != nil {
+		return nil, fmt.Errorf("line 1: %w", err)
+	} else if rec.Version != Version {
+		return nil, fmt.Errorf("unsupported recording version %d, expect %d", rec.Version, Version)
+	}
+	for line := 2; scanner.Scan(); line++ {
+		var frame Frame
+		err = json.Unmarshal(scanner.Bytes(), &frame)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		} else if n := len(rec.Frames); n > 0 && frame.Tick <= rec.Frames[n-1].Tick {
+			return nil, fmt.Errorf("line %d: tick %d not increasing", line, frame.Tick)
+		}
+		rec.Frames = append(rec.Frames, frame)
+	}
+	return &rec, scanner.Err()
+}
+
+// LoadFile 读取记录文件
+func LoadFile(path string) (*Recording, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	rec, err := Load(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rec, nil
+}
+
+// Recorder 记录按键的按键来源，包装实际的按键来源，每帧写入一行
+type Recorder struct {
+	src  input.Source
+	w    *bufio.Writer
+	tick int
+	held uint32 // 当前帧被按住的按键
+}
+
+// NewRecorder 创建记录器并写入Header，data为开始录制时读取的存档
+func NewRecorder(src input.Source, w io.Writer, seed uint64, startTime time.Time, data *save.Data) (*Recorder, error) {
+	r := &Recorder{src: src, w: bufio.NewWriter(w)}
+	err := r.write(Header{Version: Version, Seed: seed, StartTime: startTime, Save: data})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Recorder) write(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = r.w.Write(append(data, '\n'))
+	return err
+}
+
+// KeyInputAction 读取实际的按键并记录，同时记录当前帧所有被按住的按键
+func (r *Recorder) KeyInputAction() (*input.KeyInputAction, error) {
+	tick := r.tick
+	r.tick++
+	action, err := r.src.KeyInputAction()
+	if err != nil {
+		return nil, err
+	}
+	r.held = 0
+	for _, a := range enum.Values[input.KeyInputAction](input.KeyInputActionEnum) {
+		if r.src.Held(a) {
+			r.held |= heldBit(a)
+		}
+	}
+	if action == nil && r.held == 0 {
+		return nil, nil
+	}
+	err = r.write(Frame{Tick: tick, Action: action, Held: r.held})
+	if err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+func (r *Recorder) Held(action input.KeyInputAction) bool {
+	return r.held&heldBit(action) != 0
+}
+
+// Flush 将缓冲的记录写入文件
+func (r *Recorder) Flush() error {
+	return r.w.Flush()
+}
+
+// Player 按记录回放的按键来源
+type Player struct {
+	rec   *Recording
+	tick  int
+	index int    // 下一个待回放的帧
+	held  uint32 // 当前帧被按住的按键
+}
+
+func NewPlayer(rec *Recording) *Player {
+	return &Player{rec: rec}
+}
+
+// Done 记录是否已回放完毕
+func (p *Player) Done() bool {
+	return p.index >= len(p.rec.Frames)
+}
+
+func (p *Player) KeyInputAction() (*input.KeyInputAction, error) {
+	tick := p.tick
+	p.tick++
+	p.held = 0
+	if p.Done() || p.rec.Frames[p.index].Tick != tick {
+		return nil, nil
+	}
+	frame := p.rec.Frames[p.index]
+	p.index++
+	p.held = frame.Held
+	return frame.Action, nil
+}
+
+func (p *Player) Held(action input.KeyInputAction) bool {
+	return p.held&heldBit(action) != 0
+}
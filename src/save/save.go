@@ -0,0 +1,260 @@
+package save
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/pokemon"
+	"github.com/kkkunny/pokemon/src/util"
+)
+
+const (
+	Version  = 1 // 当前存档版本
+	MaxSlots = 3 // 存档槽数量
+)
+
+var (
+	ErrNotExist  = errors.New("save not exist")
+	ErrCorrupted = errors.New("save corrupted")
+)
+
+// Pokemon 存档中的宝可梦
+type Pokemon struct {
+	Species  int16                      `json:"species"`
+	Nickname string                     `json:"nickname,omitempty"`
+	Level    int                        `json:"level"`
+	Exp      int                        `json:"exp"`
+	Nature   pokemon.Nature             `json:"nature"`
+	Gender   pokemon.Gender             `json:"gender"`
+	IV       pokemon.Stats              `json:"iv"`
+	EV       pokemon.Stats              `json:"ev"`
+	HP       int                        `json:"hp"`
+	Status   pokemon.StatusCondition    `json:"status"`
+	HeldItem string                     `json:"held_item,omitempty"`
+	OTID     uint32                     `json:"ot_id"`
+	Moves    [pokemon.MaxMoves]MoveSlot `json:"moves"`
+}
+
+// MoveSlot 存档中的技能槽
+type MoveSlot struct {
+	Move string `json:"move,omitempty"`
+	PP   int    `json:"pp,omitempty"`
+	PPUp int    `json:"pp_up,omitempty"`
+}
+
+// NewPokemon 从宝可梦个体生成存档数据
+func NewPokemon(p *pokemon.Pokemon) Pokemon {
+	data := Pokemon{
+		Species:  p.Race.ID,
+		Nickname: p.Nickname,
+		Level:    p.Level,
+		Exp:      p.Exp,
+		Nature:   p.Nature,
+		Gender:   p.Gender,
+		IV:       p.IV,
+		EV:       p.EV,
+		HP:       p.HP,
+		Status:   p.Status,
+		HeldItem: p.HeldItem,
+		OTID:     p.OTID,
+	}
+	for i, slot := range p.Moves {
+		data.Moves[i] = MoveSlot{Move: slot.Move, PP: slot.PP, PPUp: slot.PPUp}
+	}
+	return data
+}
+
+// Pokemon 还原宝可梦个体
+func (data Pokemon) Pokemon() (*pokemon.Pokemon, error) {
+	race, err := pokemon.GetPokemonRace(data.Species)
+	if err != nil {
+		return nil, err
+	}
+	p := &pokemon.Pokemon{
+		Race:     race,
+		Nickname: data.Nickname,
+		Level:    data.Level,
+		Exp:      data.Exp,
+		Nature:   data.Nature,
+		Gender:   data.Gender,
+		IV:       data.IV,
+		EV:       data.EV,
+		HP:       data.HP,
+		Status:   data.Status,
+		HeldItem: data.HeldItem,
+		OTID:     data.OTID,
+	}
+	for i, slot := range data.Moves {
+		p.Moves[i] = pokemon.MoveSlot{Move: slot.Move, PP: slot.PP, PPUp: slot.PPUp}
+	}
+	return p, nil
+}
+
+// Data 存档内容
+type Data struct {
+	Map       string          `json:"map"`       // 当前地图id
+	X         int             `json:"x"`         // 主角位置
+	Y         int             `json:"y"`         // 主角位置
+	Direction util.Direction  `json:"direction"` // 主角朝向
+	Party     []Pokemon       `json:"party"`     // 队伍
+	Bag       map[string]int  `json:"bag"`       // 背包，道具id -> 数量
+	Flags     map[string]bool `json:"flags"`     // 剧情标记
+	Vars      map[string]int  `json:"vars"`      // 剧情变量
+	Money     int             `json:"money"`     // 金钱
+	PlayTime  time.Duration   `json:"play_time"` // 游玩时长
+	Time      time.Time       `json:"time"`      // 游戏世界时间
+	SavedAt   time.Time       `json:"saved_at"`  // 保存时的现实时间
+}
+
+// 存档文件
+type file struct {
+	Version  int             `json:"version"`
+	Checksum string          `json:"checksum"` // data的sha256
+	Data     json.RawMessage `json:"data"`
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Migration 将存档数据从某个版本升级到下一个版本
+type Migration func(data map[string]any) error
+
+var migrations = make(map[int]Migration)
+
+// RegisterMigration 注册从from版本升级到from+1版本的迁移函数
+func RegisterMigration(from int, fn Migration) {
+	migrations[from] = fn
+}
+
+// 逐版本升级存档数据
+func migrate(version int, raw json.RawMessage) (json.RawMessage, error) {
+	if version == Version {
+		return raw, nil
+	} else if version > Version {
+		return nil, fmt.Errorf("save version %d is newer than supported version %d", version, Version)
+	}
+	var data map[string]any
+	err := json.Unmarshal(raw, &data)
+	if err != nil {
+		return nil, err
+	}
+	for ; version < Version; version++ {
+		fn, ok := migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration from save version %d", version)
+		}
+		err = fn(data)
+		if err != nil {
+			return nil, fmt.Errorf("migrate save version %d: %w", version, err)
+		}
+	}
+	return json.Marshal(data)
+}
+
+// SlotPath 存档槽对应的文件路径
+func SlotPath(slot int) string {
+	return filepath.Join(config.SavesPath, fmt.Sprintf("slot%d.sav", slot))
+}
+
+func checkSlot(slot int) error {
+	if slot < 0 || slot >= MaxSlots {
+		return fmt.Errorf("invalid save slot %d, expect 0~%d", slot, MaxSlots-1)
+	}
+	return nil
+}
+
+// Exists 存档槽是否有存档
+func Exists(slot int) bool {
+	if checkSlot(slot) != nil {
+		return false
+	}
+	_, err := os.Stat(SlotPath(slot))
+	return err == nil
+}
+
+// Save 写入存档，先写入临时文件再重命名，避免写入中断损坏原存档
+func Save(slot int, data *Data) error {
+	err := checkSlot(slot)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(file{Version: Version, Checksum: checksum(raw), Data: raw}, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(config.SavesPath, 0o755)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(config.SavesPath, fmt.Sprintf("slot%d.*.tmp", slot))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(content)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), SlotPath(slot))
+}
+
+// Load 读取存档，校验失败时返回ErrCorrupted
+func Load(slot int) (*Data, error) {
+	err := checkSlot(slot)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(SlotPath(slot))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotExist
+	} else if err != nil {
+		return nil, err
+	}
+
+	var f file
+	err = json.Unmarshal(content, &f)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorrupted, err)
+	}
+	// 格式化写入时data会被缩进，校验前先压缩
+	var raw bytes.Buffer
+	err = json.Compact(&raw, f.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorrupted, err)
+	}
+	if checksum(raw.Bytes()) != f.Checksum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorrupted)
+	}
+
+	migrated, err := migrate(f.Version, raw.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	var data Data
+	err = json.Unmarshal(migrated, &data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorrupted, err)
+	}
+	return &data, nil
+}
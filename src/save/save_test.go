@@ -0,0 +1,233 @@
+package save
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/pokemon"
+	"github.com/kkkunny/pokemon/src/util"
+)
+
+// 存档写入临时目录
+func useTempSaves(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "saves")
+	old := config.SavesPath
+	config.SavesPath = dir
+	t.Cleanup(func() { config.SavesPath = old })
+	return dir
+}
+
+func newTestData() *Data {
+	return &Data{
+		Map:       "pallet_town",
+		X:         5,
+		Y:         7,
+		Direction: util.DirectionEnum.Left,
+		Party: []Pokemon{{
+			Species:  1,
+			Nickname: "小种子",
+			Level:    12,
+			Exp:      1500,
+			Nature:   pokemon.NatureEnum.Brave,
+			IV:       pokemon.Stats{HP: 31, Attack: 20, Defense: 10, SpAttack: 5, SpDefense: 0, Speed: 15},
+			EV:       pokemon.Stats{Attack: 12},
+			HP:       30,
+			Status:   pokemon.StatusConditionEnum.Poison,
+			OTID:     12345,
+			Moves:    [pokemon.MaxMoves]MoveSlot{{Move: "tackle", PP: 30}, {Move: "growl", PP: 40, PPUp: 1}},
+		}},
+		Bag:      map[string]int{"potion": 3, "poke_ball": 5},
+		Flags:    map[string]bool{"got_starter": true},
+		Vars:     map[string]int{"rival_battles": 2},
+		Money:    3000,
+		PlayTime: 90 * time.Minute,
+		Time:     time.Date(2024, 1, 1, 18, 30, 0, 0, time.UTC),
+		SavedAt:  time.Date(2024, 5, 4, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := useTempSaves(t)
+	want := newTestData()
+	if Exists(1) {
+		t.Fatal("slot exists before saving")
+	}
+	err := Save(1, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Exists(1) || Exists(0) {
+		t.Fatal("wrong slot saved")
+	}
+	got, err := Load(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("loaded data differs\n got: %+v\nwant: %+v", got, want)
+	}
+
+	// 覆盖原存档，不留下临时文件
+	want.Money = 10
+	err = Save(1, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = Load(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Money != 10 {
+		t.Fatalf("money %d after overwrite, want 10", got.Money)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != filepath.Base(SlotPath(1)) {
+		t.Fatalf("save directory contains %v", entries)
+	}
+}
+
+func TestLoadCorrupted(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(content []byte) []byte
+	}{
+		{name: "flipped_byte", edit: func(content []byte) []byte {
+			return bytes.Replace(content, []byte(`"money": 3000`), []byte(`"money": 3001`), 1)
+		}},
+		{name: "bad_checksum", edit: func(content []byte) []byte {
+			var f file
+			_ = json.Unmarshal(content, &f)
+			return bytes.Replace(content, []byte(f.Checksum), []byte(strings.Repeat("0", len(f.Checksum))), 1)
+		}},
+		{name: "truncated", edit: func(content []byte) []byte {
+			return content[:len(content)/2]
+		}},
+		{name: "not_json", edit: func(content []byte) []byte {
+			return []byte("slot")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempSaves(t)
+			err := Save(0, newTestData())
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, err := os.ReadFile(SlotPath(0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			edited := tt.edit(content)
+			if bytes.Equal(edited, content) {
+				t.Fatal("file not changed")
+			}
+			err = os.WriteFile(SlotPath(0), edited, 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := Load(0)
+			if !errors.Is(err, ErrCorrupted) {
+				t.Fatalf("loaded %+v, error %v, want ErrCorrupted", data, err)
+			}
+		})
+	}
+}
+
+// 按版本写入存档文件，校验和正确
+func writeVersion(t *testing.T, slot int, version int, data string) {
+	t.Helper()
+	var raw bytes.Buffer
+	err := json.Compact(&raw, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := json.Marshal(file{Version: version, Checksum: checksum(raw.Bytes()), Data: raw.Bytes()})
+	if err == nil {
+		err = os.MkdirAll(config.SavesPath, 0o755)
+	}
+	if err == nil {
+		err = os.WriteFile(SlotPath(slot), content, 0o644)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigration(t *testing.T) {
+	useTempSaves(t)
+	// 第0版的金钱字段名为gold
+	RegisterMigration(0, func(data map[string]any) error {
+		data["money"] = data["gold"]
+		delete(data, "gold")
+		return nil
+	})
+	t.Cleanup(func() { delete(migrations, 0) })
+
+	writeVersion(t, 0, 0, `{"map": "pallet_town", "x": 3, "gold": 500}`)
+	data, err := Load(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Map != "pallet_town" || data.X != 3 || data.Money != 500 {
+		t.Fatalf("migrated data %+v", data)
+	}
+
+	// 没有对应的迁移函数
+	writeVersion(t, 1, -1, `{"map": "pallet_town"}`)
+	_, err = Load(1)
+	if err == nil || !strings.Contains(err.Error(), "no migration from save version -1") {
+		t.Fatalf("error %v, want missing migration", err)
+	}
+	// 比当前版本新
+	writeVersion(t, 2, Version+1, `{"map": "pallet_town"}`)
+	_, err = Load(2)
+	if err == nil || !strings.Contains(err.Error(), "newer than supported") {
+		t.Fatalf("error %v, want newer version", err)
+	}
+}
+
+func TestMigrationError(t *testing.T) {
+	useTempSaves(t)
+	RegisterMigration(0, func(data map[string]any) error {
+		return errors.New("unknown map")
+	})
+	t.Cleanup(func() { delete(migrations, 0) })
+
+	writeVersion(t, 0, 0, `{"map": "lost_town"}`)
+	_, err := Load(0)
+	if err == nil || !strings.Contains(err.Error(), "migrate save version 0: unknown map") {
+		t.Fatalf("error %v, want migration error", err)
+	}
+}
+
+func TestSlot(t *testing.T) {
+	useTempSaves(t)
+	for _, slot := range []int{-1, MaxSlots} {
+		if _, err := Load(slot); err == nil || errors.Is(err, ErrNotExist) {
+			t.Errorf("load slot %d: error %v, want invalid slot", slot, err)
+		}
+		if err := Save(slot, newTestData()); err == nil {
+			t.Errorf("saved to slot %d", slot)
+		}
+		if Exists(slot) {
+			t.Errorf("slot %d exists", slot)
+		}
+	}
+	for slot := range MaxSlots {
+		if _, err := Load(slot); !errors.Is(err, ErrNotExist) {
+			t.Errorf("load empty slot %d: error %v, want ErrNotExist", slot, err)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package script
+
+import (
+	"github.com/yuin/gopher-lua"
+
+	"github.com/kkkunny/pokemon/src/system/world/sprite"
+	"github.com/kkkunny/pokemon/src/util"
+)
+
+// Host 脚本可以操作的游戏接口，由游戏系统实现
+type Host interface {
+	ShowDialogue(text string) // 显示对话，text为翻译key
+	ShowLabel(text string)    // 显示标签，text为翻译key
+	DialogueDisplaying() bool // 对话或标签是否还在显示
+
+	Self() sprite.MovableSprite // 主角
+	CheckCollision(d util.Direction, x, y int) bool
+	Warp(mapID string, x, y int) error
+
+	Flag(name string) bool
+	SetFlag(name string, v bool)
+	Var(name string) int
+	SetVar(name string, v int)
+
+	GiveItem(id string, count int) (bool, error)
+	GivePokemon(species int16, level int) (bool, error)
+
+	StartBattle(site string, species int16, level int) error
+	BattleResult() (string, bool) // 最近一场战斗的结果，战斗进行中时返回false
+
+	PlaySound(name string) error
+}
+
+// Walker 可以被脚本移动的精灵
+type Walker interface {
+	sprite.MovableSprite
+	SetDirection(d util.Direction)
+	SetNextStepDirection(d util.Direction) bool
+	Busying() bool
+}
+
+func checkWalker(l *lua.LState, n int) Walker {
+	w, ok := checkSprite(l, n).(Walker)
+	if !ok {
+		l.ArgError(n, "expect movable sprite")
+	}
+	return w
+}
+
+func checkDirection(l *lua.LState, n int) util.Direction {
+	s := l.CheckString(n)
+	switch s {
+	case "up", "down", "left", "right":
+		return util.ParseDirection(s)
+	default:
+		l.ArgError(n, "expect up, down, left or right")
+		return util.DirectionEnum.Down
+	}
+}
+
+// 从a看向b的方向
+func directionTo(a, b sprite.Sprite) util.Direction {
+	ax, ay := a.Position()
+	bx, by := b.Position()
+	return util.DirectionOf(bx-ax, by-ay)
+}
+
+func (r *Runtime) modules() map[string]map[string]lua.LGFunction {
+	return map[string]map[string]lua.LGFunction{
+		"game":   r.gameModule(),
+		"sprite": r.spriteModule(),
+	}
+}
+
+// 等待对话关闭
+func (r *Runtime) waitDialogue() ([]lua.LValue, bool) {
+	return nil, !r.host.DialogueDisplaying()
+}
+
+func (r *Runtime) gameModule() map[string]lua.LGFunction {
+	return map[string]lua.LGFunction{
+		// say(text) 显示对话并等待关闭
+		"say": func(l *lua.LState) int {
+			r.host.ShowDialogue(l.CheckString(1))
+			return r.yield(l, r.waitDialogue)
+		},
+		// label(text) 显示标签并等待关闭
+		"label": func(l *lua.LState) int {
+			r.host.ShowLabel(l.CheckString(1))
+			return r.yield(l, r.waitDialogue)
+		},
+		// wait_input() 等待按下A键
+		"wait_input": func(l *lua.LState) int {
+			r.pressed = false
+			return r.yield(l, func() ([]lua.LValue, bool) {
+				return nil, r.pressed
+			})
+		},
+		// wait(frames) 等待若干帧
+		"wait": func(l *lua.LState) int {
+			frames := l.CheckInt(1)
+			return r.yield(l, func() ([]lua.LValue, bool) {
+				frames--
+				return nil, frames <= 0
+			})
+		},
+		// warp(map, x, y) 传送主角
+		"warp": func(l *lua.LState) int {
+			err := r.host.Warp(l.CheckString(1), l.CheckInt(2), l.CheckInt(3))
+			if err != nil {
+				l.RaiseError("%s", err.Error())
+			}
+			return 0
+		},
+		"get_flag": func(l *lua.LState) int {
+			l.Push(lua.LBool(r.host.Flag(l.CheckString(1))))
+			return 1
+		},
+		"set_flag": func(l *lua.LState) int {
+			r.host.SetFlag(l.CheckString(1), l.OptBool(2, true))
+			return 0
+		},
+		"get_var": func(l *lua.LState) int {
+			l.Push(lua.LNumber(r.host.Var(l.CheckString(1))))
+			return 1
+		},
+		"set_var": func(l *lua.LState) int {
+			r.host.SetVar(l.CheckString(1), l.CheckInt(2))
+			return 0
+		},
+		// give_item(id, count) count为负数时从背包中取出，返回是否全部放入或取出
+		"give_item": func(l *lua.LState) int {
+			ok, err := r.host.GiveItem(l.CheckString(1), l.OptInt(2, 1))
+			if err != nil {
+				l.RaiseError("%s", err.Error())
+			}
+			l.Push(lua.LBool(ok))
+			return 1
+		},
+		// give_pokemon(species, level) 返回是否成功加入队伍
+		"give_pokemon": func(l *lua.LState) int {
+			ok, err := r.host.GivePokemon(int16(l.CheckInt(1)), l.CheckInt(2))
+			if err != nil {
+				l.RaiseError("%s", err.Error())
+			}
+			l.Push(lua.LBool(ok))
+			return 1
+		},
+		// start_battle(site, species, level) 等待战斗结束，返回结果 win/lose/flee/catch
+		"start_battle": func(l *lua.LState) int {
+			err := r.host.StartBattle(l.CheckString(1), int16(l.CheckInt(2)), l.CheckInt(3))
+			if err != nil {
+				l.RaiseError("%s", err.Error())
+			}
+			return r.yield(l, func() ([]lua.LValue, bool) {
+				result, ok := r.host.BattleResult()
+				return []lua.LValue{lua.LString(result)}, ok
+			})
+		},
+		// play_sound(name) 播放 data/voice/<name>.ogg
+		"play_sound": func(l *lua.LState) int {
+			err := r.host.PlaySound(l.CheckString(1))
+			if err != nil {
+				l.RaiseError("%s", err.Error())
+			}
+			return 0
+		},
+	}
+}
+
+func (r *Runtime) spriteModule() map[string]lua.LGFunction {
+	return map[string]lua.LGFunction{
+		// self() 主角
+		"self": func(l *lua.LState) int {
+			l.Push(r.toLValue(r.host.Self()))
+			return 1
+		},
+		"text": func(l *lua.LState) int {
+			l.Push(lua.LString(checkSprite(l, 1).GetText()))
+			return 1
+		},
+		"position": func(l *lua.LState) int {
+			x, y := checkSprite(l, 1).Position()
+			l.Push(lua.LNumber(x))
+			l.Push(lua.LNumber(y))
+			return 2
+		},
+		"set_movable": func(l *lua.LState) int {
+			s, ok := checkSprite(l, 1).(sprite.MovableSprite)
+			if !ok {
+				l.ArgError(1, "expect movable sprite")
+			}
+			s.SetMovable(l.CheckBool(2))
+			return 0
+		},
+		// turn(sprite, direction) 转向
+		"turn": func(l *lua.LState) int {
+			w, d := checkWalker(l, 1), checkDirection(l, 2)
+			return r.yield(l, func() ([]lua.LValue, bool) {
+				if w.Busying() {
+					return nil, false
+				}
+				w.SetDirection(d)
+				return nil, true
+			})
+		},
+		// face(sprite, target) 转向另一个精灵
+		"face": func(l *lua.LState) int {
+			w, target := checkWalker(l, 1), checkSprite(l, 2)
+			return r.yield(l, func() ([]lua.LValue, bool) {
+				if w.Busying() {
+					return nil, false
+				}
+				w.SetDirection(directionTo(w, target))
+				return nil, true
+			})
+		},
+		// move(sprite, direction, steps) 向某方向走若干步，被阻挡时提前结束，返回实际步数
+		"move": func(l *lua.LState) int {
+			w, d, steps := checkWalker(l, 1), checkDirection(l, 2), l.OptInt(3, 1)
+			var moved int
+			return r.yield(l, func() ([]lua.LValue, bool) {
+				if w.Busying() {
+					return nil, false
+				}
+				x, y := w.Position()
+				nx, ny := x, y
+				switch d {
+				case util.DirectionEnum.Up:
+					ny--
+				case util.DirectionEnum.Down:
+					ny++
+				case util.DirectionEnum.Left:
+					nx--
+				case util.DirectionEnum.Right:
+					nx++
+				}
+				if moved >= steps || r.host.CheckCollision(d, nx, ny) {
+					return []lua.LValue{lua.LNumber(moved)}, true
+				}
+				w.SetDirection(d)
+				w.SetNextStepDirection(d)
+				moved++
+				return nil, false
+			})
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package script
+
+import (
+	"math/rand/v2"
+
+	"github.com/yuin/gopher-lua"
+
+	"github.com/kkkunny/pokemon/src/system/context"
+)
+
+// 用游戏的随机数流替换Lua自带的math.random和math.randomseed，使脚本结果可由种子重现
+func (r *Runtime) openRandom() {
+	math, ok := r.state.GetGlobal(lua.MathLibName).(*lua.LTable)
+	if !ok {
+		return
+	}
+	r.state.SetField(math, "random", r.state.NewFunction(r.luaRandom))
+	r.state.SetField(math, "randomseed", r.state.NewFunction(r.luaRandomSeed))
+}
+
+// math.random([m [, n]])
+func (r *Runtime) luaRandom(l *lua.LState) int {
+	rnd := r.ctx.Rand(context.RandStreamEnum.Script)
+	switch l.GetTop() {
+	case 0:
+		l.Push(lua.LNumber(rnd.Float64()))
+	case 1:
+		n := l.CheckInt(1)
+		if n < 1 {
+			l.ArgError(1, "interval is empty")
+		}
+		l.Push(lua.LNumber(rnd.IntN(n) + 1))
+	default:
+		m, n := l.CheckInt(1), l.CheckInt(2)
+		if m > n {
+			l.ArgError(2, "interval is empty")
+		}
+		l.Push(lua.LNumber(m + rnd.IntN(n-m+1)))
+	}
+	return 1
+}
+
+// math.randomseed(x)，只重置脚本使用的随机数流
+func (r *Runtime) luaRandomSeed(l *lua.LState) int {
+	seed := uint64(l.CheckInt64(1))
+	r.ctx.SetRand(context.RandStreamEnum.Script, rand.New(rand.NewPCG(seed, seed)))
+	return 0
+}
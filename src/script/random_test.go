@@ -0,0 +1,76 @@
+package script
+
+import (
+	"testing"
+
+	"github.com/yuin/gopher-lua"
+
+	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/system/context"
+)
+
+// 脚本只调用随机数，不需要游戏接口
+type stubHost struct {
+	Host
+}
+
+// 在新的运行时中执行代码，返回全局变量results中的数字
+func runRandomScript(t *testing.T, seed uint64, code string) []float64 {
+	t.Helper()
+	ctx := context.NewContext(&config.Config{Seed: seed}, nil, nil)
+	r := NewRuntime(stubHost{}, ctx)
+	defer r.Close()
+	if err := r.state.DoString(code); err != nil {
+		t.Fatal(err)
+	}
+	table, ok := r.state.GetGlobal("results").(*lua.LTable)
+	if !ok {
+		t.Fatal("results is not a table")
+	}
+	var results []float64
+	table.ForEach(func(_, v lua.LValue) {
+		results = append(results, float64(v.(lua.LNumber)))
+	})
+	return results
+}
+
+const randomScript = `
+results = {}
+for i = 1, 20 do
+    results[#results + 1] = math.random(3)
+    results[#results + 1] = math.random(5, 10)
+    results[#results + 1] = math.random()
+end
+`
+
+func TestRandomReproducible(t *testing.T) {
+	a := runRandomScript(t, 42, randomScript)
+	b := runRandomScript(t, 42, randomScript)
+	if len(a) != 60 || len(a) != len(b) {
+		t.Fatalf("unexpected result count %d and %d", len(a), len(b))
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("result %d differs with the same seed: %v != %v", i, a[i], b[i])
+		}
+	}
+	for i := 0; i < len(a); i += 3 {
+		if a[i] < 1 || a[i] > 3 || a[i+1] < 5 || a[i+1] > 10 || a[i+2] < 0 || a[i+2] >= 1 {
+			t.Fatalf("result out of range: %v", a[i:i+3])
+		}
+	}
+}
+
+func TestRandomSeedReproducible(t *testing.T) {
+	const code = `
+math.randomseed(7)
+results = {math.random(1000), math.random(1000), math.random(1000)}
+`
+	a := runRandomScript(t, 1, code)
+	b := runRandomScript(t, 2, code)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("result %d differs after randomseed: %v != %v", i, a[i], b[i])
+		}
+	}
+}
@@ -1,19 +1,193 @@
 package script
 
 import (
-	"path/filepath"
+	"fmt"
+	"path"
+	"slices"
 
 	"github.com/yuin/gopher-lua"
 
+	"github.com/kkkunny/pokemon/src/assets"
 	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/system/context"
+	"github.com/kkkunny/pokemon/src/system/world/sprite"
 )
 
-func LoadScriptFile(name string) (*lua.LState, error) {
-	l := lua.NewState()
-	lf, err := l.LoadFile(filepath.Join(config.ScriptsPath, name) + ".lua")
+// 等待条件，满足时返回传回脚本的值
+type waitFunc func() ([]lua.LValue, bool)
+
+// 一次脚本调用，运行在独立的协程中
+type task struct {
+	name string
+	co   *lua.LState
+	fn   *lua.LFunction
+	args []lua.LValue
+	wait waitFunc
+}
+
+// Runtime 脚本运行时
+// 每个脚本文件 data/scripts/<name>.lua 需定义同名的全局函数作为入口，
+// 每次调用都在新的协程中执行，可以在等待对话、移动、战斗时让出，跨帧继续执行
+type Runtime struct {
+	host    Host
+	ctx     context.Context
+	state   *lua.LState
+	loaded  map[string]bool
+	tasks   []*task
+	current *task
+	pressed bool // 等待输入期间是否按下了A键
+}
+
+func NewRuntime(host Host, ctx context.Context) *Runtime {
+	r := &Runtime{
+		host:   host,
+		ctx:    ctx,
+		state:  lua.NewState(),
+		loaded: make(map[string]bool),
+	}
+	r.openRandom()
+	for name, funcs := range r.modules() {
+		r.state.PreloadModule(name, func(l *lua.LState) int {
+			l.Push(l.SetFuncs(l.NewTable(), funcs))
+			return 1
+		})
+	}
+	return r
+}
+
+func (r *Runtime) Close() {
+	r.state.Close()
+}
+
+// 载入脚本文件，每个文件只执行一次
+func (r *Runtime) load(name string) error {
+	if r.loaded[name] {
+		return nil
+	}
+	scriptPath := path.Join(config.ScriptsPath, name) + ".lua"
+	file, err := assets.OpenFile(scriptPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	fn, err := r.state.Load(file, scriptPath)
+	if err != nil {
+		return err
+	}
+	r.state.Push(fn)
+	err = r.state.PCall(0, lua.MultRet, nil)
+	if err != nil {
+		return err
+	}
+	r.loaded[name] = true
+	return nil
+}
+
+// 将Go值转为Lua值
+func (r *Runtime) toLValue(v any) lua.LValue {
+	switch v := v.(type) {
+	case nil:
+		return lua.LNil
+	case lua.LValue:
+		return v
+	case bool:
+		return lua.LBool(v)
+	case int:
+		return lua.LNumber(v)
+	case string:
+		return lua.LString(v)
+	default:
+		ud := r.state.NewUserData()
+		ud.Value = v
+		return ud
+	}
+}
+
+// Run 调用脚本，立即执行到第一次让出
+func (r *Runtime) Run(name string, args ...any) error {
+	err := r.load(name)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("script `%s`: %w", name, err)
+	}
+	fn, ok := r.state.GetGlobal(name).(*lua.LFunction)
+	if !ok {
+		return fmt.Errorf("script `%s`: function `%s` not defined", name, name)
+	}
+	co, _ := r.state.NewThread()
+	t := &task{name: name, co: co, fn: fn}
+	for _, arg := range args {
+		t.args = append(t.args, r.toLValue(arg))
+	}
+	r.tasks = append(r.tasks, t)
+	return r.resume(t, t.args...)
+}
+
+// Running 是否有脚本在执行
+func (r *Runtime) Running() bool {
+	return len(r.tasks) > 0
+}
+
+// OnAction 接收按键，用于等待输入
+func (r *Runtime) OnAction(action input.KeyInputAction) {
+	if action == input.KeyInputActionEnum.A.Pressed() {
+		r.pressed = true
+	}
+}
+
+// Update 继续执行等待条件已满足的脚本
+func (r *Runtime) Update() error {
+	for _, t := range slices.Clone(r.tasks) {
+		values, ok := t.wait()
+		if !ok {
+			continue
+		}
+		err := r.resume(t, values...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runtime) resume(t *task, args ...lua.LValue) error {
+	r.current, t.wait = t, nil
+	defer func() { r.current = nil }()
+	state, err, _ := r.state.Resume(t.co, t.fn, args...)
+	if err != nil {
+		r.remove(t)
+		return fmt.Errorf("script `%s`: %w", t.name, err)
+	}
+	if state == lua.ResumeOK {
+		r.remove(t)
+	} else if t.wait == nil {
+		// 由coroutine.yield让出时下一帧继续
+		t.wait = func() ([]lua.LValue, bool) { return nil, true }
+	}
+	return nil
+}
+
+func (r *Runtime) remove(t *task) {
+	for i, v := range r.tasks {
+		if v == t {
+			r.tasks = append(r.tasks[:i], r.tasks[i+1:]...)
+			return
+		}
+	}
+}
+
+// 挂起当前脚本直到条件满足
+func (r *Runtime) yield(l *lua.LState, wait waitFunc) int {
+	r.current.wait = wait
+	return l.Yield()
+}
+
+// 脚本参数中的精灵
+func checkSprite(l *lua.LState, n int) sprite.Sprite {
+	ud := l.CheckUserData(n)
+	sp, ok := ud.Value.(sprite.Sprite)
+	if !ok {
+		l.ArgError(n, "expect sprite")
 	}
-	l.Push(lf)
-	return l, nil
+	return sp
 }
@@ -0,0 +1,116 @@
+package state
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 比较运算符，长的在前以便优先匹配
+var compareOps = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// 条件中的一项
+type term struct {
+	name   string
+	negate bool   // 标记取反
+	op     string // 为空表示标记，否则为变量比较
+	value  int
+}
+
+func (t term) eval(s *Store) bool {
+	if t.op == "" {
+		return s.Flag(t.name) != t.negate
+	}
+	v := s.Var(t.name)
+	switch t.op {
+	case ">=":
+		return v >= t.value
+	case "<=":
+		return v <= t.value
+	case "==":
+		return v == t.value
+	case "!=":
+		return v != t.value
+	case ">":
+		return v > t.value
+	default:
+		return v < t.value
+	}
+}
+
+// Condition 由标记和变量组成的条件，空条件恒为真
+// 格式为以 `&&` 连接的多项，每项为 `flag`、`!flag` 或 `var 运算符 整数`，如 `got_starter && !beat_rival && badges >= 1`
+type Condition struct {
+	terms []term
+}
+
+// ParseCondition 解析条件
+func ParseCondition(s string) (Condition, error) {
+	var cond Condition
+	if strings.TrimSpace(s) == "" {
+		return cond, nil
+	}
+	for _, item := range strings.Split(s, "&&") {
+		item = strings.TrimSpace(item)
+		t, err := parseTerm(item)
+		if err != nil {
+			return Condition{}, fmt.Errorf("condition `%s`: %w", s, err)
+		}
+		cond.terms = append(cond.terms, t)
+	}
+	return cond, nil
+}
+
+func parseTerm(s string) (term, error) {
+	for _, op := range compareOps {
+		name, value, ok := strings.Cut(s, op)
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if !validName(name) {
+			return term{}, fmt.Errorf("invalid variable name `%s`", name)
+		}
+		v, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return term{}, fmt.Errorf("invalid number `%s`", strings.TrimSpace(value))
+		}
+		return term{name: name, op: op, value: v}, nil
+	}
+	t := term{name: s}
+	if rest, ok := strings.CutPrefix(s, "!"); ok {
+		t.name, t.negate = strings.TrimSpace(rest), true
+	}
+	if !validName(t.name) {
+		return term{}, fmt.Errorf("invalid flag name `%s`", t.name)
+	}
+	return t, nil
+}
+
+// 名字只允许字母、数字、下划线和点
+func validName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !(c == '_' || c == '.' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// Eval 计算条件
+func (c Condition) Eval(s *Store) bool {
+	for _, t := range c.terms {
+		if !t.eval(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty 是否为空条件
+func (c Condition) Empty() bool {
+	return len(c.terms) == 0
+}
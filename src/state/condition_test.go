@@ -0,0 +1,68 @@
+package state
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCondition(t *testing.T) {
+	store := NewStore()
+	store.SetFlag("got_starter", true)
+	store.SetVar("badges", 2)
+	store.SetVar("debt", -3)
+
+	tests := []struct {
+		name string
+		s    string
+		want bool
+		err  string // 期望错误包含的内容，为空时应解析成功
+	}{
+		{name: "empty", s: "  ", want: true},
+		{name: "flag", s: "got_starter", want: true},
+		{name: "flag_unset", s: "beat_rival", want: false},
+		{name: "negate", s: "!beat_rival", want: true},
+		{name: "negate_set", s: "! got_starter", want: false},
+		{name: "dotted_name", s: "route_1.item_1", want: false},
+		{name: "ge", s: "badges >= 2", want: true},
+		{name: "ge_false", s: "badges >= 3", want: false},
+		{name: "le", s: "badges<=2", want: true},
+		{name: "le_false", s: "badges <= 1", want: false},
+		{name: "eq", s: "badges == 2", want: true},
+		{name: "eq_unset", s: "money == 0", want: true},
+		{name: "ne", s: "badges != 2", want: false},
+		{name: "gt", s: "badges > 1", want: true},
+		{name: "gt_false", s: "badges > 2", want: false},
+		{name: "lt", s: "badges < 3", want: true},
+		{name: "lt_false", s: "badges < 2", want: false},
+		{name: "negative_number", s: "debt < -2", want: true},
+		{name: "and", s: "got_starter && !beat_rival && badges >= 1", want: true},
+		{name: "and_false", s: "got_starter && beat_rival", want: false},
+		{name: "and_var_false", s: "got_starter&&badges>5", want: false},
+		{name: "invalid_flag", s: "got starter", err: "invalid flag name `got starter`"},
+		{name: "invalid_negate", s: "!", err: "invalid flag name ``"},
+		{name: "invalid_var", s: "bad-ges >= 1", err: "invalid variable name `bad-ges`"},
+		{name: "missing_var", s: ">= 1", err: "invalid variable name ``"},
+		{name: "invalid_number", s: "badges >= two", err: "invalid number `two`"},
+		{name: "missing_number", s: "badges ==", err: "invalid number ``"},
+		{name: "single_equal", s: "badges = 1", err: "invalid flag name"},
+		{name: "empty_term", s: "got_starter && ", err: "invalid flag name ``"},
+		{name: "or", s: "got_starter || beat_rival", err: "invalid flag name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, err := ParseCondition(tt.s)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cond.Eval(store); got != tt.want {
+				t.Fatalf("eval %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
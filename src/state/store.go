@@ -0,0 +1,71 @@
+package state
+
+import "maps"
+
+// Store 剧情标记和变量
+type Store struct {
+	flags map[string]bool
+	vars  map[string]int
+}
+
+func NewStore() *Store {
+	return &Store{
+		flags: make(map[string]bool),
+		vars:  make(map[string]int),
+	}
+}
+
+// Flag 获取标记，未设置时为false
+func (s *Store) Flag(name string) bool {
+	return s.flags[name]
+}
+
+// SetFlag 设置标记，设置为false时删除
+func (s *Store) SetFlag(name string, v bool) {
+	if v {
+		s.flags[name] = true
+	} else {
+		delete(s.flags, name)
+	}
+}
+
+// Var 获取变量，未设置时为0
+func (s *Store) Var(name string) int {
+	return s.vars[name]
+}
+
+// SetVar 设置变量，设置为0时删除
+func (s *Store) SetVar(name string, v int) {
+	if v != 0 {
+		s.vars[name] = v
+	} else {
+		delete(s.vars, name)
+	}
+}
+
+// AddVar 变量增加delta，返回增加后的值
+func (s *Store) AddVar(name string, delta int) int {
+	s.SetVar(name, s.Var(name)+delta)
+	return s.Var(name)
+}
+
+// Flags 所有已设置的标记，用于存档
+func (s *Store) Flags() map[string]bool {
+	return maps.Clone(s.flags)
+}
+
+// Vars 所有非0的变量，用于存档
+func (s *Store) Vars() map[string]int {
+	return maps.Clone(s.vars)
+}
+
+// Reset 用存档内容替换所有标记和变量
+func (s *Store) Reset(flags map[string]bool, vars map[string]int) {
+	s.flags, s.vars = make(map[string]bool), make(map[string]int)
+	for k, v := range flags {
+		s.SetFlag(k, v)
+	}
+	for k, v := range vars {
+		s.SetVar(k, v)
+	}
+}
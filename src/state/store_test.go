@@ -0,0 +1,63 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStoreDeleteZero(t *testing.T) {
+	s := NewStore()
+	s.SetFlag("a", true)
+	s.SetFlag("a", false)
+	s.SetVar("b", 3)
+	s.SetVar("b", 0)
+	if len(s.Flags()) != 0 || len(s.Vars()) != 0 {
+		t.Fatalf("flags %v, vars %v, want both empty", s.Flags(), s.Vars())
+	}
+
+	// 加到0时同样删除
+	s.SetVar("c", 2)
+	if got := s.AddVar("c", -2); got != 0 {
+		t.Fatalf("add var got %d, want 0", got)
+	}
+	if _, ok := s.Vars()["c"]; ok {
+		t.Fatal("zero var kept")
+	}
+	if got := s.AddVar("d", 5); got != 5 || s.Var("d") != 5 {
+		t.Fatalf("add var got %d, want 5", got)
+	}
+}
+
+func TestStoreReset(t *testing.T) {
+	s := NewStore()
+	s.SetFlag("old_flag", true)
+	s.SetVar("old_var", 1)
+
+	flags := map[string]bool{"got_starter": true, "beat_rival": false}
+	vars := map[string]int{"badges": 2, "zero": 0}
+	s.Reset(flags, vars)
+	if want := map[string]bool{"got_starter": true}; !reflect.DeepEqual(s.Flags(), want) {
+		t.Fatalf("flags %v, want %v", s.Flags(), want)
+	}
+	if want := map[string]int{"badges": 2}; !reflect.DeepEqual(s.Vars(), want) {
+		t.Fatalf("vars %v, want %v", s.Vars(), want)
+	}
+
+	// 不与存档或导出的结果共用map
+	flags["other"] = true
+	vars["badges"] = 8
+	exported := s.Vars()
+	exported["badges"] = 9
+	if s.Flag("other") || s.Var("badges") != 2 {
+		t.Fatal("store shares maps with its callers")
+	}
+
+	s.Reset(nil, nil)
+	if len(s.Flags()) != 0 || len(s.Vars()) != 0 {
+		t.Fatalf("flags %v, vars %v after reset", s.Flags(), s.Vars())
+	}
+	s.SetFlag("after_reset", true)
+	if !s.Flag("after_reset") {
+		t.Fatal("store unusable after reset with nil maps")
+	}
+}
@@ -0,0 +1,244 @@
+package bag
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/tnnmigga/enum"
+
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/item"
+	"github.com/kkkunny/pokemon/src/system/context"
+	"github.com/kkkunny/pokemon/src/util"
+	"github.com/kkkunny/pokemon/src/util/draw"
+)
+
+// Mode 打开背包的场合
+type Mode uint8
+
+var ModeEnum = enum.New[struct {
+	Field  Mode // 在野外
+	Battle Mode // 在战斗中
+}]()
+
+const (
+	visibleRows = 7  // 列表同时显示的行数
+	lineHeight  = 36 // 列表行高
+)
+
+// Screen 背包界面
+type Screen struct {
+	ctx context.Context
+
+	active  bool
+	mode    Mode
+	bag     *item.Bag
+	pocket  int    // 当前口袋下标
+	cursor  int    // 道具光标，等于道具数量时指向关闭背包
+	scroll  int    // 列表第一行的下标
+	option  int    // 子菜单光标，为-1时子菜单未打开
+	message string // 提示消息
+
+	onUse   func(it *item.Item) error // 选择使用道具
+	onClose func() error              // 关闭背包
+}
+
+func NewScreen(ctx context.Context) *Screen {
+	return &Screen{ctx: ctx}
+}
+
+func (s *Screen) SetOnUse(f func(it *item.Item) error) {
+	s.onUse = f
+}
+
+func (s *Screen) SetOnClose(f func() error) {
+	s.onClose = f
+}
+
+func (s *Screen) Active() bool {
+	return s.active
+}
+
+// Open 打开背包，保留上次所在的口袋
+func (s *Screen) Open(bag *item.Bag, mode Mode) {
+	s.active = true
+	s.mode = mode
+	s.bag = bag
+	s.option = -1
+	s.message = ""
+	s.resetCursor()
+}
+
+// ShowMessage 在提示栏显示消息，按A后消失
+func (s *Screen) ShowMessage(text string) {
+	s.message = text
+}
+
+func (s *Screen) pockets() []item.Pocket {
+	return enum.Values[item.Pocket](item.PocketEnum)
+}
+
+func (s *Screen) slots() []item.Slot {
+	return s.bag.Pocket(s.pockets()[s.pocket])
+}
+
+func (s *Screen) resetCursor() {
+	s.cursor = min(s.cursor, len(s.slots()))
+	s.scroll = min(s.scroll, s.cursor)
+}
+
+// Close 关闭背包，不触发关闭回调
+func (s *Screen) Close() {
+	s.active = false
+}
+
+func (s *Screen) close() error {
+	s.active = false
+	if s.onClose == nil {
+		return nil
+	}
+	return s.onClose()
+}
+
+func (s *Screen) OnAction(action input.KeyInputAction) error {
+	switch {
+	case s.message != "":
+		if action == input.KeyInputActionEnum.A.Pressed() || action == input.KeyInputActionEnum.B.Pressed() {
+			s.message = ""
+			s.resetCursor()
+		}
+	case s.option >= 0:
+		return s.onOptionAction(action)
+	default:
+		return s.onListAction(action)
+	}
+	return nil
+}
+
+func (s *Screen) onListAction(action input.KeyInputAction) error {
+	count := len(s.slots()) + 1
+	switch action {
+	case input.KeyInputActionEnum.MoveLeft.Pressed(), input.KeyInputActionEnum.L.Pressed():
+		s.pocket = (s.pocket + len(s.pockets()) - 1) % len(s.pockets())
+		s.cursor, s.scroll = 0, 0
+	case input.KeyInputActionEnum.MoveRight.Pressed(), input.KeyInputActionEnum.R.Pressed():
+		s.pocket = (s.pocket + 1) % len(s.pockets())
+		s.cursor, s.scroll = 0, 0
+	case input.KeyInputActionEnum.MoveUp.Pressed():
+		s.cursor = (s.cursor + count - 1) % count
+	case input.KeyInputActionEnum.MoveDown.Pressed():
+		s.cursor = (s.cursor + 1) % count
+	case input.KeyInputActionEnum.A.Pressed():
+		if s.cursor >= count-1 {
+			return s.close()
+		}
+		s.option = 0
+	case input.KeyInputActionEnum.B.Pressed():
+		return s.close()
+	}
+	// 光标始终在可见范围内
+	s.scroll = min(max(s.scroll, s.cursor-visibleRows+1), s.cursor)
+	return nil
+}
+
+func (s *Screen) onOptionAction(action input.KeyInputAction) error {
+	switch action {
+	case input.KeyInputActionEnum.MoveUp.Pressed(), input.KeyInputActionEnum.MoveDown.Pressed():
+		s.option = 1 - s.option
+	case input.KeyInputActionEnum.A.Pressed():
+		selected := s.option
+		s.option = -1
+		slots := s.slots()
+		if selected != 0 || s.cursor >= len(slots) {
+			return nil
+		}
+		it := slots[s.cursor].Item
+		if _, ok := item.GetUseHandler(it, s.mode == ModeEnum.Battle); !ok {
+			s.message = s.ctx.Localisation().Get("bag.cant_use")
+			return nil
+		}
+		if s.onUse == nil {
+			return nil
+		}
+		return s.onUse(it)
+	case input.KeyInputActionEnum.B.Pressed():
+		s.option = -1
+	}
+	return nil
+}
+
+func (s *Screen) OnUpdate() error {
+	return nil
+}
+
+func (s *Screen) OnDraw(drawer draw.OptionDrawer) error {
+	loc := s.ctx.Localisation()
+	textFont := util.GetFont(util.FontTypeEnum.Normal, 26)
+	smallFont := util.GetFont(util.FontTypeEnum.Normal, 20)
+	screenWidth, screenHeight := drawer.Bounds().Dx(), drawer.Bounds().Dy()
+	draw.OverlayColor(drawer, util.NewNRGBColor(248, 216, 120))
+
+	// 口袋
+	pockets := s.pockets()
+	tabW := (screenWidth - 20) / len(pockets)
+	for i, pocket := range pockets {
+		tabColor := color.Color(util.NewNRGBColor(200, 168, 72))
+		if i == s.pocket {
+			tabColor = util.NewNRGBColor(248, 88, 40)
+		}
+		draw.PrepareDrawRect(drawer, tabW-6, 40, tabColor).Move(10+i*tabW+3, 10).SetRadius(8).Draw()
+		draw.PrepareDrawText(drawer, loc.Get("pocket."+string(pocket)), smallFont, color.White).Move(10+i*tabW+14, 18).Draw()
+	}
+
+	// 道具列表
+	listW, listH := screenWidth-20, visibleRows*lineHeight+20
+	listDrawer := drawer.Move(10, 60)
+	draw.PrepareDrawRect(listDrawer, listW, listH, util.NewNRGBColor(248, 248, 248)).SetBorderWidth(4).SetBorderColor(util.NewNRGBColor(112, 104, 128)).SetRadius(6).Draw()
+	slots := s.slots()
+	for row := range visibleRows {
+		index := s.scroll + row
+		if index > len(slots) {
+			break
+		}
+		if index == s.cursor {
+			draw.PrepareDrawText(listDrawer, "▶", textFont, color.Black).Move(14, 10+row*lineHeight).Draw()
+		}
+		if index == len(slots) {
+			draw.PrepareDrawText(listDrawer, loc.Get("bag.close"), textFont, color.Black).Move(44, 10+row*lineHeight).Draw()
+			continue
+		}
+		draw.PrepareDrawText(listDrawer, slots[index].Item.Name(loc), textFont, color.Black).Move(44, 10+row*lineHeight).Draw()
+		if slots[index].Item.MaxStack > 1 {
+			draw.PrepareDrawText(listDrawer, fmt.Sprintf("×%3d", slots[index].Count), textFont, color.Black).Move(listW-100, 10+row*lineHeight).Draw()
+		}
+	}
+
+	// 说明栏
+	barH := screenHeight - 60 - listH - 20
+	draw.PrepareDrawRect(drawer, screenWidth, barH+10, color.Black).Move(0, screenHeight-barH-10).Draw()
+	draw.PrepareDrawRect(drawer, screenWidth-10, barH, util.NewNRGBColor(40, 80, 104)).Move(5, screenHeight-barH-5).SetRadius(6).Draw()
+	var desc string
+	switch {
+	case s.message != "":
+		desc = s.message
+	case len(slots) == 0:
+		desc = loc.Get("bag.empty")
+	case s.cursor < len(slots):
+		desc = slots[s.cursor].Item.Description(loc)
+	}
+	draw.PrepareDrawText(drawer, desc, textFont, color.White).Move(24, screenHeight-barH+5).Draw()
+
+	// 子菜单
+	if s.option >= 0 {
+		menuW, menuH := 160, 2*lineHeight+20
+		menuDrawer := drawer.Move(screenWidth-menuW-20, screenHeight-barH-menuH-20)
+		draw.PrepareDrawRect(menuDrawer, menuW, menuH, util.NewNRGBColor(248, 248, 248)).SetBorderWidth(4).SetBorderColor(util.NewNRGBColor(112, 104, 128)).SetRadius(6).Draw()
+		for i, key := range []string{"bag.use", "bag.cancel"} {
+			if i == s.option {
+				draw.PrepareDrawText(menuDrawer, "▶", textFont, color.Black).Move(14, 10+i*lineHeight).Draw()
+			}
+			draw.PrepareDrawText(menuDrawer, loc.Get(key), textFont, color.Black).Move(44, 10+i*lineHeight).Draw()
+		}
+	}
+	return nil
+}
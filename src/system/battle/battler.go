@@ -0,0 +1,103 @@
+package battle
+
+import (
+	"github.com/kkkunny/pokemon/src/pokemon"
+)
+
+// Battler 参战宝可梦
+type Battler struct {
+	*pokemon.Pokemon
+	BattleStats pokemon.Stats  // 进入战斗时的能力值
+	Stages      map[string]int // 能力等级变化
+
+	sleepTurns int  // 剩余睡眠回合
+	toxicTurns int  // 剧毒累计回合
+	seeded     bool // 是否被寄生种子寄生
+	fainted    bool // 是否已经通知过倒下
+}
+
+func NewBattler(p *pokemon.Pokemon) *Battler {
+	return &Battler{
+		Pokemon:     p,
+		BattleStats: p.Stats(),
+		Stages:      make(map[string]int),
+	}
+}
+
+// MaxHP 进入战斗时的体力上限
+func (b *Battler) MaxHP() int {
+	return b.BattleStats.HP
+}
+
+// Move 获取技能槽中的技能
+func (b *Battler) Move(index int) (*pokemon.Move, bool) {
+	if index < 0 || index >= len(b.Moves) || b.Moves[index].Empty() {
+		return nil, false
+	}
+	move, err := pokemon.GetMove(b.Moves[index].Move)
+	if err != nil {
+		return nil, false
+	}
+	return move, true
+}
+
+// UsableMoves 还有PP的技能槽下标
+func (b *Battler) UsableMoves() []int {
+	var indexes []int
+	for i, slot := range b.Moves {
+		if !slot.Empty() && slot.PP > 0 {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// 能力等级倍数
+func stageMultiplier(stat string, stage int) (int, int) {
+	base := 2
+	if stat == "accuracy" || stat == "evasion" {
+		base = 3
+	}
+	if stage >= 0 {
+		return base + stage, base
+	}
+	return base, base - stage
+}
+
+// Stat 计算能力等级和异常状态后的实际能力值
+func (b *Battler) Stat(stat pokemon.Stat) int {
+	v := b.BattleStats.Get(stat)
+	if stat == pokemon.StatEnum.HP {
+		return v
+	}
+	num, den := stageMultiplier(stat.String(), b.Stages[stat.String()])
+	v = v * num / den
+	switch {
+	case stat == pokemon.StatEnum.Attack && b.Status == pokemon.StatusConditionEnum.Burn:
+		v /= 2
+	case stat == pokemon.StatEnum.Speed && b.Status == pokemon.StatusConditionEnum.Paralysis:
+		v /= 4
+	}
+	return max(v, 1)
+}
+
+// ChangeStage 改变能力等级，返回实际变化量
+func (b *Battler) ChangeStage(stat string, stages int) int {
+	old := b.Stages[stat]
+	b.Stages[stat] = min(max(old+stages, -6), 6)
+	return b.Stages[stat] - old
+}
+
+// TakeDamage 受到伤害，返回实际扣除的体力
+func (b *Battler) TakeDamage(damage int) int {
+	damage = min(max(damage, 0), b.HP)
+	b.HP -= damage
+	return damage
+}
+
+// Recover 回复体力，返回实际回复的体力
+func (b *Battler) Recover(hp int) int {
+	hp = min(max(hp, 0), b.MaxHP()-b.HP)
+	b.HP += hp
+	return hp
+}
@@ -0,0 +1,64 @@
+package battle
+
+// PlayerController 由玩家操作界面选择行动
+type PlayerController struct {
+	pending *Action
+}
+
+func NewPlayerController() *PlayerController {
+	return &PlayerController{}
+}
+
+// Submit 提交玩家选择的行动
+func (c *PlayerController) Submit(action Action) {
+	c.pending = &action
+}
+
+// Waiting 是否正在等待玩家选择
+func (c *PlayerController) Waiting() bool {
+	return c.pending == nil
+}
+
+func (c *PlayerController) SelectAction(_ *Engine, _ Side) (Action, bool) {
+	if c.pending == nil {
+		return Action{}, false
+	}
+	action := *c.pending
+	c.pending = nil
+	return action, true
+}
+
+// SelectReplacement 使用玩家提交的替换行动
+func (c *PlayerController) SelectReplacement(_ *Engine, _ Side) (int, bool) {
+	if c.pending == nil || c.pending.Type != ActionTypeEnum.Switch {
+		return 0, false
+	}
+	index := c.pending.Switch
+	c.pending = nil
+	return index, true
+}
+
+// AIController 随机选择还有PP的技能
+type AIController struct{}
+
+func NewAIController() *AIController {
+	return &AIController{}
+}
+
+func (c *AIController) SelectAction(e *Engine, side Side) (Action, bool) {
+	moves := e.Battler(side).UsableMoves()
+	if len(moves) == 0 {
+		return Action{Type: ActionTypeEnum.Fight, Move: StruggleMove}, true
+	}
+	return Action{Type: ActionTypeEnum.Fight, Move: moves[e.Rand().IntN(len(moves))]}, true
+}
+
+// SelectReplacement 按队伍顺序派出下一只还能战斗的宝可梦
+func (c *AIController) SelectReplacement(e *Engine, side Side) (int, bool) {
+	for i := range e.Party(side) {
+		if e.CanSwitchTo(side, i) {
+			return i, true
+		}
+	}
+	return 0, false
+}
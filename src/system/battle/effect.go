@@ -0,0 +1,111 @@
+package battle
+
+import (
+	"fmt"
+
+	"github.com/kkkunny/pokemon/src/pokemon"
+)
+
+func init() {
+	RegisterMoveEffect("multi_hit", MoveEffectHandler{BeforeHit: func(ctx *MoveContext) {
+		// 2~3次各3/8，4~5次各1/8
+		hits := []int{2, 2, 2, 3, 3, 3, 4, 5}[ctx.Engine.Rand().IntN(8)]
+		ctx.Hits = min(max(hits, ctx.Effect.MinHits), ctx.Effect.MaxHits)
+	}})
+	RegisterMoveEffect("status", MoveEffectHandler{AfterHit: func(ctx *MoveContext) {
+		side, target := ctx.Target()
+		if target.Fainted() || target.Status != pokemon.StatusConditionEnum.None {
+			return
+		}
+		target.Status = ctx.Effect.Status
+		switch target.Status {
+		case pokemon.StatusConditionEnum.Sleep:
+			target.sleepTurns = 1 + ctx.Engine.Rand().IntN(4)
+		case pokemon.StatusConditionEnum.BadPoison:
+			target.toxicTurns = 0
+		}
+		ctx.Engine.Emit(Event{Type: EventTypeEnum.StatusInflicted, Side: side, Status: target.Status})
+	}})
+	RegisterMoveEffect("stat_stage", MoveEffectHandler{AfterHit: func(ctx *MoveContext) {
+		side, target := ctx.Target()
+		if target.Fainted() {
+			return
+		}
+		changed := target.ChangeStage(ctx.Effect.Stat, ctx.Effect.Stages)
+		ctx.Engine.Emit(Event{Type: EventTypeEnum.StatStage, Side: side, Stat: ctx.Effect.Stat, Stages: changed})
+	}})
+	RegisterMoveEffect("recoil", MoveEffectHandler{AfterHit: func(ctx *MoveContext) {
+		damage := ctx.Attacker.TakeDamage(max(ctx.Damage*ctx.Effect.Ratio/100, 1))
+		ctx.Engine.Emit(Event{Type: EventTypeEnum.Recoil, Side: ctx.Side, Damage: damage})
+	}})
+	RegisterMoveEffect("drain", MoveEffectHandler{AfterHit: func(ctx *MoveContext) {
+		hp := ctx.Attacker.Recover(max(ctx.Damage*ctx.Effect.Ratio/100, 1))
+		ctx.Engine.Emit(Event{Type: EventTypeEnum.Drain, Side: ctx.Side, Damage: hp})
+	}})
+	RegisterMoveEffect("heal", MoveEffectHandler{AfterHit: func(ctx *MoveContext) {
+		side, target := ctx.Target()
+		hp := target.Recover(target.MaxHP() * ctx.Effect.Ratio / 100)
+		ctx.Engine.Emit(Event{Type: EventTypeEnum.Heal, Side: side, Damage: hp})
+	}})
+	RegisterMoveEffect("leech_seed", MoveEffectHandler{AfterHit: func(ctx *MoveContext) {
+		side, target := ctx.Target()
+		if target.seeded || target.Race.Type.Contain(pokemon.TypeEnum.Grass) {
+			ctx.Engine.Emit(Event{Type: EventTypeEnum.Effect, Side: ctx.Side, Move: ctx.Move, Effect: 0})
+			return
+		}
+		target.seeded = true
+		ctx.Engine.Emit(Event{Type: EventTypeEnum.Seeded, Side: side})
+	}})
+}
+
+// MoveContext 技能追加效果的执行上下文
+type MoveContext struct {
+	Engine   *Engine
+	Side     Side // 使用技能的一方
+	Attacker *Battler
+	Defender *Battler
+	Move     *pokemon.Move
+	Effect   pokemon.MoveEffect // 当前处理的效果
+	Hits     int                // 攻击次数
+	Damage   int                // 本次技能造成的总伤害
+}
+
+// Target 效果目标
+func (ctx *MoveContext) Target() (Side, *Battler) {
+	if ctx.Move.EffectTarget(ctx.Effect) == pokemon.MoveTargetEnum.Self {
+		return ctx.Side, ctx.Attacker
+	}
+	return ctx.Side.Other(), ctx.Defender
+}
+
+// MoveEffectHandler 技能追加效果处理器
+type MoveEffectHandler struct {
+	BeforeHit func(ctx *MoveContext) // 命中后、计算伤害前，可修改攻击次数等
+	AfterHit  func(ctx *MoveContext) // 造成伤害后，按概率触发
+}
+
+var moveEffectHandlers = make(map[string]MoveEffectHandler)
+
+// RegisterMoveEffect 注册技能追加效果
+func RegisterMoveEffect(name string, handler MoveEffectHandler) {
+	moveEffectHandlers[name] = handler
+}
+
+// ValidateMoveEffects 检查技能表中的效果是否都已注册，以及是否定义了挣扎
+func ValidateMoveEffects() error {
+	moves, err := pokemon.Moves()
+	if err != nil {
+		return err
+	}
+	for id, move := range moves {
+		for i, effect := range move.Effects {
+			if _, ok := moveEffectHandlers[effect.Type]; !ok {
+				return fmt.Errorf("move `%s`: effects[%d].type: unknown effect `%s`", id, i, effect.Type)
+			}
+		}
+	}
+	if _, ok := moves[struggleMoveID]; !ok {
+		return fmt.Errorf("move `%s` is not defined", struggleMoveID)
+	}
+	return nil
+}
@@ -0,0 +1,529 @@
+package battle
+
+import (
+	"cmp"
+	"errors"
+	"math/rand/v2"
+	"slices"
+
+	stlval "github.com/kkkunny/stl/value"
+	"github.com/tnnmigga/enum"
+
+	"github.com/kkkunny/pokemon/src/item"
+	"github.com/kkkunny/pokemon/src/pokemon"
+)
+
+// Side 战斗方
+type Side uint8
+
+var SideEnum = enum.New[struct {
+	Self     Side // 我方
+	Opponent Side // 敌方
+}]()
+
+// Other 对方
+func (s Side) Other() Side {
+	if s == SideEnum.Self {
+		return SideEnum.Opponent
+	}
+	return SideEnum.Self
+}
+
+// Result 战斗结果
+type Result uint8
+
+var ResultEnum = enum.New[struct {
+	None  Result // 进行中
+	Win   Result // 胜利
+	Lose  Result // 失败
+	Flee  Result // 逃跑
+	Catch Result // 捕获
+}]()
+
+// ActionType 行动类型
+type ActionType uint8
+
+var ActionTypeEnum = enum.New[struct {
+	Fight  ActionType // 使用技能
+	Flee   ActionType // 逃跑
+	Switch ActionType // 替换宝可梦
+	Item   ActionType // 使用道具
+}]()
+
+// StruggleMove 所有技能都没有PP时使用挣扎，作为行动的技能槽下标
+const StruggleMove = -1
+
+// 挣扎的技能id
+const struggleMoveID = "struggle"
+
+// Action 行动
+type Action struct {
+	Type   ActionType
+	Move   int    // 使用的技能槽下标，为StruggleMove时使用挣扎
+	Switch int    // 替换上场的队伍下标
+	Item   string // 使用的道具id
+	Target int    // 道具目标的队伍下标
+}
+
+// Controller 行动选择者
+type Controller interface {
+	// SelectAction 选择本回合的行动，还未选好时返回false
+	SelectAction(e *Engine, side Side) (Action, bool)
+	// SelectReplacement 选择替换倒下宝可梦的队伍下标，还未选好时返回false
+	SelectReplacement(e *Engine, side Side) (int, bool)
+}
+
+// EventType 战斗事件类型
+type EventType uint8
+
+var EventTypeEnum = enum.New[struct {
+	UseMove         EventType // 使用技能
+	CantMove        EventType // 因异常状态无法行动
+	Miss            EventType // 未命中
+	Critical        EventType // 会心一击
+	Effect          EventType // 效果提示
+	Damage          EventType // 造成伤害
+	HitCount        EventType // 连续攻击次数
+	StatusInflicted EventType // 陷入异常状态
+	StatusCured     EventType // 异常状态解除
+	StatusDamage    EventType // 异常状态伤害
+	StatStage       EventType // 能力等级变化
+	Recoil          EventType // 反伤
+	Drain           EventType // 吸取
+	Heal            EventType // 回复
+	Seeded          EventType // 被寄生
+	SeedDrain       EventType // 寄生种子吸取
+	Faint           EventType // 倒下
+	FleeSuccess     EventType // 逃跑成功
+	FleeFailed      EventType // 逃跑失败
+	Withdraw        EventType // 收回宝可梦
+	SwitchIn        EventType // 派出宝可梦
+	UseItem         EventType // 使用道具
+	ItemEffect      EventType // 道具生效
+	BallShake       EventType // 精灵球摇晃
+	Caught          EventType // 捕获成功
+	BreakFree       EventType // 从精灵球中挣脱
+}]()
+
+// Event 战斗事件，用于界面展示
+type Event struct {
+	Type    EventType
+	Side    Side                    // 事件相关方
+	Pokemon *pokemon.Pokemon        // 相关宝可梦，为空时为事件相关方当前在场的宝可梦
+	Item    *item.Item              // 相关道具
+	Move    *pokemon.Move           // 相关技能
+	Effect  float64                 // 属性克制倍数
+	Damage  int                     // 伤害或回复量
+	Hits    int                     // 攻击次数
+	Status  pokemon.StatusCondition // 异常状态
+	Stat    string                  // 能力项
+	Stages  int                     // 能力等级变化
+}
+
+// Engine 回合制战斗引擎，不依赖任何界面
+type Engine struct {
+	rand        *rand.Rand
+	parties     [2][]*pokemon.Pokemon
+	active      [2]int      // 在场宝可梦的队伍下标
+	battlers    [2]*Battler // 在场宝可梦
+	controllers [2]Controller
+	replacing   [2]bool // 等待替换倒下的宝可梦
+
+	turn         int    // 当前回合数
+	fleeAttempts int    // 逃跑尝试次数
+	result       Result // 战斗结果
+	events       []Event
+}
+
+// ErrNoPokemon 有一方队伍中没有还能战斗的宝可梦
+var ErrNoPokemon = errors.New("battle: no pokemon able to battle")
+
+// NewEngine 创建战斗，双方各自派出队伍中第一只还能战斗的宝可梦
+func NewEngine(r *rand.Rand, selfParty, opponentParty []*pokemon.Pokemon, selfController, opponentController Controller) (*Engine, error) {
+	e := &Engine{
+		rand:        r,
+		parties:     [2][]*pokemon.Pokemon{selfParty, opponentParty},
+		controllers: [2]Controller{selfController, opponentController},
+	}
+	for _, side := range enum.Values[Side](SideEnum) {
+		index := slices.IndexFunc(e.parties[side], func(p *pokemon.Pokemon) bool { return !p.Fainted() })
+		if index < 0 {
+			return nil, ErrNoPokemon
+		}
+		e.active[side], e.battlers[side] = index, NewBattler(e.parties[side][index])
+	}
+	return e, nil
+}
+
+func (e *Engine) Battler(side Side) *Battler {
+	return e.battlers[side]
+}
+
+func (e *Engine) Party(side Side) []*pokemon.Pokemon {
+	return e.parties[side]
+}
+
+// Active 在场宝可梦的队伍下标
+func (e *Engine) Active(side Side) int {
+	return e.active[side]
+}
+
+// Replacing 是否在等待某方替换倒下的宝可梦
+func (e *Engine) Replacing(side Side) bool {
+	return e.replacing[side]
+}
+
+// CanSwitchTo 能否替换为队伍中的某只宝可梦
+func (e *Engine) CanSwitchTo(side Side, index int) bool {
+	party := e.parties[side]
+	return index >= 0 && index < len(party) && index != e.active[side] && !party[index].Fainted()
+}
+
+// 是否还有可以替换上场的宝可梦
+func (e *Engine) hasReplacement(side Side) bool {
+	for i := range e.parties[side] {
+		if e.CanSwitchTo(side, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Engine) Rand() *rand.Rand {
+	return e.rand
+}
+
+func (e *Engine) Turn() int {
+	return e.turn
+}
+
+func (e *Engine) Result() Result {
+	return e.result
+}
+
+// Finished 战斗是否已经结束
+func (e *Engine) Finished() bool {
+	return e.result != ResultEnum.None
+}
+
+// PopEvents 取出所有未处理的事件
+func (e *Engine) PopEvents() []Event {
+	events := e.events
+	e.events = nil
+	return events
+}
+
+// Emit 产生事件
+func (e *Engine) Emit(event Event) {
+	e.events = append(e.events, event)
+}
+
+// Step 向双方询问行动，均选择完毕后执行一个回合
+// @return: 是否执行了回合
+func (e *Engine) Step() bool {
+	if e.Finished() {
+		return false
+	}
+	if e.replacing[SideEnum.Self] || e.replacing[SideEnum.Opponent] {
+		return e.stepReplacement()
+	}
+	var actions [2]Action
+	for _, side := range enum.Values[Side](SideEnum) {
+		action, ok := e.controllers[side].SelectAction(e, side)
+		if !ok {
+			return false
+		}
+		actions[side] = action
+	}
+	e.ExecuteTurn(actions[SideEnum.Self], actions[SideEnum.Opponent])
+	return true
+}
+
+// 双方都选好替换的宝可梦后一起派出
+func (e *Engine) stepReplacement() bool {
+	var indexes [2]int
+	for _, side := range enum.Values[Side](SideEnum) {
+		if !e.replacing[side] {
+			continue
+		}
+		index, ok := e.controllers[side].SelectReplacement(e, side)
+		if !ok || !e.CanSwitchTo(side, index) {
+			return false
+		}
+		indexes[side] = index
+	}
+	for _, side := range enum.Values[Side](SideEnum) {
+		if e.replacing[side] {
+			e.replacing[side] = false
+			e.switchIn(side, indexes[side])
+		}
+	}
+	return true
+}
+
+// 派出队伍中的宝可梦，能力等级等战斗中的变化会被重置
+func (e *Engine) switchIn(side Side, index int) {
+	if old := e.battlers[side]; !old.Fainted() {
+		e.Emit(Event{Type: EventTypeEnum.Withdraw, Side: side, Pokemon: old.Pokemon})
+	}
+	e.active[side], e.battlers[side] = index, NewBattler(e.parties[side][index])
+	e.Emit(Event{Type: EventTypeEnum.SwitchIn, Side: side, Pokemon: e.battlers[side].Pokemon})
+}
+
+type turnAction struct {
+	side   Side
+	action Action
+}
+
+// ExecuteTurn 按优先度和速度执行一个回合
+func (e *Engine) ExecuteTurn(selfAction, opponentAction Action) {
+	if e.Finished() {
+		return
+	}
+	e.turn++
+
+	order := []turnAction{{side: SideEnum.Self, action: selfAction}, {side: SideEnum.Opponent, action: opponentAction}}
+	// 速度相同时随机决定先后
+	if e.rand.IntN(2) == 0 {
+		order[0], order[1] = order[1], order[0]
+	}
+	slices.SortStableFunc(order, func(l, r turnAction) int {
+		if c := cmp.Compare(e.actionPriority(r), e.actionPriority(l)); c != 0 {
+			return c
+		}
+		return cmp.Compare(e.battlers[r.side].Stat(pokemon.StatEnum.Speed), e.battlers[l.side].Stat(pokemon.StatEnum.Speed))
+	})
+
+	for _, ta := range order {
+		if e.Finished() || e.battlers[ta.side].Fainted() {
+			continue
+		}
+		switch ta.action.Type {
+		case ActionTypeEnum.Flee:
+			e.flee(ta.side)
+		case ActionTypeEnum.Switch:
+			if e.CanSwitchTo(ta.side, ta.action.Switch) {
+				e.switchIn(ta.side, ta.action.Switch)
+			}
+		case ActionTypeEnum.Item:
+			e.useItem(ta.side, ta.action)
+		case ActionTypeEnum.Fight:
+			e.useMove(ta.side, ta.action.Move)
+		}
+		e.checkFaint()
+	}
+	if !e.Finished() {
+		e.endTurn()
+		e.checkFaint()
+	}
+}
+
+func (e *Engine) actionPriority(ta turnAction) int {
+	switch ta.action.Type {
+	case ActionTypeEnum.Flee:
+		// 逃跑总是最先行动
+		return 1 << 8
+	case ActionTypeEnum.Switch, ActionTypeEnum.Item:
+		// 替换和道具在逃跑之后、技能之前
+		return 1 << 7
+	case ActionTypeEnum.Fight:
+		move, ok := e.battlers[ta.side].Move(ta.action.Move)
+		if !ok {
+			return 0
+		}
+		return move.Priority
+	default:
+		return 0
+	}
+}
+
+// 逃跑，使用第三世代公式
+func (e *Engine) flee(side Side) {
+	e.fleeAttempts++
+	selfSpeed, otherSpeed := e.battlers[side].Stat(pokemon.StatEnum.Speed), e.battlers[side.Other()].Stat(pokemon.StatEnum.Speed)
+	if selfSpeed >= otherSpeed {
+		e.Emit(Event{Type: EventTypeEnum.FleeSuccess, Side: side})
+		e.result = ResultEnum.Flee
+		return
+	}
+	odds := (selfSpeed*128/otherSpeed + 30*e.fleeAttempts) % 256
+	if e.rand.IntN(256) < odds {
+		e.Emit(Event{Type: EventTypeEnum.FleeSuccess, Side: side})
+		e.result = ResultEnum.Flee
+		return
+	}
+	e.Emit(Event{Type: EventTypeEnum.FleeFailed, Side: side})
+}
+
+// 行动前检查异常状态
+// @return: 能否行动
+func (e *Engine) checkCanMove(side Side) bool {
+	b := e.battlers[side]
+	switch b.Status {
+	case pokemon.StatusConditionEnum.Sleep:
+		b.sleepTurns--
+		if b.sleepTurns > 0 {
+			e.Emit(Event{Type: EventTypeEnum.CantMove, Side: side, Status: b.Status})
+			return false
+		}
+		e.Emit(Event{Type: EventTypeEnum.StatusCured, Side: side, Status: b.Status})
+		b.Status = pokemon.StatusConditionEnum.None
+	case pokemon.StatusConditionEnum.Freeze:
+		if e.rand.IntN(5) != 0 {
+			e.Emit(Event{Type: EventTypeEnum.CantMove, Side: side, Status: b.Status})
+			return false
+		}
+		e.Emit(Event{Type: EventTypeEnum.StatusCured, Side: side, Status: b.Status})
+		b.Status = pokemon.StatusConditionEnum.None
+	case pokemon.StatusConditionEnum.Paralysis:
+		if e.rand.IntN(4) == 0 {
+			e.Emit(Event{Type: EventTypeEnum.CantMove, Side: side, Status: b.Status})
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Engine) useMove(side Side, index int) {
+	attacker, defender := e.battlers[side], e.battlers[side.Other()]
+	struggle := index == StruggleMove
+	var move *pokemon.Move
+	var ok bool
+	if struggle {
+		var err error
+		move, err = pokemon.GetMove(struggleMoveID)
+		ok = err == nil
+	} else {
+		move, ok = attacker.Move(index)
+	}
+	if !ok || !e.checkCanMove(side) {
+		return
+	}
+	if !struggle && attacker.Moves[index].PP > 0 {
+		attacker.Moves[index].PP--
+	}
+	e.Emit(Event{Type: EventTypeEnum.UseMove, Side: side, Move: move})
+
+	// 命中判定
+	if move.Accuracy > 0 && move.Target != pokemon.MoveTargetEnum.Self {
+		num, den := stageMultiplier("accuracy", min(max(attacker.Stages["accuracy"]-defender.Stages["evasion"], -6), 6))
+		if e.rand.IntN(100) >= move.Accuracy*num/den {
+			e.Emit(Event{Type: EventTypeEnum.Miss, Side: side, Move: move})
+			return
+		}
+	}
+
+	ctx := &MoveContext{Engine: e, Side: side, Attacker: attacker, Defender: defender, Move: move, Hits: 1}
+	if move.Category != pokemon.MoveCategoryEnum.Status {
+		effect := move.Type.GetEffectTo(defender.Race.Type)
+		if struggle {
+			// 挣扎不受属性克制影响
+			effect = 1
+		}
+		if effect == 0 {
+			e.Emit(Event{Type: EventTypeEnum.Effect, Side: side, Move: move, Effect: effect})
+			return
+		}
+		e.runMoveEffects(ctx, func(handler MoveEffectHandler) func(ctx *MoveContext) { return handler.BeforeHit }, false)
+
+		hits := 0
+		for ; hits < ctx.Hits && !defender.Fainted(); hits++ {
+			critical := e.rand.IntN(16) == 0
+			damage := defender.TakeDamage(e.calcDamage(attacker, defender, move, effect, critical))
+			ctx.Damage += damage
+			if critical {
+				e.Emit(Event{Type: EventTypeEnum.Critical, Side: side, Move: move})
+			}
+			e.Emit(Event{Type: EventTypeEnum.Damage, Side: side, Move: move, Effect: effect, Damage: damage})
+		}
+		if effect != 1 {
+			e.Emit(Event{Type: EventTypeEnum.Effect, Side: side, Move: move, Effect: effect})
+		}
+		if ctx.Hits > 1 {
+			e.Emit(Event{Type: EventTypeEnum.HitCount, Side: side, Move: move, Hits: hits})
+		}
+	}
+	e.runMoveEffects(ctx, func(handler MoveEffectHandler) func(ctx *MoveContext) { return handler.AfterHit }, true)
+}
+
+// 执行技能追加效果
+func (e *Engine) runMoveEffects(ctx *MoveContext, getter func(handler MoveEffectHandler) func(ctx *MoveContext), rollChance bool) {
+	for _, effect := range ctx.Move.Effects {
+		fn := getter(moveEffectHandlers[effect.Type])
+		if fn == nil {
+			continue
+		} else if rollChance && effect.Chance > 0 && e.rand.IntN(100) >= effect.Chance {
+			continue
+		}
+		ctx.Effect = effect
+		fn(ctx)
+	}
+}
+
+// 回合结束时的异常状态和寄生种子
+func (e *Engine) endTurn() {
+	for _, side := range enum.Values[Side](SideEnum) {
+		b := e.battlers[side]
+		if b.Fainted() {
+			continue
+		}
+		switch b.Status {
+		case pokemon.StatusConditionEnum.Poison, pokemon.StatusConditionEnum.Burn:
+			damage := b.TakeDamage(max(b.MaxHP()/8, 1))
+			e.Emit(Event{Type: EventTypeEnum.StatusDamage, Side: side, Status: b.Status, Damage: damage})
+		case pokemon.StatusConditionEnum.BadPoison:
+			b.toxicTurns++
+			damage := b.TakeDamage(max(b.MaxHP()*b.toxicTurns/16, 1))
+			e.Emit(Event{Type: EventTypeEnum.StatusDamage, Side: side, Status: b.Status, Damage: damage})
+		}
+		if b.seeded && !b.Fainted() {
+			other := e.battlers[side.Other()]
+			damage := b.TakeDamage(max(b.MaxHP()/8, 1))
+			other.Recover(damage)
+			e.Emit(Event{Type: EventTypeEnum.SeedDrain, Side: side, Damage: damage})
+		}
+	}
+}
+
+// 检查倒下并决定胜负
+func (e *Engine) checkFaint() {
+	for _, side := range enum.Values[Side](SideEnum) {
+		b := e.battlers[side]
+		if !b.Fainted() || b.fainted {
+			continue
+		}
+		b.fainted = true
+		e.Emit(Event{Type: EventTypeEnum.Faint, Side: side, Pokemon: b.Pokemon})
+	}
+	for _, side := range enum.Values[Side](SideEnum) {
+		if !e.battlers[side].Fainted() {
+			continue
+		}
+		if !e.hasReplacement(side) {
+			e.result = stlval.Ternary(side == SideEnum.Self, ResultEnum.Lose, ResultEnum.Win)
+			return
+		}
+		e.replacing[side] = true
+	}
+}
+
+// 计算伤害，使用第三世代公式
+func (e *Engine) calcDamage(attacker, defender *Battler, move *pokemon.Move, effect float64, critical bool) int {
+	attackStat, defenseStat := pokemon.StatEnum.Attack, pokemon.StatEnum.Defense
+	if move.Category == pokemon.MoveCategoryEnum.Special {
+		attackStat, defenseStat = pokemon.StatEnum.SpAttack, pokemon.StatEnum.SpDefense
+	}
+	attack, defense := attacker.Stat(attackStat), defender.Stat(defenseStat)
+	damage := float64((2*attacker.Level/5+2)*move.Power*attack/defense/50 + 2)
+	if critical {
+		damage *= 2
+	}
+	// 属性一致加成
+	if attacker.Race.Type.Contain(move.Type) {
+		damage *= 1.5
+	}
+	damage *= effect
+	// 随机浮动 85%~100%
+	damage = damage * float64(85+e.rand.IntN(16)) / 100
+	return max(int(damage), 1)
+}
@@ -0,0 +1,348 @@
+package battle
+
+import (
+	"errors"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kkkunny/pokemon/src/assets"
+	"github.com/kkkunny/pokemon/src/pokemon"
+)
+
+func TestMain(m *testing.M) {
+	// 技能和属性克制表从内置数据读取
+	assets.Mount(os.DirFS(filepath.Join("..", "..", "..", "data")))
+	err := pokemon.LoadTypeChart()
+	if err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// 按顺序返回预先设定的行动，用完后不再行动
+type scriptedController struct {
+	actions      []Action
+	replacements []int
+}
+
+func (c *scriptedController) SelectAction(_ *Engine, _ Side) (Action, bool) {
+	if len(c.actions) == 0 {
+		return Action{}, false
+	}
+	action := c.actions[0]
+	c.actions = c.actions[1:]
+	return action, true
+}
+
+func (c *scriptedController) SelectReplacement(_ *Engine, _ Side) (int, bool) {
+	if len(c.replacements) == 0 {
+		return 0, false
+	}
+	index := c.replacements[0]
+	c.replacements = c.replacements[1:]
+	return index, true
+}
+
+// 50级、个体值和努力值为0、性格无修正的宝可梦，除速度外种族值均为100
+// 能力值：体力160，速度为种族值+5，其他105
+func newTestPokemon(typ pokemon.Type, speed int, moves ...string) *pokemon.Pokemon {
+	p := &pokemon.Pokemon{
+		Race: &pokemon.PokemonRace{
+			Type:      typ,
+			BaseStats: pokemon.Stats{HP: 100, Attack: 100, Defense: 100, SpAttack: 100, SpDefense: 100, Speed: speed},
+		},
+		Level:  50,
+		Nature: pokemon.NatureEnum.Hardy,
+	}
+	for i, move := range moves {
+		p.Moves[i] = pokemon.MoveSlot{Move: move, PP: 10}
+	}
+	p.HP = p.Stats().HP
+	return p
+}
+
+func fight(move int) Action {
+	return Action{Type: ActionTypeEnum.Fight, Move: move}
+}
+
+func newTestEngine(t *testing.T, seed uint64, selfParty, opponentParty []*pokemon.Pokemon, self, opponent Controller) *Engine {
+	t.Helper()
+	e, err := NewEngine(rand.New(rand.NewPCG(seed, 0)), selfParty, opponentParty, self, opponent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func findEvent(events []Event, typ EventType) (Event, bool) {
+	for _, event := range events {
+		if event.Type == typ {
+			return event, true
+		}
+	}
+	return Event{}, false
+}
+
+func TestNewEngine(t *testing.T) {
+	fainted := newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch")
+	fainted.HP = 0
+	tests := []struct {
+		name     string
+		self     []*pokemon.Pokemon
+		opponent []*pokemon.Pokemon
+		err      error
+		active   int // 我方在场宝可梦的下标
+	}{
+		{name: "first", self: []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch")}, opponent: []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch")}},
+		{name: "skip_fainted", self: []*pokemon.Pokemon{fainted, newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch")}, opponent: []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch")}, active: 1},
+		{name: "self_empty", opponent: []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch")}, err: ErrNoPokemon},
+		{name: "opponent_empty", self: []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch")}, err: ErrNoPokemon},
+		{name: "all_fainted", self: []*pokemon.Pokemon{fainted}, opponent: []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch")}, err: ErrNoPokemon},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewEngine(rand.New(rand.NewPCG(1, 0)), tt.self, tt.opponent, &scriptedController{}, &scriptedController{})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error %v, want %v", err, tt.err)
+			}
+			if err == nil && e.Active(SideEnum.Self) != tt.active {
+				t.Fatalf("active %d, want %d", e.Active(SideEnum.Self), tt.active)
+			}
+		})
+	}
+}
+
+func TestTurnOrder(t *testing.T) {
+	tests := []struct {
+		name                     string
+		selfSpeed, opponentSpeed int
+		self, opponent           Action
+		first                    Side
+	}{
+		{name: "faster", selfSpeed: 100, opponentSpeed: 50, self: fight(0), opponent: fight(0), first: SideEnum.Self},
+		{name: "slower", selfSpeed: 50, opponentSpeed: 100, self: fight(0), opponent: fight(0), first: SideEnum.Opponent},
+		{name: "priority_move", selfSpeed: 50, opponentSpeed: 100, self: fight(1), opponent: fight(0), first: SideEnum.Self},
+		{name: "both_priority", selfSpeed: 50, opponentSpeed: 100, self: fight(1), opponent: fight(1), first: SideEnum.Opponent},
+		{name: "switch_before_move", selfSpeed: 50, opponentSpeed: 100, self: Action{Type: ActionTypeEnum.Switch, Switch: 1}, opponent: fight(1), first: SideEnum.Self},
+		{name: "flee_before_switch", selfSpeed: 50, opponentSpeed: 100, self: Action{Type: ActionTypeEnum.Flee}, opponent: Action{Type: ActionTypeEnum.Switch, Switch: 1}, first: SideEnum.Self},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 先后顺序不受速度相同时的随机数影响
+			for seed := range uint64(8) {
+				self := []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, tt.selfSpeed, "scratch", "quick_attack"), newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch")}
+				opponent := []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, tt.opponentSpeed, "scratch", "quick_attack"), newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch")}
+				e := newTestEngine(t, seed, self, opponent, &scriptedController{}, &scriptedController{})
+				e.ExecuteTurn(tt.self, tt.opponent)
+				events := e.PopEvents()
+				if len(events) == 0 {
+					t.Fatal("no events")
+				}
+				if events[0].Side != tt.first {
+					t.Fatalf("seed %d: first event %+v, want side %d", seed, events[0], tt.first)
+				}
+			}
+		})
+	}
+
+	// 速度相同时双方都有可能先行动
+	var seen [2]bool
+	for seed := range uint64(32) {
+		self := []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch")}
+		opponent := []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch")}
+		e := newTestEngine(t, seed, self, opponent, &scriptedController{}, &scriptedController{})
+		e.ExecuteTurn(fight(0), fight(0))
+		seen[e.PopEvents()[0].Side] = true
+	}
+	if !seen[SideEnum.Self] || !seen[SideEnum.Opponent] {
+		t.Fatalf("speed tie always resolved the same way: %v", seen)
+	}
+}
+
+func TestTypeEffect(t *testing.T) {
+	tests := []struct {
+		name     string
+		move     string
+		defender pokemon.Type
+		effect   float64
+	}{
+		{name: "super_effective", move: "water_gun", defender: pokemon.TypeEnum.Fire, effect: 2},
+		{name: "not_very_effective", move: "water_gun", defender: pokemon.TypeEnum.Grass, effect: 0.5},
+		{name: "dual_type", move: "water_gun", defender: pokemon.TypeEnum.Fire | pokemon.TypeEnum.Ground, effect: 4},
+		{name: "cancel_out", move: "ember", defender: pokemon.TypeEnum.Grass | pokemon.TypeEnum.Water, effect: 1},
+		{name: "no_effect", move: "scratch", defender: pokemon.TypeEnum.Ghost, effect: 0},
+		{name: "immune", move: "thunder_shock", defender: pokemon.TypeEnum.Ground, effect: 0},
+	}
+	// 攻击方先行动且不是属性一致，随机数相同时伤害只差属性克制倍数
+	damage := func(t *testing.T, move string, defender pokemon.Type) (Event, bool) {
+		t.Helper()
+		self := []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Psychic, 100, move)}
+		opponent := []*pokemon.Pokemon{newTestPokemon(defender, 50, "growth")}
+		e := newTestEngine(t, 1, self, opponent, &scriptedController{}, &scriptedController{})
+		e.ExecuteTurn(fight(0), fight(0))
+		return findEvent(e.PopEvents(), EventTypeEnum.Damage)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			neutral, ok := damage(t, tt.move, pokemon.TypeEnum.Psychic)
+			if !ok || neutral.Effect != 1 {
+				t.Fatalf("neutral hit: %+v", neutral)
+			}
+			got, ok := damage(t, tt.move, tt.defender)
+			if tt.effect == 0 {
+				if ok {
+					t.Fatalf("damaged an immune target: %+v", got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("no damage")
+			}
+			if got.Effect != tt.effect {
+				t.Fatalf("effect %v, want %v", got.Effect, tt.effect)
+			}
+			// 只有取整带来的误差
+			want := float64(neutral.Damage) * tt.effect
+			if float64(got.Damage) < want-1 || float64(got.Damage) > want+tt.effect {
+				t.Fatalf("damage %d, neutral %d, effect %v", got.Damage, neutral.Damage, tt.effect)
+			}
+		})
+	}
+}
+
+func TestFaintAndReplacement(t *testing.T) {
+	self := []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, 100, "scratch"), newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch")}
+	opponent := []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch"), newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch")}
+	opponent[0].HP = 1
+	e := newTestEngine(t, 1, self, opponent, &scriptedController{actions: []Action{fight(0)}}, NewAIController())
+
+	if !e.Step() {
+		t.Fatal("turn not executed")
+	}
+	events := e.PopEvents()
+	if event, ok := findEvent(events, EventTypeEnum.Faint); !ok || event.Side != SideEnum.Opponent {
+		t.Fatalf("no faint event for the opponent: %+v", events)
+	}
+	// 倒下的宝可梦不能再行动
+	for _, event := range events {
+		if event.Type == EventTypeEnum.UseMove && event.Side == SideEnum.Opponent {
+			t.Fatal("fainted pokemon used a move")
+		}
+	}
+	if e.Finished() || !e.Replacing(SideEnum.Opponent) || e.Replacing(SideEnum.Self) {
+		t.Fatalf("result %v, replacing %v/%v", e.Result(), e.Replacing(SideEnum.Self), e.Replacing(SideEnum.Opponent))
+	}
+	if e.CanSwitchTo(SideEnum.Opponent, 0) {
+		t.Fatal("can switch to a fainted pokemon")
+	}
+
+	// 替换完成前不会进入下一回合
+	if !e.Step() {
+		t.Fatal("replacement not executed")
+	}
+	if e.Replacing(SideEnum.Opponent) || e.Active(SideEnum.Opponent) != 1 {
+		t.Fatalf("replacing %v, active %d", e.Replacing(SideEnum.Opponent), e.Active(SideEnum.Opponent))
+	}
+	if event, ok := findEvent(e.PopEvents(), EventTypeEnum.SwitchIn); !ok || event.Pokemon != opponent[1] {
+		t.Fatalf("switch in event %+v", event)
+	}
+	if e.Turn() != 1 {
+		t.Fatalf("turn %d, want 1", e.Turn())
+	}
+}
+
+func TestInvalidReplacement(t *testing.T) {
+	self := []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch"), newTestPokemon(pokemon.TypeEnum.Normal, 50, "scratch")}
+	opponent := []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, 100, "scratch")}
+	self[0].HP = 1
+	controller := &scriptedController{actions: []Action{fight(0)}, replacements: []int{0, 1}}
+	e := newTestEngine(t, 1, self, opponent, controller, NewAIController())
+	e.Step()
+	if !e.Replacing(SideEnum.Self) {
+		t.Fatal("self not replacing")
+	}
+	// 选择倒下的宝可梦时继续等待
+	if e.Step() || !e.Replacing(SideEnum.Self) {
+		t.Fatal("replaced with a fainted pokemon")
+	}
+	if !e.Step() || e.Active(SideEnum.Self) != 1 {
+		t.Fatalf("active %d, want 1", e.Active(SideEnum.Self))
+	}
+}
+
+func TestFlee(t *testing.T) {
+	tests := []struct {
+		name                     string
+		selfSpeed, opponentSpeed int
+		attempt                  int // 第几次尝试逃跑
+		odds                     int // 成功率（单位：1/256），小于0时必定成功
+	}{
+		{name: "faster", selfSpeed: 100, opponentSpeed: 50, attempt: 1, odds: -1},
+		{name: "same_speed", selfSpeed: 50, opponentSpeed: 50, attempt: 1, odds: -1},
+		// 速度55对105：55*128/105 = 67
+		{name: "slower", selfSpeed: 50, opponentSpeed: 100, attempt: 1, odds: 67 + 30},
+		{name: "third_attempt", selfSpeed: 50, opponentSpeed: 100, attempt: 3, odds: 67 + 90},
+		// 15*128/205 = 9
+		{name: "much_slower", selfSpeed: 10, opponentSpeed: 200, attempt: 1, odds: 9 + 30},
+		// 超过255后回绕
+		{name: "overflow", selfSpeed: 50, opponentSpeed: 100, attempt: 7, odds: (67 + 210) % 256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var successes int
+			for seed := range uint64(256) {
+				self := []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, tt.selfSpeed, "scratch")}
+				opponent := []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, tt.opponentSpeed, "scratch")}
+				e := newTestEngine(t, seed, self, opponent, &scriptedController{}, &scriptedController{})
+				e.fleeAttempts = tt.attempt - 1
+				e.flee(SideEnum.Self)
+
+				// 用相同种子的随机数推算预期结果
+				want := tt.odds < 0 || rand.New(rand.NewPCG(seed, 0)).IntN(256) < tt.odds
+				if got := e.Result() == ResultEnum.Flee; got != want {
+					t.Fatalf("seed %d: fled %v, want %v", seed, got, want)
+				}
+				if want {
+					successes++
+				}
+			}
+			if tt.odds >= 0 && (successes == 0 || successes == 256) {
+				t.Fatalf("%d/256 successes with odds %d", successes, tt.odds)
+			}
+		})
+	}
+}
+
+func TestResult(t *testing.T) {
+	tests := []struct {
+		name                     string
+		selfHP, opponentHP       int
+		selfSpeed, opponentSpeed int
+		result                   Result
+	}{
+		{name: "win", selfHP: 160, opponentHP: 1, selfSpeed: 100, opponentSpeed: 50, result: ResultEnum.Win},
+		{name: "lose", selfHP: 1, opponentHP: 160, selfSpeed: 50, opponentSpeed: 100, result: ResultEnum.Lose},
+		// 先行动的一方先击倒对方
+		{name: "faster_wins", selfHP: 1, opponentHP: 1, selfSpeed: 100, opponentSpeed: 50, result: ResultEnum.Win},
+		{name: "slower_loses", selfHP: 1, opponentHP: 1, selfSpeed: 50, opponentSpeed: 100, result: ResultEnum.Lose},
+		{name: "continue", selfHP: 160, opponentHP: 160, selfSpeed: 100, opponentSpeed: 50, result: ResultEnum.None},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			self := []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, tt.selfSpeed, "scratch")}
+			opponent := []*pokemon.Pokemon{newTestPokemon(pokemon.TypeEnum.Normal, tt.opponentSpeed, "scratch")}
+			self[0].HP, opponent[0].HP = tt.selfHP, tt.opponentHP
+			e := newTestEngine(t, 1, self, opponent, &scriptedController{actions: []Action{fight(0), fight(0)}}, NewAIController())
+			e.Step()
+			if e.Result() != tt.result {
+				t.Fatalf("result %v, want %v", e.Result(), tt.result)
+			}
+			// 结束后不再执行回合
+			if e.Finished() && e.Step() {
+				t.Fatal("turn executed after the battle finished")
+			}
+		})
+	}
+}
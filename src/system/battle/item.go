@@ -0,0 +1,72 @@
+package battle
+
+import (
+	"math"
+
+	"github.com/kkkunny/pokemon/src/item"
+	"github.com/kkkunny/pokemon/src/pokemon"
+)
+
+// 投掷精灵球，由战斗引擎处理
+const catchEffect = "catch"
+
+func init() {
+	item.RegisterUseHandler(catchEffect, item.UseHandler{})
+}
+
+// IsBall 是否是用于捕获的道具
+func IsBall(it *item.Item) bool {
+	return it.Battle == catchEffect
+}
+
+// 使用道具
+func (e *Engine) useItem(side Side, action Action) {
+	it, err := item.GetItem(action.Item)
+	if err != nil {
+		return
+	}
+	e.Emit(Event{Type: EventTypeEnum.UseItem, Side: side, Item: it})
+	if IsBall(it) {
+		e.throwBall(side, it)
+		return
+	}
+	if action.Target < 0 || action.Target >= len(e.parties[side]) {
+		return
+	}
+	target := e.parties[side][action.Target]
+	if !item.Use(&item.UseContext{Item: it, Target: target, Battle: true}) {
+		e.Emit(Event{Type: EventTypeEnum.ItemEffect, Side: side, Item: it})
+		return
+	}
+	e.Emit(Event{Type: EventTypeEnum.ItemEffect, Side: side, Pokemon: target, Item: it})
+}
+
+// 投掷精灵球，使用第三世代公式
+func (e *Engine) throwBall(side Side, ball *item.Item) {
+	target := e.battlers[side.Other()]
+	maxHP := float64(target.MaxHP())
+	a := (3*maxHP - 2*float64(target.HP)) * float64(target.Race.CatchRate) * float64(ball.Amount) / 10 / (3 * maxHP)
+	switch target.Status {
+	case pokemon.StatusConditionEnum.Sleep, pokemon.StatusConditionEnum.Freeze:
+		a *= 2
+	case pokemon.StatusConditionEnum.Paralysis, pokemon.StatusConditionEnum.Poison, pokemon.StatusConditionEnum.BadPoison, pokemon.StatusConditionEnum.Burn:
+		a *= 1.5
+	}
+
+	shakes := 4
+	if a < 255 {
+		b := 1048560 / math.Sqrt(math.Sqrt(16711680/max(a, 1)))
+		for shakes = 0; shakes < 4; shakes++ {
+			if float64(e.rand.IntN(65536)) >= b {
+				break
+			}
+		}
+	}
+	e.Emit(Event{Type: EventTypeEnum.BallShake, Side: side, Hits: min(shakes, 3)})
+	if shakes < 4 {
+		e.Emit(Event{Type: EventTypeEnum.BreakFree, Side: side.Other()})
+		return
+	}
+	e.Emit(Event{Type: EventTypeEnum.Caught, Side: side.Other()})
+	e.result = ResultEnum.Catch
+}
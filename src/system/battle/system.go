@@ -1,92 +1,480 @@
 package battle
 
 import (
+	"fmt"
 	"image/color"
-	"path/filepath"
+	"path"
 
 	"golang.org/x/image/font"
 
 	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/item"
 	"github.com/kkkunny/pokemon/src/pokemon"
+	"github.com/kkkunny/pokemon/src/system/bag"
 	"github.com/kkkunny/pokemon/src/system/context"
+	"github.com/kkkunny/pokemon/src/system/party"
 	"github.com/kkkunny/pokemon/src/util"
 	"github.com/kkkunny/pokemon/src/util/draw"
 	imgutil "github.com/kkkunny/pokemon/src/util/image"
 )
 
+// 界面阶段
+type phase uint8
+
+const (
+	phaseMessage phase = iota // 显示消息
+	phaseCommand              // 选择指令
+	phaseMove                 // 选择技能
+	phaseParty                // 选择宝可梦
+	phaseBag                  // 选择道具
+)
+
+// 指令
+const (
+	commandFight   = iota // 战斗
+	commandBag            // 背包
+	commandPokemon        // 宝可梦
+	commandFlee           // 逃跑
+	commandCount
+)
+
 type System struct {
 	ctx context.Context
 
 	active    bool
 	siteImage imgutil.Image // 战斗场地
 
-	pok *pokemon.PokemonRace
+	party       *pokemon.Party // 玩家队伍
+	partyScreen *party.Screen  // 队伍界面
+	bag         *item.Bag      // 玩家背包
+	bagScreen   *bag.Screen    // 背包界面
+	pendingItem *item.Item     // 正在选择使用对象的道具
+	trainer     string         // 对手训练师名，野生战斗时为空
+
+	engine   *Engine
+	player   *PlayerController
+	phase    phase
+	messages []string // 待显示的消息
+	cursor   int      // 指令或技能光标
+
+	onBattleEnd func(result Result) error // 战斗结束回调
 }
 
 func NewSystem(ctx context.Context) (*System, error) {
-	pok, err := pokemon.NewPokemonRace(1)
+	err := ValidateMoveEffects()
 	if err != nil {
 		return nil, err
 	}
-	return &System{
-		ctx: ctx,
-		pok: pok,
-	}, nil
+	s := &System{
+		ctx:         ctx,
+		partyScreen: party.NewScreen(ctx),
+		bagScreen:   bag.NewScreen(ctx),
+	}
+	s.partyScreen.SetOnSelect(s.onPartySelect)
+	s.partyScreen.SetOnClose(s.onPartyClose)
+	s.bagScreen.SetOnUse(s.onBagUse)
+	s.bagScreen.SetOnClose(s.onBagClose)
+	return s, nil
+}
+
+func (s *System) SetOnBattleEnd(f func(result Result) error) {
+	s.onBattleEnd = f
 }
 
 func (s *System) Active() bool {
 	return s.active
 }
 
-func (s *System) StartOneBattle(site string) error {
-	siteImage, err := imgutil.NewImageFromFile(filepath.Join(config.GFXBattleSitesPath, site+".png"))
+// StartOneBattle 开始一场野生宝可梦战斗，玩家派出队伍中第一只还能战斗的宝可梦
+func (s *System) StartOneBattle(playerParty *pokemon.Party, playerBag *item.Bag, site string, species int16, level int) error {
+	race, err := pokemon.GetPokemonRace(species)
 	if err != nil {
 		return err
 	}
-	s.siteImage = siteImage.Scale(config.Scale, config.Scale)
+	err = s.start(playerParty, playerBag, site, "", []*pokemon.Pokemon{pokemon.NewPokemon(race, level, s.ctx.Rand(context.RandStreamEnum.Pokemon))})
+	if err != nil {
+		return err
+	}
+	s.messages = []string{
+		fmt.Sprintf(s.ctx.Localisation().Get("battle.wild_appear"), s.battlerName(SideEnum.Opponent)),
+		fmt.Sprintf(s.ctx.Localisation().Get("battle.send_out"), s.battlerName(SideEnum.Self)),
+	}
+	return nil
+}
+
+// StartTrainerBattle 开始一场训练师战斗，不能逃跑也不能捕捉
+func (s *System) StartTrainerBattle(playerParty *pokemon.Party, playerBag *item.Bag, site string, trainer string, team []*pokemon.Pokemon) error {
+	if len(team) == 0 {
+		return fmt.Errorf("trainer `%s` has no pokemon", trainer)
+	}
+	err := s.start(playerParty, playerBag, site, trainer, team)
+	if err != nil {
+		return err
+	}
+	s.messages = []string{
+		fmt.Sprintf(s.ctx.Localisation().Get("battle.trainer_challenge"), trainer),
+		fmt.Sprintf(s.ctx.Localisation().Get("battle.trainer_send_out"), trainer, s.battlerName(SideEnum.Opponent)),
+		fmt.Sprintf(s.ctx.Localisation().Get("battle.send_out"), s.battlerName(SideEnum.Self)),
+	}
+	return nil
+}
+
+func (s *System) start(playerParty *pokemon.Party, playerBag *item.Bag, site string, trainer string, opponents []*pokemon.Pokemon) error {
+	siteImage, err := imgutil.NewImageFromFile(path.Join(config.GFXBattleSitesPath, site+".png"))
+	if err != nil {
+		return err
+	}
+	s.siteImage = siteImage.Scale(float64(s.ctx.Config().Scale), float64(s.ctx.Config().Scale))
+
+	player := NewPlayerController()
+	engine, err := NewEngine(s.ctx.Rand(context.RandStreamEnum.Battle), playerParty.Members(), opponents, player, NewAIController())
+	if err != nil {
+		return err
+	}
+	s.player = player
+	s.party = playerParty
+	s.bag = playerBag
+	s.pendingItem = nil
+	s.trainer = trainer
+	s.engine = engine
+	s.phase = phaseMessage
+	s.cursor = 0
 	s.active = true
 	return nil
 }
 
+// Trainer 是否为训练师战斗
+func (s *System) Trainer() bool {
+	return s.trainer != ""
+}
+
+func (s *System) battlerName(side Side) string {
+	return s.engine.Battler(side).Name(s.ctx.Localisation())
+}
+
+// 将战斗事件转为消息
+func (s *System) eventMessage(event Event) (string, bool) {
+	loc := s.ctx.Localisation()
+	name := s.battlerName(event.Side)
+	if event.Pokemon != nil {
+		name = event.Pokemon.Name(loc)
+	}
+	switch event.Type {
+	case EventTypeEnum.UseMove:
+		return fmt.Sprintf(loc.Get("battle.use_move"), name, event.Move.Name(loc)), true
+	case EventTypeEnum.CantMove:
+		return fmt.Sprintf(loc.Get(fmt.Sprintf("battle.cant_move.%s", event.Status)), name), true
+	case EventTypeEnum.Miss:
+		return fmt.Sprintf(loc.Get("battle.miss"), name), true
+	case EventTypeEnum.Critical:
+		return loc.Get("battle.critical"), true
+	case EventTypeEnum.Effect:
+		switch {
+		case event.Effect == 0:
+			return fmt.Sprintf(loc.Get("battle.no_effect"), s.battlerName(event.Side.Other())), true
+		case event.Effect > 1:
+			return loc.Get("battle.super_effective"), true
+		default:
+			return loc.Get("battle.not_very_effective"), true
+		}
+	case EventTypeEnum.HitCount:
+		return fmt.Sprintf(loc.Get("battle.hit_count"), event.Hits), true
+	case EventTypeEnum.StatusInflicted:
+		return fmt.Sprintf(loc.Get(fmt.Sprintf("battle.status.%s", event.Status)), name), true
+	case EventTypeEnum.StatusCured:
+		return fmt.Sprintf(loc.Get(fmt.Sprintf("battle.cured.%s", event.Status)), name), true
+	case EventTypeEnum.StatusDamage:
+		return fmt.Sprintf(loc.Get(fmt.Sprintf("battle.hurt.%s", event.Status)), name), true
+	case EventTypeEnum.StatStage:
+		statName := loc.Get("stat." + event.Stat)
+		switch {
+		case event.Stages > 0:
+			return fmt.Sprintf(loc.Get("battle.stat_rose"), name, statName), true
+		case event.Stages < 0:
+			return fmt.Sprintf(loc.Get("battle.stat_fell"), name, statName), true
+		default:
+			return fmt.Sprintf(loc.Get("battle.stat_unchanged"), name, statName), true
+		}
+	case EventTypeEnum.Recoil:
+		return fmt.Sprintf(loc.Get("battle.recoil"), name), true
+	case EventTypeEnum.Drain:
+		return fmt.Sprintf(loc.Get("battle.drain"), s.battlerName(event.Side.Other())), true
+	case EventTypeEnum.Heal:
+		return fmt.Sprintf(loc.Get("battle.heal"), name), true
+	case EventTypeEnum.Seeded:
+		return fmt.Sprintf(loc.Get("battle.seeded"), name), true
+	case EventTypeEnum.SeedDrain:
+		return fmt.Sprintf(loc.Get("battle.seed_drain"), name), true
+	case EventTypeEnum.Faint:
+		return fmt.Sprintf(loc.Get("battle.faint"), name), true
+	case EventTypeEnum.FleeSuccess:
+		return loc.Get("battle.flee_success"), true
+	case EventTypeEnum.FleeFailed:
+		return loc.Get("battle.flee_failed"), true
+	case EventTypeEnum.Withdraw:
+		if event.Side == SideEnum.Opponent {
+			return fmt.Sprintf(loc.Get("battle.opponent_withdraw"), name), true
+		}
+		return fmt.Sprintf(loc.Get("battle.withdraw"), name), true
+	case EventTypeEnum.SwitchIn:
+		if event.Side == SideEnum.Opponent {
+			return fmt.Sprintf(loc.Get("battle.opponent_send_out"), name), true
+		}
+		return fmt.Sprintf(loc.Get("battle.send_out"), name), true
+	case EventTypeEnum.UseItem:
+		return fmt.Sprintf(loc.Get("battle.use_item"), event.Item.Name(loc)), true
+	case EventTypeEnum.ItemEffect:
+		handler, ok := item.GetUseHandler(event.Item, true)
+		if event.Pokemon == nil || !ok || handler.Message == "" {
+			return loc.Get("battle.item_no_effect"), true
+		}
+		return fmt.Sprintf(loc.Get(handler.Message), name), true
+	case EventTypeEnum.BallShake:
+		if event.Hits == 0 {
+			return "", false
+		}
+		return fmt.Sprintf(loc.Get("battle.ball_shake"), event.Hits), true
+	case EventTypeEnum.BreakFree:
+		return fmt.Sprintf(loc.Get("battle.break_free"), name), true
+	case EventTypeEnum.Caught:
+		return fmt.Sprintf(loc.Get("battle.caught"), name), true
+	default:
+		return "", false
+	}
+}
+
+func (s *System) OnAction(action input.KeyInputAction) error {
+	switch s.phase {
+	case phaseMessage:
+		if action != input.KeyInputActionEnum.A.Pressed() && action != input.KeyInputActionEnum.B.Pressed() || len(s.messages) == 0 {
+			return nil
+		}
+		s.messages = s.messages[1:]
+		if len(s.messages) > 0 {
+			return nil
+		}
+		if s.engine.Finished() {
+			return s.end()
+		}
+		if s.engine.Replacing(SideEnum.Self) {
+			// 宝可梦倒下后必须选择下一只
+			s.phase = phaseParty
+			s.partyScreen.Open(s.party, party.ModeEnum.Forced, s.engine.Active(SideEnum.Self))
+			return nil
+		}
+		s.phase = phaseCommand
+		s.cursor = 0
+	case phaseCommand:
+		switch action {
+		case input.KeyInputActionEnum.MoveUp.Pressed():
+			s.cursor = (s.cursor + commandCount - 1) % commandCount
+		case input.KeyInputActionEnum.MoveDown.Pressed():
+			s.cursor = (s.cursor + 1) % commandCount
+		case input.KeyInputActionEnum.A.Pressed():
+			switch s.cursor {
+			case commandFight:
+				s.phase = phaseMove
+				s.cursor = 0
+			case commandBag:
+				s.phase = phaseBag
+				s.bagScreen.Open(s.bag, bag.ModeEnum.Battle)
+			case commandPokemon:
+				s.phase = phaseParty
+				s.partyScreen.Open(s.party, party.ModeEnum.Battle, s.engine.Active(SideEnum.Self))
+			case commandFlee:
+				if s.Trainer() {
+					s.messages = []string{s.ctx.Localisation().Get("battle.no_flee")}
+					s.phase = phaseMessage
+					return nil
+				}
+				s.player.Submit(Action{Type: ActionTypeEnum.Flee})
+				s.phase = phaseMessage
+			}
+		}
+	case phaseMove:
+		self := s.engine.Battler(SideEnum.Self)
+		moveCount := len(self.Moves)
+		switch action {
+		case input.KeyInputActionEnum.MoveUp.Pressed():
+			for i := 1; i < moveCount; i++ {
+				if index := (s.cursor + moveCount - i) % moveCount; !self.Moves[index].Empty() {
+					s.cursor = index
+					break
+				}
+			}
+		case input.KeyInputActionEnum.MoveDown.Pressed():
+			for i := 1; i < moveCount; i++ {
+				if index := (s.cursor + i) % moveCount; !self.Moves[index].Empty() {
+					s.cursor = index
+					break
+				}
+			}
+		case input.KeyInputActionEnum.A.Pressed():
+			if len(self.UsableMoves()) == 0 {
+				s.player.Submit(Action{Type: ActionTypeEnum.Fight, Move: StruggleMove})
+				s.phase = phaseMessage
+			} else if self.Moves[s.cursor].PP > 0 {
+				s.player.Submit(Action{Type: ActionTypeEnum.Fight, Move: s.cursor})
+				s.phase = phaseMessage
+			}
+		case input.KeyInputActionEnum.B.Pressed():
+			s.phase = phaseCommand
+			s.cursor = commandFight
+		}
+	case phaseParty:
+		return s.partyScreen.OnAction(action)
+	case phaseBag:
+		return s.bagScreen.OnAction(action)
+	}
+	return nil
+}
+
+// 在队伍界面选择了替换上场的宝可梦或道具的使用对象
+func (s *System) onPartySelect(index int) error {
+	if s.pendingItem != nil {
+		it := s.pendingItem
+		s.pendingItem = nil
+		target, _ := s.party.Get(index)
+		if !item.CanUse(&item.UseContext{Item: it, Target: target, Battle: true}) {
+			s.phase = phaseBag
+			s.bagScreen.ShowMessage(s.ctx.Localisation().Get("bag.no_effect"))
+			return nil
+		}
+		s.submitItem(it, index)
+		return nil
+	}
+	s.player.Submit(Action{Type: ActionTypeEnum.Switch, Switch: index})
+	s.phase = phaseMessage
+	return nil
+}
+
+// 未选择就关闭了队伍界面
+func (s *System) onPartyClose() error {
+	if s.pendingItem != nil {
+		// 回到背包
+		s.pendingItem = nil
+		s.phase = phaseBag
+		return nil
+	}
+	s.phase = phaseCommand
+	s.cursor = commandPokemon
+	return nil
+}
+
+// 在背包中选择使用道具
+func (s *System) onBagUse(it *item.Item) error {
+	if IsBall(it) {
+		if s.Trainer() {
+			s.bagScreen.ShowMessage(s.ctx.Localisation().Get("battle.no_catch_trainer"))
+			return nil
+		}
+		if s.party.Full() {
+			s.bagScreen.ShowMessage(s.ctx.Localisation().Get("bag.party_full"))
+			return nil
+		}
+		s.submitItem(it, 0)
+		return nil
+	}
+	handler, _ := item.GetUseHandler(it, true)
+	if handler.NeedTarget {
+		s.pendingItem = it
+		s.phase = phaseParty
+		s.partyScreen.Open(s.party, party.ModeEnum.Select, s.engine.Active(SideEnum.Self))
+		return nil
+	}
+	s.submitItem(it, 0)
+	return nil
+}
+
+// 关闭了背包
+func (s *System) onBagClose() error {
+	s.phase = phaseCommand
+	s.cursor = commandBag
+	return nil
+}
+
+// 提交使用道具的行动，道具在生效时从背包中扣除
+func (s *System) submitItem(it *item.Item, target int) {
+	s.bagScreen.Close()
+	s.player.Submit(Action{Type: ActionTypeEnum.Item, Item: it.ID, Target: target})
+	s.phase = phaseMessage
+}
+
 func (s *System) OnUpdate() error {
+	if !s.engine.Step() {
+		return nil
+	}
+	for _, event := range s.engine.PopEvents() {
+		if event.Type == EventTypeEnum.UseItem && event.Side == SideEnum.Self && !event.Item.Reusable {
+			s.bag.Remove(event.Item.ID, 1)
+		}
+		msg, ok := s.eventMessage(event)
+		if ok {
+			s.messages = append(s.messages, msg)
+		}
+	}
+	if len(s.messages) > 0 {
+		s.phase = phaseMessage
+	}
+	if s.engine.Finished() {
+		switch s.engine.Result() {
+		case ResultEnum.Win:
+			if s.Trainer() {
+				s.messages = append(s.messages, fmt.Sprintf(s.ctx.Localisation().Get("battle.trainer_defeated"), s.trainer))
+			} else {
+				s.messages = append(s.messages, s.ctx.Localisation().Get("battle.win"))
+			}
+		case ResultEnum.Lose:
+			s.messages = append(s.messages, s.ctx.Localisation().Get("battle.lose"))
+		}
+	}
 	return nil
 }
 
+// 结束战斗并回到世界
+func (s *System) end() error {
+	s.active = false
+	if s.engine.Result() == ResultEnum.Catch {
+		s.party.Add(s.engine.Battler(SideEnum.Opponent).Pokemon)
+	}
+	if s.onBattleEnd == nil {
+		return nil
+	}
+	return s.onBattleEnd(s.engine.Result())
+}
+
 func (s *System) frontSize() (int, int) {
 	displayText := s.ctx.Localisation().Get("game_name")
 	bounds, _ := font.BoundString(util.GetFont(util.FontTypeEnum.Normal, 32).UnsafeInternal(), displayText)
 	return (bounds.Max.X - bounds.Min.X).Round() / len([]rune(displayText)), (bounds.Max.Y - bounds.Min.Y).Round()
 }
 
-func (s *System) drawPokemonStatusCard(drawer draw.OptionDrawer) {
-	draw.PrepareDrawRect(drawer, 300, 80, util.NewNRGBColor(248, 248, 216)).SetBorderWidth(5).SetBorderColor(color.Black).Draw()
-	opponentName := s.ctx.Localisation().Get("pokemon.1")
-	opponentNameBounds, _ := font.BoundString(util.GetFont(util.FontTypeEnum.Normal, 26).UnsafeInternal(), opponentName)
-	draw.PrepareDrawText(drawer, opponentName, util.GetFont(util.FontTypeEnum.Normal, 26), color.Black).Move(20, 10).Draw()
-	genderText := "♂"
-	genderBounds, _ := font.BoundString(util.GetFont(util.FontTypeEnum.Emoji, 16).UnsafeInternal(), opponentName)
-	draw.PrepareDrawText(drawer, genderText, util.GetFont(util.FontTypeEnum.Emoji, 16), util.NewNRGBColor(65, 200, 248)).Move(20+opponentNameBounds.Max.X.Round(), 10+opponentNameBounds.Max.Y.Round()-genderBounds.Max.Y.Round()).Draw()
-	draw.PrepareDrawText(drawer, "Lv  5", util.GetFont(util.FontTypeEnum.Normal, 26), color.Black).Move(220, 10).Draw()
-	draw.PrepareDrawRect(drawer, 220, 20, util.NewNRGBColor(80, 104, 88)).Move(70, 50).SetRadius(7).Draw()
-	draw.PrepareDrawText(drawer, "HP", util.GetFont(util.FontTypeEnum.Normal, 20), util.NewNRGBColor(248, 178, 65)).Move(76, 50).Draw()
-	draw.PrepareDrawRect(drawer, 192, 16, color.White).Move(96, 52).SetRadius(5).Draw()
-	draw.PrepareDrawRect(drawer, 188, 12, util.NewNRGBColor(80, 104, 88)).Move(98, 54).SetRadius(3).Draw()
-	hp := 100
-	draw.PrepareDrawRect(drawer, int(float64(188)/100*float64(hp)), 12, util.NewNRGBColor(110, 245, 165)).Move(98, 54).SetRadius(3).Draw()
+func (s *System) drawPokemonStatusCard(drawer draw.OptionDrawer, side Side) {
+	battler := s.engine.Battler(side)
+	party.DrawStatusCard(drawer, s.ctx.Localisation(), battler.Pokemon, battler.MaxHP())
 }
 
 func (s *System) OnDraw(drawer draw.OptionDrawer) error {
+	switch s.phase {
+	case phaseParty:
+		return s.partyScreen.OnDraw(drawer)
+	case phaseBag:
+		return s.bagScreen.OnDraw(drawer)
+	}
 	draw.OverlayColor(drawer, color.White)
 
 	screenWidth, screenHeight := drawer.Bounds().Dx(), drawer.Bounds().Dy()
+	scale := s.ctx.Config().Scale
 
 	// 敌方
 	opponentSiteX, opponentSiteY := screenWidth-s.siteImage.Bounds().Dx(), screenHeight/2-s.siteImage.Bounds().Dy()
 	draw.PrepareDrawImage(drawer, s.siteImage).Move(opponentSiteX, opponentSiteY).Draw()
-	s.pok.Front.Update()
-	pokemonImage := s.pok.Front.GetCurrentFrameImage()
-	draw.PrepareDrawImage(drawer, pokemonImage).Scale(config.Scale, config.Scale).Move(opponentSiteX+s.siteImage.Bounds().Dx()/2-pokemonImage.Bounds().Dx()/2*config.Scale, opponentSiteY+s.siteImage.Bounds().Dy()/4*3-pokemonImage.Bounds().Dy()*config.Scale).Draw()
-	s.drawPokemonStatusCard(drawer.Move(80, 50))
+	opponentRace := s.engine.Battler(SideEnum.Opponent).Race
+	opponentRace.Front.Update()
+	pokemonImage := opponentRace.Front.GetCurrentFrameImage()
+	draw.PrepareDrawImage(drawer, pokemonImage).Scale(float64(scale), float64(scale)).Move(opponentSiteX+s.siteImage.Bounds().Dx()/2-pokemonImage.Bounds().Dx()/2*scale, opponentSiteY+s.siteImage.Bounds().Dy()/4*3-pokemonImage.Bounds().Dy()*scale).Draw()
+	s.drawPokemonStatusCard(drawer.Move(80, 50), SideEnum.Opponent)
 
 	// 我方
 	fontW, fontH := s.frontSize()
@@ -94,10 +482,11 @@ func (s *System) OnDraw(drawer draw.OptionDrawer) error {
 
 	selfSiteX, selfSiteY := 0, screenHeight-bgH-10-s.siteImage.Bounds().Dy()/3*2
 	draw.PrepareDrawImage(drawer, s.siteImage).Move(selfSiteX, selfSiteY).Draw()
-	s.pok.Back.Update()
-	pokemonImage = s.pok.Back.GetCurrentFrameImage()
-	draw.PrepareDrawImage(drawer, pokemonImage).Scale(config.Scale, config.Scale).Move(selfSiteX+s.siteImage.Bounds().Dx()/2-pokemonImage.Bounds().Dx()/2*config.Scale, selfSiteY+s.siteImage.Bounds().Dy()/4*3-pokemonImage.Bounds().Dy()*config.Scale).Draw()
-	s.drawPokemonStatusCard(drawer.Move(340, 250))
+	selfRace := s.engine.Battler(SideEnum.Self).Race
+	selfRace.Back.Update()
+	pokemonImage = selfRace.Back.GetCurrentFrameImage()
+	draw.PrepareDrawImage(drawer, pokemonImage).Scale(float64(scale), float64(scale)).Move(selfSiteX+s.siteImage.Bounds().Dx()/2-pokemonImage.Bounds().Dx()/2*scale, selfSiteY+s.siteImage.Bounds().Dy()/4*3-pokemonImage.Bounds().Dy()*scale).Draw()
+	s.drawPokemonStatusCard(drawer.Move(340, 250), SideEnum.Self)
 
 	// 对话栏
 
@@ -114,5 +503,45 @@ func (s *System) OnDraw(drawer draw.OptionDrawer) error {
 	draw.PrepareDrawRect(drawer, screenWidth/2-10, bgH, util.NewNRGBColor(132, 131, 188)).Move(screenWidth/2+5, screenHeight-bgH-5).SetRadius(4).Draw()
 	draw.PrepareDrawRect(drawer, screenWidth/2-14, bgH-4, util.NewNRGBColor(112, 104, 128)).Move(screenWidth/2+7, screenHeight-bgH-3).Draw()
 	draw.PrepareDrawRect(drawer, screenWidth/2-24, bgH-14, util.NewNRGBColor(248, 248, 248)).Move(screenWidth/2+12, screenHeight-bgH+2).SetRadius(6).Draw()
+
+	s.drawMenu(drawer.Move(0, screenHeight-bgH), screenWidth, fontH)
 	return nil
 }
+
+// 绘制消息和指令
+func (s *System) drawMenu(drawer draw.OptionDrawer, screenWidth, fontH int) {
+	textFont := util.GetFont(util.FontTypeEnum.Normal, 26)
+	switch s.phase {
+	case phaseMessage:
+		if len(s.messages) > 0 {
+			draw.PrepareDrawText(drawer, s.messages[0], textFont, color.White).Move(30, 20).Draw()
+		}
+	case phaseCommand:
+		prompt := fmt.Sprintf(s.ctx.Localisation().Get("battle.prompt"), s.battlerName(SideEnum.Self))
+		draw.PrepareDrawText(drawer, prompt, textFont, color.White).Move(30, 20).Draw()
+		commands := []string{s.ctx.Localisation().Get("battle.command.fight"), s.ctx.Localisation().Get("battle.command.bag"), s.ctx.Localisation().Get("battle.command.pokemon"), s.ctx.Localisation().Get("battle.command.flee")}
+		s.drawOptions(drawer.Move(screenWidth/2+30, 12), commands, fontH)
+	case phaseMove:
+		self := s.engine.Battler(SideEnum.Self)
+		names := make([]string, len(self.Moves))
+		for i, slot := range self.Moves {
+			if move, ok := self.Move(i); ok {
+				names[i] = fmt.Sprintf("%s %d/%d", move.Name(s.ctx.Localisation()), slot.PP, move.MaxPP(slot.PPUp))
+			} else {
+				names[i] = "-"
+			}
+		}
+		s.drawOptions(drawer.Move(screenWidth/2+30, 12), names, fontH)
+	}
+}
+
+func (s *System) drawOptions(drawer draw.OptionDrawer, options []string, fontH int) {
+	textFont := util.GetFont(util.FontTypeEnum.Normal, 26)
+	lineH := fontH * 4 / 5
+	for i, option := range options {
+		if i == s.cursor {
+			draw.PrepareDrawText(drawer, "▶", textFont, color.Black).Move(0, i*lineH).Draw()
+		}
+		draw.PrepareDrawText(drawer, option, textFont, color.Black).Move(30, i*lineH).Draw()
+	}
+}
@@ -1,24 +1,36 @@
 package context
 
 import (
+	"math/rand/v2"
+
 	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/state"
 	"github.com/kkkunny/pokemon/src/util/i18n"
 )
 
 type Context interface {
 	Config() *config.Config
 	Localisation() *i18n.Localisation
+	State() *state.Store
+	// Rand 游戏逻辑使用的随机数流，由配置中的种子生成，相同种子和输入可以重现一局游戏
+	Rand(stream RandStream) *rand.Rand
+	// SetRand 替换随机数流，用于固定结果
+	SetRand(stream RandStream, r *rand.Rand)
 }
 
 type _Context struct {
 	cfg *config.Config
 	loc *i18n.Localisation
+	st  *state.Store
+	r   map[RandStream]*rand.Rand
 }
 
-func NewContext(cfg *config.Config, loc *i18n.Localisation) Context {
+func NewContext(cfg *config.Config, loc *i18n.Localisation, st *state.Store) Context {
 	return &_Context{
 		cfg: cfg,
 		loc: loc,
+		st:  st,
+		r:   make(map[RandStream]*rand.Rand),
 	}
 }
 
@@ -29,3 +41,20 @@ func (ctx *_Context) Config() *config.Config {
 func (ctx *_Context) Localisation() *i18n.Localisation {
 	return ctx.loc
 }
+
+func (ctx *_Context) State() *state.Store {
+	return ctx.st
+}
+
+func (ctx *_Context) Rand(stream RandStream) *rand.Rand {
+	r, ok := ctx.r[stream]
+	if !ok {
+		r = newRand(ctx.cfg.Seed, stream)
+		ctx.r[stream] = r
+	}
+	return r
+}
+
+func (ctx *_Context) SetRand(stream RandStream, r *rand.Rand) {
+	ctx.r[stream] = r
+}
@@ -0,0 +1,26 @@
+package context
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+
+	"github.com/tnnmigga/enum"
+)
+
+// RandStream 随机数流，各玩法使用独立的流，互不影响调用次数
+type RandStream string
+
+var RandStreamEnum = enum.New[struct {
+	Npc       RandStream `enum:"npc"`       // NPC走动
+	Battle    RandStream `enum:"battle"`    // 伤害、暴击、命中、捕获等
+	Encounter RandStream `enum:"encounter"` // 野生宝可梦遇敌
+	Pokemon   RandStream `enum:"pokemon"`   // 生成宝可梦的个体值等
+	Script    RandStream `enum:"script"`    // 脚本中的math.random
+}]()
+
+// 由种子和流名生成随机数
+func newRand(seed uint64, stream RandStream) *rand.Rand {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(stream))
+	return rand.New(rand.NewPCG(seed, h.Sum64()))
+}
@@ -2,9 +2,9 @@ package dialogue
 
 import (
 	"strings"
-	"time"
 
 	stlval "github.com/kkkunny/stl/value"
+	"github.com/tnnmigga/enum"
 	"golang.org/x/image/font"
 
 	"github.com/kkkunny/pokemon/src/system/context"
@@ -15,28 +15,60 @@ import (
 
 const (
 	waitForContinueChar     = '🔻'
-	normalDisplayInterval   = time.Millisecond * 150
-	fastModeDisplayInterval = time.Millisecond * 30
+	fastModeDisplayInterval = 2 // 快进时每个字的显示间隔帧数
 )
 
+// TextSpeed 文字显示速度
+type TextSpeed uint8
+
+var TextSpeedEnum = enum.New[struct {
+	Slow   TextSpeed
+	Normal TextSpeed
+	Fast   TextSpeed
+}]()
+
+// ParseTextSpeed 按名称解析文字速度，如 `normal`
+func ParseTextSpeed(name string) (TextSpeed, bool) {
+	for i, key := range enum.Keys(TextSpeedEnum) {
+		if strings.EqualFold(key, name) {
+			return enum.Values[TextSpeed](TextSpeedEnum)[i], true
+		}
+	}
+	return 0, false
+}
+
+// 每个字的显示间隔帧数，按帧计时以便回放时结果一致
+func (s TextSpeed) interval() int {
+	switch s {
+	case TextSpeedEnum.Slow:
+		return 15
+	case TextSpeedEnum.Fast:
+		return 4
+	default:
+		return 9
+	}
+}
+
 type System struct {
 	ctx context.Context
 
-	displayInterval time.Duration
+	textSpeed       TextSpeed
+	displayInterval int
 
 	// 显示文字的必备属性
-	display        bool
-	isDialogue     bool
-	text           []rune
-	index          int
-	lastUpdateTime time.Time
-	waitFrame      int
+	display    bool
+	isDialogue bool
+	text       []rune
+	index      int
+	counter    int // 距上次显示新字的帧数
+	waitFrame  int
 }
 
 func NewSystem(ctx context.Context) (*System, error) {
 	return &System{
 		ctx:             ctx,
-		displayInterval: normalDisplayInterval,
+		textSpeed:       TextSpeedEnum.Normal,
+		displayInterval: TextSpeedEnum.Normal.interval(),
 	}, nil
 }
 
@@ -52,7 +84,7 @@ func (s *System) SetLabel(text string) {
 	s.isDialogue = false
 	s.text = []rune(text)
 	s.index = 0
-	s.lastUpdateTime = time.Time{}
+	s.counter = s.displayInterval
 }
 
 func (s *System) DisplayLabel(text string) {
@@ -64,7 +96,7 @@ func (s *System) SetDialogue(text string) {
 	s.isDialogue = true
 	s.text = []rune(text)
 	s.index = 0
-	s.lastUpdateTime = time.Time{}
+	s.counter = s.displayInterval
 }
 
 func (s *System) DisplayDialogue(text string) {
@@ -170,26 +202,44 @@ func (s *System) OnDraw(drawer draw.OptionDrawer) error {
 		bounds, _ = font.BoundString(util.GetFont(util.FontTypeEnum.Emoji, 36).UnsafeInternal(), renderStr)
 		y -= float64((bounds.Max.Y - bounds.Min.Y).Round()) / 2
 		draw.PrepareDrawText(drawer, waitString, util.GetFont(util.FontTypeEnum.Emoji, 36), util.NewNRGBColor(224, 8, 8)).Move(int(x), int(y)).Draw()
-		if time.Since(s.lastUpdateTime) > s.displayInterval*2 {
+	}
+	return nil
+}
+
+// Update 每帧推进文字显示
+func (s *System) Update() {
+	if !s.display {
+		return
+	}
+	s.counter++
+	if s.WaitForContinue() {
+		if s.counter > s.displayInterval*2 {
 			s.waitFrame = (s.waitFrame + 1) % 3
-			s.lastUpdateTime = time.Now()
+			s.counter = 0
 		}
-		return nil
-	} else if s.StreamDone() || (s.lastUpdateTime != stlval.Default[time.Time]() && time.Since(s.lastUpdateTime) < s.displayInterval) {
-		return nil
+		return
+	} else if s.StreamDone() || s.counter < s.displayInterval {
+		return
 	}
-
-	s.lastUpdateTime = time.Now()
+	s.counter = 0
 	s.index++
-	return nil
 }
 
 func (s *System) SetFastMode(v bool) {
-	s.displayInterval = stlval.Ternary(v, fastModeDisplayInterval, normalDisplayInterval)
+	s.displayInterval = stlval.Ternary(v, fastModeDisplayInterval, s.textSpeed.interval())
 }
 
 func (s *System) FastMode() bool {
-	return s.displayInterval != normalDisplayInterval
+	return s.displayInterval != s.textSpeed.interval()
+}
+
+func (s *System) SetTextSpeed(speed TextSpeed) {
+	s.textSpeed = speed
+	s.displayInterval = speed.interval()
+}
+
+func (s *System) TextSpeed() TextSpeed {
+	return s.textSpeed
 }
 
 func (s *System) WaitForContinue() bool {
@@ -0,0 +1,102 @@
+package system
+
+import (
+	"path"
+
+	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/pokemon"
+	"github.com/kkkunny/pokemon/src/system/battle"
+	"github.com/kkkunny/pokemon/src/system/context"
+	"github.com/kkkunny/pokemon/src/system/world"
+	"github.com/kkkunny/pokemon/src/system/world/sprite"
+	"github.com/kkkunny/pokemon/src/util"
+)
+
+// 以下为脚本运行时使用的接口
+
+func (s *System) ShowDialogue(text string) {
+	s.showDialogue(s.ctx.Localisation().Get(text))
+}
+
+func (s *System) ShowLabel(text string) {
+	s.showLabel(s.ctx.Localisation().Get(text))
+}
+
+func (s *System) DialogueDisplaying() bool {
+	return s.dialogue.Display()
+}
+
+func (s *System) Self() sprite.MovableSprite {
+	return s.self
+}
+
+func (s *System) CheckCollision(d util.Direction, x, y int) bool {
+	return s.world.CheckCollision(d, x, y)
+}
+
+func (s *System) Warp(mapID string, x, y int) error {
+	return s.world.Warp([]sprite.Sprite{s.self}, mapID, x, y)
+}
+
+func (s *System) Flag(name string) bool {
+	return s.ctx.State().Flag(name)
+}
+
+func (s *System) SetFlag(name string, v bool) {
+	s.ctx.State().SetFlag(name, v)
+}
+
+func (s *System) Var(name string) int {
+	return s.ctx.State().Var(name)
+}
+
+func (s *System) SetVar(name string, v int) {
+	s.ctx.State().SetVar(name, v)
+}
+
+func (s *System) GiveItem(id string, count int) (bool, error) {
+	if count < 0 {
+		return s.bag.Remove(id, -count), nil
+	}
+	added, err := s.bag.Add(id, count)
+	return err == nil && added == count, err
+}
+
+func (s *System) GivePokemon(species int16, level int) (bool, error) {
+	if s.party.Full() {
+		return false, nil
+	}
+	race, err := pokemon.GetPokemonRace(species)
+	if err != nil {
+		return false, err
+	}
+	s.registerCaught(species)
+	return s.party.Add(pokemon.NewPokemon(race, level, s.ctx.Rand(context.RandStreamEnum.Pokemon))), nil
+}
+
+func (s *System) StartBattle(site string, species int16, level int) error {
+	return s.OnBattleStart(world.Encounter{Site: site, Species: species, Level: level})
+}
+
+func (s *System) BattleResult() (string, bool) {
+	switch s.battleResult {
+	case battle.ResultEnum.Win:
+		return "win", true
+	case battle.ResultEnum.Lose:
+		return "lose", true
+	case battle.ResultEnum.Flee:
+		return "flee", true
+	case battle.ResultEnum.Catch:
+		return "catch", true
+	default:
+		return "", false
+	}
+}
+
+func (s *System) PlaySound(name string) error {
+	err := s.soundPlayer.LoadFile(path.Join(config.VoicePath, name+".ogg"))
+	if err != nil {
+		return err
+	}
+	return s.soundPlayer.Play()
+}
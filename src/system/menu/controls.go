@@ -0,0 +1,188 @@
+package menu
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/tnnmigga/enum"
+
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/system/context"
+	"github.com/kkkunny/pokemon/src/util"
+	"github.com/kkkunny/pokemon/src/util/draw"
+)
+
+const controlsLineHeight = 30 // 按键设置行高
+
+// KeyBinder 改键使用的输入系统
+type KeyBinder interface {
+	Bindings() *input.Bindings
+	Rebind(device input.Device, action input.KeyInputAction, key string) (input.KeyInputAction, bool, error)
+	ResetBindings(device input.Device)
+	ScanKey(device input.Device) (string, bool)
+}
+
+// ControlsScreen 按键设置界面，左右键或L/R切换设备
+type ControlsScreen struct {
+	ctx    context.Context
+	binder KeyBinder
+
+	active  bool
+	device  int  // 当前设备下标
+	cursor  int  // 按键数量为恢复默认，按键数量+1为关闭
+	waiting bool // 正在等待按下新的键
+	message string
+
+	onChange func() error // 绑定修改后保存
+}
+
+func NewControlsScreen(ctx context.Context, binder KeyBinder) *ControlsScreen {
+	return &ControlsScreen{ctx: ctx, binder: binder}
+}
+
+func (s *ControlsScreen) SetOnChange(f func() error) {
+	s.onChange = f
+}
+
+func (s *ControlsScreen) Active() bool {
+	return s.active
+}
+
+func (s *ControlsScreen) Open() {
+	s.active = true
+	s.waiting = false
+	s.message = ""
+}
+
+func (s *ControlsScreen) devices() []input.Device {
+	return enum.Values[input.Device](input.DeviceEnum)
+}
+
+func (s *ControlsScreen) actions() []input.KeyInputAction {
+	return enum.Values[input.KeyInputAction](input.KeyInputActionEnum)
+}
+
+func (s *ControlsScreen) OnAction(action input.KeyInputAction) error {
+	// 等待改键时按下的键由OnUpdate扫描
+	if s.waiting {
+		return nil
+	}
+	count := len(s.actions()) + 2
+	switch action {
+	case input.KeyInputActionEnum.MoveUp.Pressed():
+		s.cursor = (s.cursor + count - 1) % count
+	case input.KeyInputActionEnum.MoveDown.Pressed():
+		s.cursor = (s.cursor + 1) % count
+	case input.KeyInputActionEnum.MoveLeft.Pressed(), input.KeyInputActionEnum.L.Pressed():
+		s.device = (s.device + len(s.devices()) - 1) % len(s.devices())
+	case input.KeyInputActionEnum.MoveRight.Pressed(), input.KeyInputActionEnum.R.Pressed():
+		s.device = (s.device + 1) % len(s.devices())
+	case input.KeyInputActionEnum.B.Pressed(), input.KeyInputActionEnum.Start.Pressed():
+		s.active = false
+	case input.KeyInputActionEnum.A.Pressed():
+		s.message = ""
+		switch s.cursor {
+		case count - 1:
+			s.active = false
+		case count - 2:
+			s.binder.ResetBindings(s.devices()[s.device])
+			s.message = s.ctx.Localisation().Get("controls.reset")
+			return s.changed()
+		default:
+			s.waiting = true
+		}
+	}
+	return nil
+}
+
+func (s *ControlsScreen) OnUpdate() error {
+	if !s.waiting {
+		return nil
+	}
+	key, ok := s.binder.ScanKey(s.devices()[s.device])
+	if !ok {
+		return nil
+	}
+	s.waiting = false
+	loc := s.ctx.Localisation()
+	action := s.actions()[s.cursor]
+	other, swapped, err := s.binder.Rebind(s.devices()[s.device], action, key)
+	if err != nil {
+		s.message = fmt.Sprintf(loc.Get("controls.invalid"), key)
+		return nil
+	} else if swapped {
+		s.message = fmt.Sprintf(loc.Get("controls.swapped"), key, loc.Get("key."+other.Name()), loc.Get("key."+action.Name()))
+	} else {
+		s.message = fmt.Sprintf(loc.Get("controls.bound"), key, loc.Get("key."+action.Name()))
+	}
+	return s.changed()
+}
+
+func (s *ControlsScreen) changed() error {
+	if s.onChange == nil {
+		return nil
+	}
+	err := s.onChange()
+	if err != nil {
+		s.message = fmt.Sprintf(s.ctx.Localisation().Get("controls.save_failed"), err)
+	}
+	return nil
+}
+
+func (s *ControlsScreen) OnDraw(drawer draw.OptionDrawer) error {
+	loc := s.ctx.Localisation()
+	textFont := util.GetFont(util.FontTypeEnum.Normal, 22)
+	screenWidth, screenHeight := drawer.Bounds().Dx(), drawer.Bounds().Dy()
+	draw.OverlayColor(drawer, util.NewNRGBColor(112, 104, 128))
+
+	// 设备
+	devices := s.devices()
+	tabW := (screenWidth - 20) / len(devices)
+	for i, device := range devices {
+		tabColor := color.Color(util.NewNRGBColor(80, 72, 96))
+		if i == s.device {
+			tabColor = util.NewNRGBColor(248, 88, 40)
+		}
+		draw.PrepareDrawRect(drawer, tabW-6, 34, tabColor).Move(10+i*tabW+3, 8).SetRadius(8).Draw()
+		draw.PrepareDrawText(drawer, loc.Get("controls.device."+string(device)), textFont, color.White).Move(10+i*tabW+14, 12).Draw()
+	}
+
+	// 按键列表
+	actions := s.actions()
+	listW, listH := screenWidth-20, (len(actions)+2)*controlsLineHeight+16
+	listDrawer := drawer.Move(10, 48)
+	drawPanel(listDrawer, listW, listH)
+	bindings := s.binder.Bindings()
+	for i := range len(actions) + 2 {
+		y := 8 + i*controlsLineHeight
+		if i == s.cursor {
+			draw.PrepareDrawText(listDrawer, "▶", textFont, color.Black).Move(14, y).Draw()
+		}
+		switch {
+		case i == len(actions):
+			draw.PrepareDrawText(listDrawer, loc.Get("controls.reset_default"), textFont, color.Black).Move(48, y).Draw()
+		case i == len(actions)+1:
+			draw.PrepareDrawText(listDrawer, loc.Get("menu.close"), textFont, color.Black).Move(48, y).Draw()
+		default:
+			draw.PrepareDrawText(listDrawer, loc.Get("key."+actions[i].Name()), textFont, color.Black).Move(48, y).Draw()
+			keys := strings.Join(bindings.Keys(devices[s.device], actions[i]), ", ")
+			if s.waiting && i == s.cursor {
+				keys = "..."
+			}
+			draw.PrepareDrawText(listDrawer, keys, textFont, util.NewNRGBColor(216, 72, 56)).Move(listW/2, y).Draw()
+		}
+	}
+
+	// 提示栏
+	text := s.message
+	if s.waiting {
+		text = loc.Get("controls.waiting")
+	}
+	if text != "" {
+		barH := 44
+		draw.PrepareDrawRect(drawer, screenWidth-10, barH, util.NewNRGBColor(40, 80, 104)).Move(5, screenHeight-barH-5).SetRadius(6).Draw()
+		draw.PrepareDrawText(drawer, text, textFont, color.White).Move(20, screenHeight-barH+4).Draw()
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package menu
+
+import (
+	"image/color"
+
+	stlval "github.com/kkkunny/stl/value"
+	"github.com/tnnmigga/enum"
+
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/system/context"
+	"github.com/kkkunny/pokemon/src/system/dialogue"
+	"github.com/kkkunny/pokemon/src/util"
+	"github.com/kkkunny/pokemon/src/util/draw"
+)
+
+// Options 游戏设置
+type Options struct {
+	TextSpeed dialogue.TextSpeed // 文字速度
+	Music     bool               // 是否播放音乐
+}
+
+// OptionsScreen 设置界面，左右键修改当前项
+type OptionsScreen struct {
+	ctx context.Context
+
+	active  bool
+	cursor  int // 0为文字速度，1为音乐，2为按键设置，3为关闭
+	options Options
+
+	onChange   func(options Options) error
+	onControls func() error // 打开按键设置
+}
+
+func NewOptionsScreen(ctx context.Context) *OptionsScreen {
+	return &OptionsScreen{ctx: ctx}
+}
+
+func (s *OptionsScreen) SetOnChange(f func(options Options) error) {
+	s.onChange = f
+}
+
+func (s *OptionsScreen) SetOnControls(f func() error) {
+	s.onControls = f
+}
+
+func (s *OptionsScreen) Active() bool {
+	return s.active
+}
+
+func (s *OptionsScreen) Open(options Options) {
+	s.active = true
+	s.cursor = 0
+	s.options = options
+}
+
+func (s *OptionsScreen) OnAction(action input.KeyInputAction) error {
+	const count = 4
+	switch action {
+	case input.KeyInputActionEnum.MoveUp.Pressed():
+		s.cursor = (s.cursor + count - 1) % count
+	case input.KeyInputActionEnum.MoveDown.Pressed():
+		s.cursor = (s.cursor + 1) % count
+	case input.KeyInputActionEnum.MoveLeft.Pressed():
+		return s.change(-1)
+	case input.KeyInputActionEnum.MoveRight.Pressed():
+		return s.change(1)
+	case input.KeyInputActionEnum.Start.Pressed(), input.KeyInputActionEnum.B.Pressed():
+		s.active = false
+	case input.KeyInputActionEnum.A.Pressed():
+		if s.cursor == count-1 {
+			s.active = false
+		} else if s.cursor == 2 && s.onControls != nil {
+			return s.onControls()
+		}
+	}
+	return nil
+}
+
+func (s *OptionsScreen) change(delta int) error {
+	switch s.cursor {
+	case 0:
+		speeds := enum.Values[dialogue.TextSpeed](dialogue.TextSpeedEnum)
+		s.options.TextSpeed = dialogue.TextSpeed(min(max(int(s.options.TextSpeed)+delta, 0), len(speeds)-1))
+	case 1:
+		s.options.Music = !s.options.Music
+	default:
+		return nil
+	}
+	if s.onChange == nil {
+		return nil
+	}
+	return s.onChange(s.options)
+}
+
+func (s *OptionsScreen) OnUpdate() error {
+	return nil
+}
+
+func (s *OptionsScreen) OnDraw(drawer draw.OptionDrawer) error {
+	loc := s.ctx.Localisation()
+	textFont := util.GetFont(util.FontTypeEnum.Normal, 28)
+	screenWidth := drawer.Bounds().Dx()
+	draw.OverlayColor(drawer, util.NewNRGBColor(112, 104, 128))
+
+	speedKeys := []string{"options.text_speed.slow", "options.text_speed.normal", "options.text_speed.fast"}
+	rows := [][2]string{
+		{loc.Get("options.text_speed"), loc.Get(speedKeys[s.options.TextSpeed])},
+		{loc.Get("options.music"), loc.Get(stlval.Ternary(s.options.Music, "options.on", "options.off"))},
+		{loc.Get("options.controls"), ""},
+		{loc.Get("menu.close"), ""},
+	}
+
+	draw.PrepareDrawText(drawer, loc.Get("menu.options"), textFont, color.White).Move(24, 16).Draw()
+	listW, listH := screenWidth-20, len(rows)*lineHeight+24
+	listDrawer := drawer.Move(10, 60)
+	drawPanel(listDrawer, listW, listH)
+	for i, row := range rows {
+		y := 12 + i*lineHeight
+		if i == s.cursor {
+			draw.PrepareDrawText(listDrawer, "▶", textFont, color.Black).Move(14, y).Draw()
+		}
+		draw.PrepareDrawText(listDrawer, row[0], textFont, color.Black).Move(48, y).Draw()
+		if row[1] != "" {
+			draw.PrepareDrawText(listDrawer, "◀ "+row[1]+" ▶", textFont, util.NewNRGBColor(216, 72, 56)).Move(listW/2, y).Draw()
+		}
+	}
+	return nil
+}
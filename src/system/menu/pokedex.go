@@ -0,0 +1,129 @@
+package menu
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/pokemon"
+	"github.com/kkkunny/pokemon/src/system/context"
+	"github.com/kkkunny/pokemon/src/util"
+	"github.com/kkkunny/pokemon/src/util/draw"
+)
+
+const dexVisibleRows = 9 // 图鉴列表同时显示的行数
+
+// SeenFlag 遇见过该种族的剧情标记
+func SeenFlag(species int16) string {
+	return fmt.Sprintf("dex.seen.%d", species)
+}
+
+// CaughtFlag 捕获过该种族的剧情标记
+func CaughtFlag(species int16) string {
+	return fmt.Sprintf("dex.caught.%d", species)
+}
+
+// DexCount 遇见过和捕获过的种族数量
+func DexCount(ctx context.Context) (seen int, caught int, err error) {
+	ids, err := pokemon.SpeciesIDs()
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, id := range ids {
+		if ctx.State().Flag(SeenFlag(id)) {
+			seen++
+		}
+		if ctx.State().Flag(CaughtFlag(id)) {
+			caught++
+		}
+	}
+	return seen, caught, nil
+}
+
+// PokedexScreen 图鉴界面
+type PokedexScreen struct {
+	ctx context.Context
+
+	active bool
+	ids    []int16
+	cursor int
+	scroll int
+}
+
+func NewPokedexScreen(ctx context.Context) *PokedexScreen {
+	return &PokedexScreen{ctx: ctx}
+}
+
+func (s *PokedexScreen) Active() bool {
+	return s.active
+}
+
+// Open 打开图鉴，重新读取已定义的种族
+func (s *PokedexScreen) Open() error {
+	ids, err := pokemon.SpeciesIDs()
+	if err != nil {
+		return err
+	}
+	s.active = true
+	s.ids = ids
+	s.cursor = min(s.cursor, max(len(ids)-1, 0))
+	s.scroll = min(s.scroll, s.cursor)
+	return nil
+}
+
+func (s *PokedexScreen) OnAction(action input.KeyInputAction) error {
+	switch action {
+	case input.KeyInputActionEnum.MoveUp.Pressed():
+		s.cursor = max(s.cursor-1, 0)
+	case input.KeyInputActionEnum.MoveDown.Pressed():
+		s.cursor = max(min(s.cursor+1, len(s.ids)-1), 0)
+	case input.KeyInputActionEnum.A.Pressed(), input.KeyInputActionEnum.B.Pressed(), input.KeyInputActionEnum.Start.Pressed():
+		s.active = false
+	}
+	s.scroll = min(max(s.scroll, s.cursor-dexVisibleRows+1), s.cursor)
+	return nil
+}
+
+func (s *PokedexScreen) OnUpdate() error {
+	return nil
+}
+
+func (s *PokedexScreen) OnDraw(drawer draw.OptionDrawer) error {
+	loc := s.ctx.Localisation()
+	state := s.ctx.State()
+	textFont := util.GetFont(util.FontTypeEnum.Normal, 28)
+	screenWidth := drawer.Bounds().Dx()
+	draw.OverlayColor(drawer, util.NewNRGBColor(216, 72, 56))
+
+	seen, caught, err := DexCount(s.ctx)
+	if err != nil {
+		return err
+	}
+	draw.PrepareDrawText(drawer, loc.Get("menu.pokedex"), textFont, color.White).Move(24, 16).Draw()
+	draw.PrepareDrawText(drawer, fmt.Sprintf(loc.Get("pokedex.count"), seen, caught), textFont, color.White).Move(screenWidth/2, 16).Draw()
+
+	listW, listH := screenWidth-20, dexVisibleRows*lineHeight+24
+	listDrawer := drawer.Move(10, 60)
+	drawPanel(listDrawer, listW, listH)
+	for row := range dexVisibleRows {
+		index := s.scroll + row
+		if index >= len(s.ids) {
+			break
+		}
+		id := s.ids[index]
+		y := 12 + row*lineHeight
+		if index == s.cursor {
+			draw.PrepareDrawText(listDrawer, "▶", textFont, color.Black).Move(14, y).Draw()
+		}
+		draw.PrepareDrawText(listDrawer, fmt.Sprintf("No.%03d", id), textFont, color.Black).Move(48, y).Draw()
+		name := "----------"
+		if state.Flag(SeenFlag(id)) {
+			name = loc.Get(fmt.Sprintf("pokemon.%d", id))
+		}
+		draw.PrepareDrawText(listDrawer, name, textFont, color.Black).Move(180, y).Draw()
+		if state.Flag(CaughtFlag(id)) {
+			draw.PrepareDrawText(listDrawer, "●", textFont, util.NewNRGBColor(216, 72, 56)).Move(listW-60, y).Draw()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/save"
+	"github.com/kkkunny/pokemon/src/system/context"
+	"github.com/kkkunny/pokemon/src/util"
+	"github.com/kkkunny/pokemon/src/util/draw"
+)
+
+// SaveScreen 存档界面，选择存档槽后保存或读取
+type SaveScreen struct {
+	ctx context.Context
+
+	active  bool
+	load    bool     // 是否为读取存档
+	cursor  int      // 等于存档槽数量时指向取消
+	slots   []string // 每个存档槽的概要
+	message string   // 保存结果，按A后关闭
+	locked  bool     // 禁止读取存档
+
+	onSave func(slot int) error
+	onLoad func(slot int) error
+}
+
+func NewSaveScreen(ctx context.Context) *SaveScreen {
+	return &SaveScreen{ctx: ctx}
+}
+
+func (s *SaveScreen) SetOnSave(f func(slot int) error) {
+	s.onSave = f
+}
+
+func (s *SaveScreen) SetOnLoad(f func(slot int) error) {
+	s.onLoad = f
+}
+
+// SetLoadLocked 禁止读取存档，读取时只提示
+func (s *SaveScreen) SetLoadLocked(locked bool) {
+	s.locked = locked
+}
+
+func (s *SaveScreen) Active() bool {
+	return s.active
+}
+
+// Open 打开存档界面，读取每个存档槽的概要
+func (s *SaveScreen) Open() {
+	s.open(false)
+}
+
+// OpenLoad 打开读取存档界面
+func (s *SaveScreen) OpenLoad() {
+	s.open(true)
+}
+
+func (s *SaveScreen) open(load bool) {
+	s.active = true
+	s.load = load
+	s.message = ""
+	s.slots = make([]string, save.MaxSlots)
+	for i := range s.slots {
+		s.slots[i] = s.slotSummary(i)
+	}
+}
+
+func (s *SaveScreen) slotSummary(slot int) string {
+	loc := s.ctx.Localisation()
+	data, err := save.Load(slot)
+	if errors.Is(err, save.ErrNotExist) {
+		return loc.Get("save.empty")
+	} else if err != nil {
+		return loc.Get("save.corrupted")
+	}
+	minutes := int(data.PlayTime / time.Minute)
+	return fmt.Sprintf(loc.Get("save.summary"), loc.Get(data.Map), minutes/60, minutes%60, data.SavedAt.Format(time.DateTime))
+}
+
+func (s *SaveScreen) OnAction(action input.KeyInputAction) error {
+	if s.message != "" {
+		if action == input.KeyInputActionEnum.A.Pressed() || action == input.KeyInputActionEnum.B.Pressed() {
+			s.active = false
+		}
+		return nil
+	}
+
+	count := len(s.slots) + 1
+	switch action {
+	case input.KeyInputActionEnum.MoveUp.Pressed():
+		s.cursor = (s.cursor + count - 1) % count
+	case input.KeyInputActionEnum.MoveDown.Pressed():
+		s.cursor = (s.cursor + 1) % count
+	case input.KeyInputActionEnum.Start.Pressed(), input.KeyInputActionEnum.B.Pressed():
+		s.active = false
+	case input.KeyInputActionEnum.A.Pressed():
+		if s.cursor >= len(s.slots) {
+			s.active = false
+			return nil
+		} else if s.load {
+			return s.loadSlot()
+		} else if s.onSave == nil {
+			return nil
+		}
+		loc := s.ctx.Localisation()
+		if err := s.onSave(s.cursor); err != nil {
+			s.message = fmt.Sprintf(loc.Get("save.failed"), err)
+			return nil
+		}
+		s.slots[s.cursor] = s.slotSummary(s.cursor)
+		s.message = loc.Get("save.done")
+	}
+	return nil
+}
+
+// 读取选中的存档槽，空槽不响应
+func (s *SaveScreen) loadSlot() error {
+	if s.onLoad == nil || !save.Exists(s.cursor) {
+		return nil
+	}
+	loc := s.ctx.Localisation()
+	if s.locked {
+		s.message = loc.Get("save.load_locked")
+		return nil
+	}
+	if err := s.onLoad(s.cursor); err != nil {
+		s.message = fmt.Sprintf(loc.Get("save.load_failed"), err)
+		return nil
+	}
+	s.message = loc.Get("save.loaded")
+	return nil
+}
+
+func (s *SaveScreen) OnUpdate() error {
+	return nil
+}
+
+func (s *SaveScreen) OnDraw(drawer draw.OptionDrawer) error {
+	loc := s.ctx.Localisation()
+	textFont := util.GetFont(util.FontTypeEnum.Normal, 26)
+	screenWidth, screenHeight := drawer.Bounds().Dx(), drawer.Bounds().Dy()
+	draw.OverlayColor(drawer, util.NewNRGBColor(120, 184, 112))
+
+	title := loc.Get("menu.save")
+	if s.load {
+		title = loc.Get("menu.load")
+	}
+	draw.PrepareDrawText(drawer, title, textFont, color.White).Move(24, 16).Draw()
+	listW, listH := screenWidth-20, (len(s.slots)+1)*lineHeight*2+24
+	listDrawer := drawer.Move(10, 60)
+	drawPanel(listDrawer, listW, listH)
+	for i := range len(s.slots) + 1 {
+		y := 12 + i*lineHeight*2
+		if i == s.cursor {
+			draw.PrepareDrawText(listDrawer, "▶", textFont, color.Black).Move(14, y).Draw()
+		}
+		if i == len(s.slots) {
+			draw.PrepareDrawText(listDrawer, loc.Get("menu.cancel"), textFont, color.Black).Move(48, y).Draw()
+			continue
+		}
+		draw.PrepareDrawText(listDrawer, fmt.Sprintf(loc.Get("save.slot"), i+1), textFont, color.Black).Move(48, y).Draw()
+		draw.PrepareDrawText(listDrawer, s.slots[i], textFont, util.NewNRGBColor(96, 96, 96)).Move(48, y+lineHeight).Draw()
+	}
+
+	if s.message != "" {
+		barH := 60
+		draw.PrepareDrawRect(drawer, screenWidth-10, barH, util.NewNRGBColor(40, 80, 104)).Move(5, screenHeight-barH-5).SetRadius(6).Draw()
+		draw.PrepareDrawText(drawer, s.message, textFont, color.White).Move(24, screenHeight-barH+10).Draw()
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package menu
+
+import (
+	"image/color"
+
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/system/context"
+	"github.com/kkkunny/pokemon/src/util"
+	"github.com/kkkunny/pokemon/src/util/draw"
+)
+
+const lineHeight = 40 // 菜单行高
+
+// Entry 开始菜单项
+type Entry struct {
+	Key  string       // 翻译key
+	Open func() error // 选择后打开对应页面
+}
+
+// StartMenu 开始菜单，覆盖在地图右侧
+type StartMenu struct {
+	ctx context.Context
+
+	active  bool
+	cursor  int // 等于菜单项数量时指向关闭
+	entries []Entry
+}
+
+func NewStartMenu(ctx context.Context) *StartMenu {
+	return &StartMenu{ctx: ctx}
+}
+
+// AddEntry 添加菜单项，按添加顺序排列
+func (m *StartMenu) AddEntry(key string, open func() error) {
+	m.entries = append(m.entries, Entry{Key: key, Open: open})
+}
+
+func (m *StartMenu) Active() bool {
+	return m.active
+}
+
+func (m *StartMenu) Overlay() bool {
+	return true
+}
+
+// Open 打开菜单，保留上次的光标位置
+func (m *StartMenu) Open() {
+	m.active = true
+}
+
+func (m *StartMenu) Close() {
+	m.active = false
+}
+
+func (m *StartMenu) OnAction(action input.KeyInputAction) error {
+	count := len(m.entries) + 1
+	switch action {
+	case input.KeyInputActionEnum.MoveUp.Pressed():
+		m.cursor = (m.cursor + count - 1) % count
+	case input.KeyInputActionEnum.MoveDown.Pressed():
+		m.cursor = (m.cursor + 1) % count
+	case input.KeyInputActionEnum.Start.Pressed(), input.KeyInputActionEnum.B.Pressed():
+		m.Close()
+	case input.KeyInputActionEnum.A.Pressed():
+		if m.cursor >= len(m.entries) {
+			m.Close()
+			return nil
+		} else if open := m.entries[m.cursor].Open; open != nil {
+			return open()
+		}
+	}
+	return nil
+}
+
+func (m *StartMenu) OnUpdate() error {
+	return nil
+}
+
+func (m *StartMenu) OnDraw(drawer draw.OptionDrawer) error {
+	loc := m.ctx.Localisation()
+	textFont := util.GetFont(util.FontTypeEnum.Normal, 28)
+	screenWidth := drawer.Bounds().Dx()
+
+	menuW, menuH := 220, (len(m.entries)+1)*lineHeight+24
+	menuDrawer := drawer.Move(screenWidth-menuW-10, 10)
+	drawPanel(menuDrawer, menuW, menuH)
+	for i := range len(m.entries) + 1 {
+		key := "menu.close"
+		if i < len(m.entries) {
+			key = m.entries[i].Key
+		}
+		if i == m.cursor {
+			draw.PrepareDrawText(menuDrawer, "▶", textFont, color.Black).Move(14, 12+i*lineHeight).Draw()
+		}
+		draw.PrepareDrawText(menuDrawer, loc.Get(key), textFont, color.Black).Move(48, 12+i*lineHeight).Draw()
+	}
+	return nil
+}
+
+// 绘制白底灰边的面板
+func drawPanel(drawer draw.OptionDrawer, w, h int) {
+	draw.PrepareDrawRect(drawer, w, h, util.NewNRGBColor(248, 248, 248)).SetBorderWidth(4).SetBorderColor(util.NewNRGBColor(112, 104, 128)).SetRadius(6).Draw()
+}
@@ -0,0 +1,85 @@
+package menu
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/system/context"
+	"github.com/kkkunny/pokemon/src/util"
+	"github.com/kkkunny/pokemon/src/util/draw"
+)
+
+// TrainerInfo 训练家卡片上显示的信息
+type TrainerInfo struct {
+	Name     string
+	Money    int
+	PlayTime time.Duration
+	Party    int // 队伍中的宝可梦数量
+}
+
+// TrainerCardScreen 训练家卡片界面
+type TrainerCardScreen struct {
+	ctx context.Context
+
+	active bool
+	info   TrainerInfo
+}
+
+func NewTrainerCardScreen(ctx context.Context) *TrainerCardScreen {
+	return &TrainerCardScreen{ctx: ctx}
+}
+
+func (s *TrainerCardScreen) Active() bool {
+	return s.active
+}
+
+// Open 打开卡片，显示打开时的信息
+func (s *TrainerCardScreen) Open(info TrainerInfo) {
+	s.active = true
+	s.info = info
+}
+
+func (s *TrainerCardScreen) OnAction(action input.KeyInputAction) error {
+	switch action {
+	case input.KeyInputActionEnum.A.Pressed(), input.KeyInputActionEnum.B.Pressed(), input.KeyInputActionEnum.Start.Pressed():
+		s.active = false
+	}
+	return nil
+}
+
+func (s *TrainerCardScreen) OnUpdate() error {
+	return nil
+}
+
+func (s *TrainerCardScreen) OnDraw(drawer draw.OptionDrawer) error {
+	loc := s.ctx.Localisation()
+	textFont := util.GetFont(util.FontTypeEnum.Normal, 30)
+	screenWidth, screenHeight := drawer.Bounds().Dx(), drawer.Bounds().Dy()
+	draw.OverlayColor(drawer, util.NewNRGBColor(88, 120, 184))
+
+	seen, caught, err := DexCount(s.ctx)
+	if err != nil {
+		return err
+	}
+	minutes := int(s.info.PlayTime / time.Minute)
+	rows := [][2]string{
+		{loc.Get("trainer_card.name"), s.info.Name},
+		{loc.Get("trainer_card.money"), fmt.Sprintf(loc.Get("trainer_card.money_value"), s.info.Money)},
+		{loc.Get("trainer_card.play_time"), fmt.Sprintf("%d:%02d", minutes/60, minutes%60)},
+		{loc.Get("trainer_card.party"), fmt.Sprintf("%d", s.info.Party)},
+		{loc.Get("trainer_card.pokedex"), fmt.Sprintf(loc.Get("pokedex.count"), seen, caught)},
+	}
+
+	cardW, cardH := min(screenWidth-40, 560), len(rows)*lineHeight+80
+	cardDrawer := drawer.Move((screenWidth-cardW)/2, (screenHeight-cardH)/2)
+	draw.PrepareDrawRect(cardDrawer, cardW, cardH, util.NewNRGBColor(248, 232, 176)).SetBorderWidth(6).SetBorderColor(util.NewNRGBColor(200, 160, 64)).SetRadius(12).Draw()
+	draw.PrepareDrawText(cardDrawer, loc.Get("menu.trainer_card"), textFont, util.NewNRGBColor(112, 80, 16)).Move(24, 20).Draw()
+	for i, row := range rows {
+		y := 70 + i*lineHeight
+		draw.PrepareDrawText(cardDrawer, row[0], textFont, color.Black).Move(32, y).Draw()
+		draw.PrepareDrawText(cardDrawer, row[1], textFont, color.Black).Move(cardW/2, y).Draw()
+	}
+	return nil
+}
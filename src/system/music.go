@@ -0,0 +1,76 @@
+package system
+
+import (
+	"github.com/kkkunny/pokemon/src/output/voice"
+)
+
+const musicFadeTicks = 60 // 切换曲目时交叉淡入淡出的帧数
+
+// 地图音乐，切换曲目时新曲目淡入、上一首淡出
+type mapMusic struct {
+	current *voice.Player
+	fading  *voice.Player // 正在淡出的上一首
+	path    string        // 当前曲目
+	volume  float64       // 设定的音量
+	fade    int           // 淡入进度
+}
+
+func newMapMusic(volume float64) *mapMusic {
+	return &mapMusic{
+		current: voice.NewPlayer(),
+		volume:  volume,
+		fade:    musicFadeTicks,
+	}
+}
+
+// Play 播放曲目，和当前曲目不同时交叉淡入淡出
+func (m *mapMusic) Play(path string) error {
+	if path == m.path {
+		return m.current.Play()
+	}
+	if m.fading != nil {
+		err := m.fading.Close()
+		if err != nil {
+			return err
+		}
+	}
+	m.fading, m.current = m.current, voice.NewPlayer()
+	m.current.SetVolume(0)
+	m.path, m.fade = path, 0
+	err := m.current.LoadFile(path)
+	if err != nil {
+		return err
+	}
+	return m.current.Play()
+}
+
+// Update 推进淡入淡出
+func (m *mapMusic) Update() error {
+	if m.fade >= musicFadeTicks {
+		return nil
+	}
+	m.fade++
+	ratio := float64(m.fade) / musicFadeTicks
+	m.current.SetVolume(m.volume * ratio)
+	if m.fading == nil {
+		return nil
+	}
+	m.fading.SetVolume(m.volume * (1 - ratio))
+	if m.fade < musicFadeTicks {
+		return nil
+	}
+	err := m.fading.Close()
+	m.fading = nil
+	return err
+}
+
+func (m *mapMusic) SetVolume(v float64) {
+	m.volume = v
+	if m.fade >= musicFadeTicks {
+		m.current.SetVolume(v)
+	}
+}
+
+func (m *mapMusic) Volume() float64 {
+	return m.volume
+}
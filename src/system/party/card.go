@@ -0,0 +1,71 @@
+package party
+
+import (
+	"fmt"
+	"image/color"
+
+	stlval "github.com/kkkunny/stl/value"
+	"golang.org/x/image/font"
+
+	"github.com/kkkunny/pokemon/src/pokemon"
+	"github.com/kkkunny/pokemon/src/util"
+	"github.com/kkkunny/pokemon/src/util/draw"
+	"github.com/kkkunny/pokemon/src/util/i18n"
+)
+
+const (
+	CardWidth  = 300 // 状态卡片宽度
+	CardHeight = 80  // 状态卡片高度
+)
+
+// 异常状态标签颜色
+var statusColors = map[pokemon.StatusCondition]color.Color{
+	pokemon.StatusConditionEnum.Sleep:     util.NewNRGBColor(140, 140, 120),
+	pokemon.StatusConditionEnum.Poison:    util.NewNRGBColor(160, 64, 160),
+	pokemon.StatusConditionEnum.BadPoison: util.NewNRGBColor(112, 40, 112),
+	pokemon.StatusConditionEnum.Burn:      util.NewNRGBColor(240, 128, 48),
+	pokemon.StatusConditionEnum.Freeze:    util.NewNRGBColor(152, 216, 216),
+	pokemon.StatusConditionEnum.Paralysis: util.NewNRGBColor(248, 208, 48),
+}
+
+// DrawStatusCard 绘制宝可梦状态卡片，包含名字、性别、等级、异常状态和体力条
+func DrawStatusCard(drawer draw.OptionDrawer, loc *i18n.Localisation, p *pokemon.Pokemon, maxHP int) {
+	draw.PrepareDrawRect(drawer, CardWidth, CardHeight, util.NewNRGBColor(248, 248, 216)).SetBorderWidth(5).SetBorderColor(color.Black).Draw()
+	name := p.Name(loc)
+	nameBounds, _ := font.BoundString(util.GetFont(util.FontTypeEnum.Normal, 26).UnsafeInternal(), name)
+	draw.PrepareDrawText(drawer, name, util.GetFont(util.FontTypeEnum.Normal, 26), color.Black).Move(20, 10).Draw()
+	if genderText := p.Gender.Symbol(); genderText != "" {
+		genderColor := stlval.Ternary(p.Gender == pokemon.GenderEnum.Male, util.NewNRGBColor(65, 200, 248), util.NewNRGBColor(248, 88, 40))
+		genderBounds, _ := font.BoundString(util.GetFont(util.FontTypeEnum.Emoji, 16).UnsafeInternal(), name)
+		draw.PrepareDrawText(drawer, genderText, util.GetFont(util.FontTypeEnum.Emoji, 16), genderColor).Move(20+nameBounds.Max.X.Round(), 10+nameBounds.Max.Y.Round()-genderBounds.Max.Y.Round()).Draw()
+	}
+	draw.PrepareDrawText(drawer, fmt.Sprintf("Lv%3d", p.Level), util.GetFont(util.FontTypeEnum.Normal, 26), color.Black).Move(220, 10).Draw()
+
+	// 异常状态
+	if statusColor, ok := statusColors[p.Status]; ok {
+		draw.PrepareDrawRect(drawer, 44, 20, statusColor).Move(20, 50).SetRadius(4).Draw()
+		draw.PrepareDrawText(drawer, loc.Get("status."+p.Status.String()), util.GetFont(util.FontTypeEnum.Normal, 16), color.White).Move(26, 51).Draw()
+	} else if p.Fainted() {
+		draw.PrepareDrawRect(drawer, 44, 20, util.NewNRGBColor(232, 80, 48)).Move(20, 50).SetRadius(4).Draw()
+		draw.PrepareDrawText(drawer, loc.Get("status.fainted"), util.GetFont(util.FontTypeEnum.Normal, 16), color.White).Move(26, 51).Draw()
+	}
+
+	// 体力条
+	draw.PrepareDrawRect(drawer, 220, 20, util.NewNRGBColor(80, 104, 88)).Move(70, 50).SetRadius(7).Draw()
+	draw.PrepareDrawText(drawer, "HP", util.GetFont(util.FontTypeEnum.Normal, 20), util.NewNRGBColor(248, 178, 65)).Move(76, 50).Draw()
+	draw.PrepareDrawRect(drawer, 192, 16, color.White).Move(96, 52).SetRadius(5).Draw()
+	draw.PrepareDrawRect(drawer, 188, 12, util.NewNRGBColor(80, 104, 88)).Move(98, 54).SetRadius(3).Draw()
+	draw.PrepareDrawRect(drawer, int(float64(188)*float64(p.HP)/float64(max(maxHP, 1))), 12, hpColor(p.HP, maxHP)).Move(98, 54).SetRadius(3).Draw()
+}
+
+// 按剩余体力比例决定体力条颜色
+func hpColor(hp, maxHP int) color.Color {
+	switch {
+	case hp*2 > maxHP:
+		return util.NewNRGBColor(110, 245, 165)
+	case hp*5 > maxHP:
+		return util.NewNRGBColor(248, 224, 56)
+	default:
+		return util.NewNRGBColor(248, 88, 56)
+	}
+}
@@ -0,0 +1,369 @@
+package party
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/tnnmigga/enum"
+
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/pokemon"
+	"github.com/kkkunny/pokemon/src/system/context"
+	"github.com/kkkunny/pokemon/src/util"
+	"github.com/kkkunny/pokemon/src/util/draw"
+)
+
+// Mode 打开队伍界面的场合
+type Mode uint8
+
+var ModeEnum = enum.New[struct {
+	View   Mode // 在菜单中查看，可以调整顺序
+	Battle Mode // 战斗中选择替换的宝可梦
+	Forced Mode // 战斗中宝可梦倒下后必须选择替换，不能取消
+	Select Mode // 选择使用道具的对象
+}]()
+
+// 子菜单选项
+type option uint8
+
+const (
+	optionSwitchIn option = iota // 替换上场
+	optionSummary                // 查看详情
+	optionSwap                   // 调整顺序
+	optionCancel                 // 取消
+)
+
+const (
+	iconSize   = 48 // 宝可梦图标大小
+	cellWidth  = 352
+	cellHeight = 88
+)
+
+// Screen 队伍界面
+type Screen struct {
+	ctx context.Context
+
+	active   bool
+	mode     Mode
+	party    *pokemon.Party
+	current  int // 在场宝可梦下标，不在战斗中时为-1
+	cursor   int // 队伍光标，等于队伍数量时指向取消
+	swapping int // 正在调整顺序的宝可梦下标，为-1时未在调整
+	options  []option
+	option   int    // 子菜单光标，为-1时子菜单未打开
+	summary  bool   // 是否在查看详情
+	message  string // 提示消息
+
+	onSelect func(index int) error // 选择替换上场的宝可梦
+	onClose  func() error          // 未选择就关闭
+}
+
+func NewScreen(ctx context.Context) *Screen {
+	return &Screen{ctx: ctx}
+}
+
+func (s *Screen) SetOnSelect(f func(index int) error) {
+	s.onSelect = f
+}
+
+func (s *Screen) SetOnClose(f func() error) {
+	s.onClose = f
+}
+
+func (s *Screen) Active() bool {
+	return s.active
+}
+
+// Open 打开队伍界面
+// @current: 在场宝可梦的下标，不在战斗中时为-1
+func (s *Screen) Open(party *pokemon.Party, mode Mode, current int) {
+	s.active = true
+	s.mode = mode
+	s.party = party
+	s.current = current
+	s.cursor = 0
+	s.swapping = -1
+	s.option = -1
+	s.summary = false
+	s.message = ""
+	if mode == ModeEnum.View {
+		s.options = []option{optionSummary, optionSwap, optionCancel}
+	} else {
+		s.options = []option{optionSwitchIn, optionSummary, optionCancel}
+	}
+}
+
+// 光标可移动的位置数量，强制替换时没有取消
+func (s *Screen) cursorCount() int {
+	if s.mode == ModeEnum.Forced {
+		return s.party.Len()
+	}
+	return s.party.Len() + 1
+}
+
+func (s *Screen) close() error {
+	s.active = false
+	if s.onClose == nil {
+		return nil
+	}
+	return s.onClose()
+}
+
+func (s *Screen) OnAction(action input.KeyInputAction) error {
+	switch {
+	case s.message != "":
+		if action == input.KeyInputActionEnum.A.Pressed() || action == input.KeyInputActionEnum.B.Pressed() {
+			s.message = ""
+		}
+	case s.summary:
+		s.onSummaryAction(action)
+	case s.option >= 0:
+		return s.onOptionAction(action)
+	default:
+		return s.onListAction(action)
+	}
+	return nil
+}
+
+func (s *Screen) onListAction(action input.KeyInputAction) error {
+	count := s.cursorCount()
+	switch action {
+	case input.KeyInputActionEnum.MoveUp.Pressed():
+		s.cursor = (s.cursor + count - 2) % count
+	case input.KeyInputActionEnum.MoveDown.Pressed():
+		s.cursor = (s.cursor + 2) % count
+	case input.KeyInputActionEnum.MoveLeft.Pressed():
+		s.cursor = (s.cursor + count - 1) % count
+	case input.KeyInputActionEnum.MoveRight.Pressed():
+		s.cursor = (s.cursor + 1) % count
+	case input.KeyInputActionEnum.A.Pressed():
+		if s.cursor >= s.party.Len() {
+			if s.swapping >= 0 {
+				s.swapping = -1
+				return nil
+			}
+			return s.close()
+		}
+		if s.swapping >= 0 {
+			s.party.Swap(s.swapping, s.cursor)
+			s.swapping = -1
+			return nil
+		} else if s.mode == ModeEnum.Select {
+			s.active = false
+			if s.onSelect == nil {
+				return nil
+			}
+			return s.onSelect(s.cursor)
+		}
+		s.option = 0
+	case input.KeyInputActionEnum.B.Pressed():
+		if s.swapping >= 0 {
+			s.swapping = -1
+			return nil
+		} else if s.mode == ModeEnum.Forced {
+			return nil
+		}
+		return s.close()
+	}
+	return nil
+}
+
+func (s *Screen) onOptionAction(action input.KeyInputAction) error {
+	switch action {
+	case input.KeyInputActionEnum.MoveUp.Pressed():
+		s.option = (s.option + len(s.options) - 1) % len(s.options)
+	case input.KeyInputActionEnum.MoveDown.Pressed():
+		s.option = (s.option + 1) % len(s.options)
+	case input.KeyInputActionEnum.A.Pressed():
+		selected := s.options[s.option]
+		s.option = -1
+		switch selected {
+		case optionSwitchIn:
+			return s.switchIn()
+		case optionSummary:
+			s.summary = true
+		case optionSwap:
+			s.swapping = s.cursor
+		}
+	case input.KeyInputActionEnum.B.Pressed():
+		s.option = -1
+	}
+	return nil
+}
+
+func (s *Screen) onSummaryAction(action input.KeyInputAction) {
+	switch action {
+	case input.KeyInputActionEnum.MoveUp.Pressed():
+		s.cursor = (s.cursor + s.party.Len() - 1) % s.party.Len()
+	case input.KeyInputActionEnum.MoveDown.Pressed():
+		s.cursor = (s.cursor + 1) % s.party.Len()
+	case input.KeyInputActionEnum.A.Pressed(), input.KeyInputActionEnum.B.Pressed():
+		s.summary = false
+	}
+}
+
+// 选择替换上场的宝可梦
+func (s *Screen) switchIn() error {
+	p, ok := s.party.Get(s.cursor)
+	if !ok {
+		return nil
+	}
+	loc := s.ctx.Localisation()
+	switch {
+	case s.cursor == s.current:
+		s.message = fmt.Sprintf(loc.Get("party.already_out"), p.Name(loc))
+		return nil
+	case p.Fainted():
+		s.message = fmt.Sprintf(loc.Get("party.fainted"), p.Name(loc))
+		return nil
+	}
+	s.active = false
+	if s.onSelect == nil {
+		return nil
+	}
+	return s.onSelect(s.cursor)
+}
+
+func (s *Screen) OnUpdate() error {
+	return nil
+}
+
+func (s *Screen) OnDraw(drawer draw.OptionDrawer) error {
+	draw.OverlayColor(drawer, util.NewNRGBColor(104, 168, 192))
+	if s.summary {
+		s.drawSummary(drawer)
+		return nil
+	}
+
+	loc := s.ctx.Localisation()
+	screenWidth, screenHeight := drawer.Bounds().Dx(), drawer.Bounds().Dy()
+	for i, p := range s.party.Members() {
+		cellDrawer := drawer.Move(4+i%2*(cellWidth+8), 12+i/2*(cellHeight+8))
+		bgColor := util.NewNRGBColor(40, 80, 104)
+		switch {
+		case i == s.swapping:
+			bgColor = util.NewNRGBColor(248, 168, 72)
+		case i == s.cursor:
+			bgColor = util.NewNRGBColor(248, 88, 40)
+		case i == s.current:
+			bgColor = util.NewNRGBColor(72, 152, 72)
+		}
+		draw.PrepareDrawRect(cellDrawer, cellWidth, cellHeight, bgColor).SetRadius(8).Draw()
+		s.drawIcon(cellDrawer.Move(2, (cellHeight-iconSize)/2), p)
+		DrawStatusCard(cellDrawer.Move(cellWidth-CardWidth-2, (cellHeight-CardHeight)/2), loc, p, p.MaxHP())
+	}
+
+	// 底部提示栏
+	textFont := util.GetFont(util.FontTypeEnum.Normal, 26)
+	barH := 80
+	draw.PrepareDrawRect(drawer, screenWidth, barH+10, color.Black).Move(0, screenHeight-barH-10).Draw()
+	draw.PrepareDrawRect(drawer, screenWidth-10, barH, util.NewNRGBColor(248, 248, 248)).Move(5, screenHeight-barH-5).SetRadius(6).Draw()
+	draw.PrepareDrawText(drawer, s.prompt(), textFont, color.Black).Move(24, screenHeight-barH+10).Draw()
+	if s.mode != ModeEnum.Forced {
+		cancelColor := color.Color(util.NewNRGBColor(40, 80, 104))
+		if s.cursor >= s.party.Len() {
+			cancelColor = util.NewNRGBColor(248, 88, 40)
+		}
+		draw.PrepareDrawRect(drawer, 120, barH-20, cancelColor).Move(screenWidth-140, screenHeight-barH+5).SetRadius(8).Draw()
+		draw.PrepareDrawText(drawer, loc.Get("party.cancel"), textFont, color.White).Move(screenWidth-112, screenHeight-barH+18).Draw()
+	}
+
+	// 子菜单
+	if s.option >= 0 {
+		menuW, lineH := 180, 34
+		menuH := len(s.options)*lineH + 20
+		menuDrawer := drawer.Move(screenWidth-menuW-5, screenHeight-barH-menuH-15)
+		draw.PrepareDrawRect(menuDrawer, menuW, menuH, util.NewNRGBColor(248, 248, 248)).SetBorderWidth(4).SetBorderColor(util.NewNRGBColor(112, 104, 128)).SetRadius(6).Draw()
+		for i, opt := range s.options {
+			if i == s.option {
+				draw.PrepareDrawText(menuDrawer, "▶", textFont, color.Black).Move(14, 10+i*lineH).Draw()
+			}
+			draw.PrepareDrawText(menuDrawer, loc.Get(optionKeys[opt]), textFont, color.Black).Move(44, 10+i*lineH).Draw()
+		}
+	}
+	return nil
+}
+
+var optionKeys = map[option]string{
+	optionSwitchIn: "party.switch_in",
+	optionSummary:  "party.summary",
+	optionSwap:     "party.swap",
+	optionCancel:   "party.cancel",
+}
+
+// 底部提示文字
+func (s *Screen) prompt() string {
+	loc := s.ctx.Localisation()
+	switch {
+	case s.message != "":
+		return s.message
+	case s.swapping >= 0:
+		return loc.Get("party.prompt.swap")
+	case s.option >= 0:
+		p, _ := s.party.Get(s.cursor)
+		return fmt.Sprintf(loc.Get("party.prompt.option"), p.Name(loc))
+	case s.mode == ModeEnum.View:
+		return loc.Get("party.prompt.view")
+	case s.mode == ModeEnum.Select:
+		return loc.Get("party.prompt.item")
+	default:
+		return loc.Get("party.prompt.switch")
+	}
+}
+
+// 绘制宝可梦图标，缩放正面图到图标大小
+func (s *Screen) drawIcon(drawer draw.OptionDrawer, p *pokemon.Pokemon) {
+	img := p.Race.Front.GetCurrentFrameImage()
+	scale := float64(iconSize) / float64(max(img.Bounds().Dx(), 1))
+	draw.PrepareDrawImage(drawer, img).Scale(scale, scale).Draw()
+}
+
+// 绘制详情页
+func (s *Screen) drawSummary(drawer draw.OptionDrawer) {
+	p, ok := s.party.Get(s.cursor)
+	if !ok {
+		return
+	}
+	loc := s.ctx.Localisation()
+	textFont := util.GetFont(util.FontTypeEnum.Normal, 26)
+	smallFont := util.GetFont(util.FontTypeEnum.Normal, 20)
+	screenWidth, screenHeight := drawer.Bounds().Dx(), drawer.Bounds().Dy()
+	stats := p.Stats()
+
+	// 左侧：外观和基本信息
+	DrawStatusCard(drawer.Move(20, 20), loc, p, stats.HP)
+	p.Race.Front.Update()
+	img := p.Race.Front.GetCurrentFrameImage()
+	scale := float64(192) / float64(max(img.Bounds().Dx(), 1))
+	draw.PrepareDrawImage(drawer, img).Scale(scale, scale).Move(74, 110).Draw()
+	infoDrawer := drawer.Move(20, 320)
+	draw.PrepareDrawRect(infoDrawer, CardWidth, 140, util.NewNRGBColor(248, 248, 248)).SetRadius(6).Draw()
+	lines := []string{
+		fmt.Sprintf("%s  %d/%d", loc.Get("stat.hp"), p.HP, stats.HP),
+		fmt.Sprintf("%s  %s", loc.Get("party.nature"), loc.Get("nature."+p.Nature.String())),
+		fmt.Sprintf("%s  %d", loc.Get("party.exp"), p.Exp),
+	}
+	for i, line := range lines {
+		draw.PrepareDrawText(infoDrawer, line, textFont, color.Black).Move(16, 12+i*40).Draw()
+	}
+
+	// 右侧：能力值和技能
+	rightX := 340
+	statsDrawer := drawer.Move(rightX, 20)
+	draw.PrepareDrawRect(statsDrawer, screenWidth-rightX-20, 200, util.NewNRGBColor(248, 248, 248)).SetRadius(6).Draw()
+	for i, stat := range enum.Values[pokemon.Stat](pokemon.StatEnum)[1:] {
+		draw.PrepareDrawText(statsDrawer, loc.Get("stat."+stat.String()), textFont, color.Black).Move(16, 12+i*36).Draw()
+		draw.PrepareDrawText(statsDrawer, fmt.Sprintf("%3d", stats.Get(stat)), textFont, color.Black).Move(screenWidth-rightX-100, 12+i*36).Draw()
+	}
+	movesDrawer := drawer.Move(rightX, 240)
+	draw.PrepareDrawRect(movesDrawer, screenWidth-rightX-20, screenHeight-260, util.NewNRGBColor(248, 248, 248)).SetRadius(6).Draw()
+	for i, slot := range p.Moves {
+		text, ppText := "-", ""
+		if move, err := pokemon.GetMove(slot.Move); !slot.Empty() && err == nil {
+			text = move.Name(loc)
+			ppText = fmt.Sprintf("PP %2d/%2d", slot.PP, move.MaxPP(slot.PPUp))
+		}
+		draw.PrepareDrawText(movesDrawer, text, textFont, color.Black).Move(16, 12+i*50).Draw()
+		draw.PrepareDrawText(movesDrawer, ppText, smallFont, util.NewNRGBColor(80, 104, 88)).Move(screenWidth-rightX-150, 18+i*50).Draw()
+	}
+}
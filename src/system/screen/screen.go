@@ -0,0 +1,122 @@
+package screen
+
+import (
+	"slices"
+
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/util/draw"
+)
+
+// Screen 界面，只有栈顶的界面接收按键和更新
+type Screen interface {
+	OnAction(action input.KeyInputAction) error
+	OnUpdate() error
+	OnDraw(drawer draw.OptionDrawer) error
+}
+
+// Overlay 覆盖在下层界面之上的界面，绘制前会先绘制下层界面
+type Overlay interface {
+	Screen
+	Overlay() bool
+}
+
+// Closable 可以自行关闭的界面，不再活跃时自动出栈
+type Closable interface {
+	Screen
+	Active() bool
+}
+
+// Stack 界面栈
+type Stack struct {
+	screens []Screen
+}
+
+func NewStack(screens ...Screen) *Stack {
+	return &Stack{screens: screens}
+}
+
+// Push 压入界面，已在栈中的界面会被移到栈顶
+func (s *Stack) Push(screen Screen) {
+	s.Remove(screen)
+	s.screens = append(s.screens, screen)
+}
+
+// Pop 弹出栈顶界面，栈底界面不会被弹出
+func (s *Stack) Pop() (Screen, bool) {
+	if len(s.screens) <= 1 {
+		return nil, false
+	}
+	top := s.screens[len(s.screens)-1]
+	s.screens = s.screens[:len(s.screens)-1]
+	return top, true
+}
+
+// Remove 移除界面
+func (s *Stack) Remove(screen Screen) {
+	s.screens = slices.DeleteFunc(s.screens, func(item Screen) bool { return item == screen })
+}
+
+// Top 栈顶界面
+func (s *Stack) Top() (Screen, bool) {
+	if len(s.screens) == 0 {
+		return nil, false
+	}
+	return s.screens[len(s.screens)-1], true
+}
+
+// Contain 界面是否在栈中
+func (s *Stack) Contain(screen Screen) bool {
+	return slices.Contains(s.screens, screen)
+}
+
+func (s *Stack) Len() int {
+	return len(s.screens)
+}
+
+// 移除已经关闭的界面，栈底界面总是保留
+func (s *Stack) prune() {
+	for i := len(s.screens) - 1; i > 0; i-- {
+		if closable, ok := s.screens[i].(Closable); ok && !closable.Active() {
+			s.screens = slices.Delete(s.screens, i, i+1)
+		}
+	}
+}
+
+func (s *Stack) OnAction(action input.KeyInputAction) error {
+	s.prune()
+	top, ok := s.Top()
+	if !ok {
+		return nil
+	}
+	err := top.OnAction(action)
+	s.prune()
+	return err
+}
+
+func (s *Stack) OnUpdate() error {
+	s.prune()
+	top, ok := s.Top()
+	if !ok {
+		return nil
+	}
+	err := top.OnUpdate()
+	s.prune()
+	return err
+}
+
+// OnDraw 从最上层的不透明界面开始向上绘制
+func (s *Stack) OnDraw(drawer draw.OptionDrawer) error {
+	begin := len(s.screens) - 1
+	for ; begin > 0; begin-- {
+		if overlay, ok := s.screens[begin].(Overlay); !ok || !overlay.Overlay() {
+			break
+		}
+	}
+	for _, screen := range s.screens[max(begin, 0):] {
+		err := screen.OnDraw(drawer)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
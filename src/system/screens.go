@@ -0,0 +1,166 @@
+package system
+
+import (
+	"time"
+
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/system/world/sprite"
+	"github.com/kkkunny/pokemon/src/system/world/sprite/person"
+	"github.com/kkkunny/pokemon/src/util/draw"
+)
+
+// 地图界面，位于界面栈底
+type worldScreen struct {
+	*System
+}
+
+func (s worldScreen) OnAction(action input.KeyInputAction) error {
+	s.script.OnAction(action)
+	if s.script.Running() {
+		return nil
+	}
+
+	if action == input.KeyInputActionEnum.Start.Pressed() {
+		s.OpenStartMenu()
+		return nil
+	}
+
+	drawInfo := &person.UpdateInfo{World: s.world}
+	err := s.self.OnAction(s.ctx, action, drawInfo)
+	if err != nil {
+		return err
+	}
+	for _, sp := range s.world.CurrentMap().Sprites() {
+		err = sp.OnAction(s.ctx, action, drawInfo)
+		if err != nil {
+			return err
+		}
+	}
+
+	if action == input.KeyInputActionEnum.A.Pressed() {
+		x, y := s.self.Position()
+		targetX, targetY := person.GetNextPositionByDirection(s.self.Direction(), x, y)
+		targetMap, targetX, targetY, _ := s.world.GetActualPosition(targetX, targetY)
+		targetSprite, ok := targetMap.GetSpriteByPosition(targetX, targetY)
+		if ok {
+			s.self.SetActionSprite(targetSprite)
+			switch targetSprite.ActionType() {
+			case sprite.ActionTypeEnum.Script:
+				s.self.SetActionSprite(nil)
+				err = s.script.Run(targetSprite.GetScript(), targetSprite)
+				if err != nil {
+					return err
+				}
+			case sprite.ActionTypeEnum.Label:
+				s.showLabel(s.ctx.Localisation().Get(targetSprite.GetText()))
+			case sprite.ActionTypeEnum.PickUp:
+				s.self.SetActionSprite(nil)
+				err = s.pickUp(targetSprite)
+				if err != nil {
+					return err
+				}
+			case sprite.ActionTypeEnum.Dialogue:
+				movableSprite, ok := targetSprite.(sprite.MovableSprite)
+				if ok {
+					movableSprite.SetMovable(false)
+				}
+				s.showDialogue(s.ctx.Localisation().Get(targetSprite.GetText()))
+			case sprite.ActionTypeEnum.Trainer:
+				s.self.SetActionSprite(nil)
+				if trainer, ok := targetSprite.(person.Trainer); ok {
+					s.talkToTrainer(trainer)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s worldScreen) OnUpdate() error {
+	// 时间
+	s.time = s.time.Add(time.Minute)
+
+	// 脚本
+	err := s.script.Update()
+	if err != nil {
+		return err
+	}
+
+	// 主角，按住B跑步
+	s.self.SetRunning(s.keyHeld(input.KeyInputActionEnum.B))
+	drawInfo := &person.UpdateInfo{World: s.world}
+	err = s.self.Update(s.ctx, drawInfo)
+	if err != nil {
+		return err
+	}
+	// 世界
+	err = s.world.Update(s.ctx, []sprite.Sprite{s.self}, drawInfo)
+	if err != nil {
+		return err
+	}
+	// 训练师视线
+	s.checkTrainerSight()
+	return nil
+}
+
+func (s worldScreen) OnDraw(drawer draw.OptionDrawer) error {
+	// 地图
+	err := s.world.OnDraw(drawer.Scale(float64(s.ctx.Config().Scale), float64(s.ctx.Config().Scale)), []sprite.Sprite{s.self})
+	if err != nil {
+		return err
+	}
+
+	// 天色
+	if !s.world.CurrentMap().Indoor() {
+		draw.OverlayColor(drawer, s.getSkyMaskColor())
+	}
+
+	// 地图名
+	return s.world.DrawMapName(drawer)
+}
+
+// 对话框，覆盖在地图上，显示期间地图和脚本继续运行
+type dialogueScreen struct {
+	*System
+}
+
+func (s dialogueScreen) Active() bool {
+	return s.dialogue.Display()
+}
+
+func (s dialogueScreen) Overlay() bool {
+	return true
+}
+
+func (s dialogueScreen) OnAction(action input.KeyInputAction) error {
+	s.dialogue.SetFastMode(false)
+	s.script.OnAction(action)
+
+	// B和A一样可以继续和关闭对话
+	confirm := action == input.KeyInputActionEnum.A.Pressed() || action == input.KeyInputActionEnum.B.Pressed()
+	if s.dialogue.WaitForContinue() && confirm {
+		s.dialogue.Continue()
+	} else if s.dialogue.StreamDone() && confirm {
+		actionSprite := s.self.ActionSprite()
+		if actionSprite != nil {
+			s.self.SetActionSprite(nil)
+			movableSprite, ok := actionSprite.(sprite.MovableSprite)
+			if ok {
+				movableSprite.SetMovable(true)
+			}
+		}
+		s.dialogue.SetDisplay(false)
+	} else if action == input.KeyInputActionEnum.A || action == input.KeyInputActionEnum.B {
+		s.dialogue.SetFastMode(true)
+	}
+	return nil
+}
+
+func (s dialogueScreen) OnUpdate() error {
+	s.dialogue.Update()
+	return worldScreen{s.System}.OnUpdate()
+}
+
+func (s dialogueScreen) OnDraw(drawer draw.OptionDrawer) error {
+	return s.dialogue.OnDraw(drawer)
+}
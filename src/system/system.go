@@ -1,37 +1,78 @@
 package system
 
 import (
+	"fmt"
 	"image/color"
 	"time"
 
+	"github.com/hajimehoshi/ebiten/v2"
+	stlslices "github.com/kkkunny/stl/container/slices"
+	stlval "github.com/kkkunny/stl/value"
+
 	"github.com/kkkunny/pokemon/src/config"
 	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/item"
 	"github.com/kkkunny/pokemon/src/output/voice"
+	"github.com/kkkunny/pokemon/src/pokemon"
+	"github.com/kkkunny/pokemon/src/save"
+	"github.com/kkkunny/pokemon/src/script"
+	"github.com/kkkunny/pokemon/src/system/bag"
 	"github.com/kkkunny/pokemon/src/system/battle"
 	"github.com/kkkunny/pokemon/src/system/context"
 	"github.com/kkkunny/pokemon/src/system/dialogue"
+	"github.com/kkkunny/pokemon/src/system/menu"
+	"github.com/kkkunny/pokemon/src/system/party"
+	"github.com/kkkunny/pokemon/src/system/screen"
 	"github.com/kkkunny/pokemon/src/system/world"
 	"github.com/kkkunny/pokemon/src/system/world/sprite"
+	itemsprite "github.com/kkkunny/pokemon/src/system/world/sprite/item"
 	"github.com/kkkunny/pokemon/src/system/world/sprite/person"
 	"github.com/kkkunny/pokemon/src/util"
 	"github.com/kkkunny/pokemon/src/util/draw"
 )
 
 type System struct {
-	ctx            context.Context
-	world          *world.World
-	self           person.Self
-	mapVoicePlayer *voice.Player
-	dialogue       *dialogue.System
+	ctx      context.Context
+	world    *world.World
+	self     person.Self
+	music    *mapMusic // 地图音乐
+	dialogue *dialogue.System
 	// 战斗页面
-	battle *battle.System
+	battle       *battle.System
+	battleResult battle.Result // 最近一场战斗的结果
+	// 脚本
+	script      *script.Runtime
+	soundPlayer *voice.Player
+
+	time     time.Time     // 游戏世界时间
+	playTime time.Duration // 游玩时长
+
+	party       *pokemon.Party // 队伍
+	partyScreen *party.Screen  // 队伍界面
+	bag         *item.Bag      // 背包
+	bagScreen   *bag.Screen    // 背包界面
+	pendingItem *item.Item     // 正在选择使用对象的道具
+	money       int            // 金钱
+	encounter   int16          // 当前战斗中野生宝可梦的种族
+	opponent    person.Trainer // 当前战斗中的对手训练师
+	challenge   *challenge     // 进行中的训练师挑战
+	warping     *warping       // 进行中的传送
 
-	time time.Time // 游戏世界时间
+	held        func(action input.KeyInputAction) bool // 按键是否被按住
+	screens     *screen.Stack                          // 界面栈，栈底为地图
+	startMenu   *menu.StartMenu                        // 开始菜单
+	pokedex     *menu.PokedexScreen                    // 图鉴
+	trainerCard *menu.TrainerCardScreen                // 训练家卡片
+	saveScreen  *menu.SaveScreen                       // 存档
+	options     *menu.OptionsScreen                    // 设置
+	controls    *menu.ControlsScreen                   // 按键设置
 }
 
 func NewSystem(ctx context.Context) (*System, error) {
+	cfg := ctx.Config()
+	textSpeed, _ := dialogue.ParseTextSpeed(cfg.TextSpeed) // 已由Config.Validate校验
 	// 地图
-	w, err := world.NewWorld(ctx, "pallet_town")
+	w, err := world.NewWorld(ctx, cfg.StartMap)
 	if err != nil {
 		return nil, err
 	}
@@ -45,128 +86,163 @@ func NewSystem(ctx context.Context) (*System, error) {
 	if err != nil {
 		return nil, err
 	}
-	ds.DisplayLabel("欢迎来到口袋妖怪世界！开始属于你的冒险吧！")
 	// 主角
 	self, err := person.NewSelf("master")
 	if err != nil {
 		return nil, err
 	}
-	self.SetPosition(6, 8)
+	self.SetPosition(cfg.StartPos[0], cfg.StartPos[1])
+	// 初始宝可梦
+	starter, err := pokemon.GetPokemonRace(1)
+	if err != nil {
+		return nil, err
+	}
 	s := &System{
-		ctx:            ctx,
-		world:          w,
-		self:           self,
-		mapVoicePlayer: voice.NewPlayer(),
-		dialogue:       ds,
-		time:           time.Now(),
-		battle:         battleSystem,
+		ctx:         ctx,
+		world:       w,
+		self:        self,
+		music:       newMapMusic(cfg.MusicVolume),
+		soundPlayer: voice.NewPlayer(),
+		dialogue:    ds,
+		time:        cfg.StartTime,
+		battle:      battleSystem,
+		party:       pokemon.NewParty(pokemon.NewPokemon(starter, 5, ctx.Rand(context.RandStreamEnum.Pokemon))),
+		partyScreen: party.NewScreen(ctx),
+		bag:         item.NewBag(),
+		bagScreen:   bag.NewScreen(ctx),
 	}
+	ds.SetTextSpeed(textSpeed)
+	s.soundPlayer.SetVolume(cfg.SoundVolume)
+	s.script = script.NewRuntime(s, ctx)
+	s.screens = screen.NewStack(worldScreen{s})
+	s.initStartMenu()
+	s.registerCaught(starter.ID)
 	w.SetOnBattleStart(s.OnBattleStart)
+	w.SetOnWarp(s.OnWarp)
+	w.SetCanSurf(s.canSurf)
+	w.SetClock(func() time.Time { return s.time })
+	battleSystem.SetOnBattleEnd(s.OnBattleEnd)
+	s.partyScreen.SetOnSelect(s.onPartySelect)
+	s.partyScreen.SetOnClose(s.onPartyClose)
+	s.bagScreen.SetOnUse(s.onBagUse)
+	s.showLabel("欢迎来到口袋妖怪世界！开始属于你的冒险吧！")
 	return s, err
 }
 
-func (s *System) OnAction(action input.KeyInputAction) error {
-	s.dialogue.SetFastMode(false)
+// 创建开始菜单及各个页面
+func (s *System) initStartMenu() {
+	s.startMenu = menu.NewStartMenu(s.ctx)
+	s.pokedex = menu.NewPokedexScreen(s.ctx)
+	s.trainerCard = menu.NewTrainerCardScreen(s.ctx)
+	s.saveScreen = menu.NewSaveScreen(s.ctx)
+	s.options = menu.NewOptionsScreen(s.ctx)
+	s.saveScreen.SetOnSave(s.SaveGame)
+	s.saveScreen.SetOnLoad(s.LoadGame)
+	s.options.SetOnChange(s.applyOptions)
+	s.options.SetOnControls(func() error {
+		if s.controls == nil {
+			return nil
+		}
+		s.controls.Open()
+		s.screens.Push(s.controls)
+		return nil
+	})
 
-	if !s.dialogue.Display() {
-		drawInfo := &person.UpdateInfo{World: s.world}
-		err := s.self.OnAction(s.ctx, action, drawInfo)
+	s.startMenu.AddEntry("menu.pokedex", func() error {
+		err := s.pokedex.Open()
 		if err != nil {
 			return err
 		}
-		for _, sp := range s.world.CurrentMap().Sprites() {
-			err = sp.OnAction(s.ctx, action, drawInfo)
-			if err != nil {
-				return err
-			}
-		}
+		s.screens.Push(s.pokedex)
+		return nil
+	})
+	s.startMenu.AddEntry("menu.pokemon", func() error {
+		s.OpenParty()
+		return nil
+	})
+	s.startMenu.AddEntry("menu.bag", func() error {
+		s.OpenBag()
+		return nil
+	})
+	s.startMenu.AddEntry("menu.trainer_card", func() error {
+		s.trainerCard.Open(menu.TrainerInfo{
+			Name:     s.ctx.Localisation().Get("trainer_card.default_name"),
+			Money:    s.money,
+			PlayTime: s.playTime,
+			Party:    s.party.Len(),
+		})
+		s.screens.Push(s.trainerCard)
+		return nil
+	})
+	s.startMenu.AddEntry("menu.save", func() error {
+		s.saveScreen.Open()
+		s.screens.Push(s.saveScreen)
+		return nil
+	})
+	s.startMenu.AddEntry("menu.load", func() error {
+		s.saveScreen.OpenLoad()
+		s.screens.Push(s.saveScreen)
+		return nil
+	})
+	s.startMenu.AddEntry("menu.options", func() error {
+		s.options.Open(menu.Options{
+			TextSpeed: s.dialogue.TextSpeed(),
+			Music:     s.music.Volume() > 0,
+		})
+		s.screens.Push(s.options)
+		return nil
+	})
+}
 
-		if action == input.KeyInputActionEnum.A.Pressed() {
-			x, y := s.self.Position()
-			targetX, targetY := person.GetNextPositionByDirection(s.self.Direction(), x, y)
-			targetMap, targetX, targetY, _ := s.world.GetActualPosition(targetX, targetY)
-			targetSprite, ok := targetMap.GetSpriteByPosition(targetX, targetY)
-			if ok {
-				s.self.SetActionSprite(targetSprite)
-				switch targetSprite.ActionType() {
-				case sprite.ActionTypeEnum.Script:
-					// scriptName := targetSprite.GetScript()
-					// rt, err := loadScriptFileWithSelf(updateInfo.World, targetSprite, s, scriptName)
-					// if err != nil {
-					// 	return err
-					// }
-					// defer rt.Close()
-					//
-					// param1 := rt.NewUserData()
-					// param1.Value = targetSprite
-					// err = rt.CallByParam(lua.P{
-					// 	Fn:      rt.GetGlobal(scriptName),
-					// 	NRet:    1,
-					// 	Protect: true,
-					// }, param1)
-					// if err != nil {
-					// 	return err
-					// }
-				case sprite.ActionTypeEnum.Label:
-					text := s.ctx.Localisation().Get(targetSprite.GetText())
-					s.dialogue.DisplayLabel(text)
-				case sprite.ActionTypeEnum.Dialogue:
-					movableSprite, ok := targetSprite.(sprite.MovableSprite)
-					if ok {
-						movableSprite.SetMovable(false)
-					}
-					text := s.ctx.Localisation().Get(targetSprite.GetText())
-					s.dialogue.DisplayDialogue(text)
-				}
-			}
-		}
-	} else if s.dialogue.WaitForContinue() && action == input.KeyInputActionEnum.A.Pressed() {
-		s.dialogue.Continue()
-	} else if s.dialogue.StreamDone() && action == input.KeyInputActionEnum.A.Pressed() {
-		actionSprite := s.self.ActionSprite()
-		if actionSprite != nil {
-			s.self.SetActionSprite(nil)
-			movableSprite, ok := actionSprite.(sprite.MovableSprite)
-			if ok {
-				movableSprite.SetMovable(true)
-			}
-		}
-		s.dialogue.SetDisplay(false)
-	} else if action == input.KeyInputActionEnum.A {
-		s.dialogue.SetFastMode(true)
-	}
+// 应用设置
+func (s *System) applyOptions(options menu.Options) error {
+	s.dialogue.SetTextSpeed(options.TextSpeed)
+	s.music.SetVolume(stlval.Ternary(options.Music, s.ctx.Config().MusicVolume, 0))
 	return nil
 }
 
+// SetHeldFunc 设置查询按键是否被按住的函数
+func (s *System) SetHeldFunc(f func(action input.KeyInputAction) bool) {
+	s.held = f
+}
+
+// SetLoadLocked 录制或回放按键时禁止从菜单读取存档，存档文件在回放时可能已经不同
+func (s *System) SetLoadLocked(locked bool) {
+	s.saveScreen.SetLoadLocked(locked)
+}
+
+// SetKeyBinder 设置改键使用的输入系统
+func (s *System) SetKeyBinder(binder menu.KeyBinder) {
+	s.controls = menu.NewControlsScreen(s.ctx, binder)
+	s.controls.SetOnChange(func() error { return binder.Bindings().Save(config.KeymapPath) })
+}
+
+// 按键是否被按住
+func (s *System) keyHeld(action input.KeyInputAction) bool {
+	return s.held != nil && s.held(action)
+}
+
+func (s *System) OnAction(action input.KeyInputAction) error {
+	return s.screens.OnAction(action)
+}
+
 func (s *System) OnUpdate() error {
+	s.playTime += time.Second / time.Duration(ebiten.TPS())
+
 	// 地图音乐
 	songFilepath, ok := s.world.CurrentMap().SongFilepath()
 	if ok {
-		err := s.mapVoicePlayer.LoadFile(songFilepath)
-		if err != nil {
-			return err
-		}
-		err = s.mapVoicePlayer.Play()
+		err := s.music.Play(songFilepath)
 		if err != nil {
 			return err
 		}
 	}
-
-	if s.battle.Active() {
-		return s.battle.OnUpdate()
-	} else {
-		// 时间
-		s.time = s.time.Add(time.Minute)
-
-		// 主角
-		drawInfo := &person.UpdateInfo{World: s.world}
-		err := s.self.Update(s.ctx, drawInfo)
-		if err != nil {
-			return err
-		}
-		// 世界
-		return s.world.Update(s.ctx, []sprite.Sprite{s.self}, drawInfo)
+	err := s.music.Update()
+	if err != nil {
+		return err
 	}
+
+	return s.screens.OnUpdate()
 }
 
 func (s *System) getSkyMaskColor() color.Color {
@@ -192,35 +268,253 @@ func (s *System) getSkyMaskColor() color.Color {
 }
 
 func (s *System) OnDraw(drawer draw.OptionDrawer) error {
-	if s.battle.Active() {
-		return s.battle.OnDraw(drawer)
-	} else {
-		// 地图
-		err := s.world.OnDraw(drawer.Scale(config.Scale, config.Scale), []sprite.Sprite{s.self})
-		if err != nil {
-			return err
-		}
+	return s.screens.OnDraw(drawer)
+}
 
-		// 天色
-		if !s.world.CurrentMap().Indoor() {
-			draw.OverlayColor(drawer, s.getSkyMaskColor())
-		}
+// 在地图上显示提示
+func (s *System) showLabel(text string) {
+	s.dialogue.DisplayLabel(text)
+	s.screens.Push(dialogueScreen{s})
+}
 
-		// 地图名
-		err = s.world.DrawMapName(drawer)
-		if err != nil {
-			return err
+// 在地图上显示对话
+func (s *System) showDialogue(text string) {
+	s.dialogue.DisplayDialogue(text)
+	s.screens.Push(dialogueScreen{s})
+}
+
+func (s *System) OnBattleStart(encounter world.Encounter) error {
+	s.battleResult = battle.ResultEnum.None
+	s.ctx.State().SetFlag(menu.SeenFlag(encounter.Species), true)
+	s.encounter = encounter.Species
+	err := s.battle.StartOneBattle(s.party, s.bag, encounter.Site, encounter.Species, encounter.Level)
+	if err != nil {
+		return err
+	}
+	s.screens.Push(s.battle)
+	return nil
+}
+
+const surfMove = "surf" // 可以在水面上移动的技能
+
+// 队伍中是否有会冲浪的宝可梦
+func (s *System) canSurf() bool {
+	return stlslices.Any(s.party.Members(), func(_ int, p *pokemon.Pokemon) bool {
+		return !p.Fainted() && p.KnowsMove(surfMove)
+	})
+}
+
+// OpenParty 打开队伍界面
+func (s *System) OpenParty() {
+	s.partyScreen.Open(s.party, party.ModeEnum.View, -1)
+	s.screens.Push(s.partyScreen)
+}
+
+// OpenBag 打开背包
+func (s *System) OpenBag() {
+	s.bagScreen.Open(s.bag, bag.ModeEnum.Field)
+	s.screens.Push(s.bagScreen)
+}
+
+// OpenStartMenu 打开开始菜单
+func (s *System) OpenStartMenu() {
+	s.startMenu.Open()
+	s.screens.Push(s.startMenu)
+}
+
+// 在背包中选择使用道具
+func (s *System) onBagUse(it *item.Item) error {
+	handler, _ := item.GetUseHandler(it, false)
+	if handler.NeedTarget {
+		s.pendingItem = it
+		s.partyScreen.Open(s.party, party.ModeEnum.Select, -1)
+		s.screens.Push(s.partyScreen)
+		return nil
+	}
+	return s.useItem(it, nil)
+}
+
+// 在队伍界面选择了道具的使用对象
+func (s *System) onPartySelect(index int) error {
+	it := s.pendingItem
+	s.pendingItem = nil
+	if it == nil {
+		return nil
+	}
+	target, _ := s.party.Get(index)
+	return s.useItem(it, target)
+}
+
+func (s *System) onPartyClose() error {
+	s.pendingItem = nil
+	return nil
+}
+
+// 在野外使用道具，结果显示在背包的提示栏
+func (s *System) useItem(it *item.Item, target *pokemon.Pokemon) error {
+	if it.Field == fishEffect {
+		return s.fish()
+	}
+	loc := s.ctx.Localisation()
+	if !item.Use(&item.UseContext{Item: it, Target: target}) {
+		s.bagScreen.ShowMessage(loc.Get("bag.no_effect"))
+		return nil
+	}
+	if !it.Reusable {
+		s.bag.Remove(it.ID, 1)
+	}
+	handler, _ := item.GetUseHandler(it, false)
+	if target != nil && handler.Message != "" {
+		s.bagScreen.ShowMessage(fmt.Sprintf(loc.Get(handler.Message), target.Name(loc)))
+	}
+	return nil
+}
+
+// 钓鱼竿的效果，由系统向主角面前的水面钓鱼
+const fishEffect = "fish"
+
+func init() {
+	item.RegisterUseHandler(fishEffect, item.UseHandler{})
+}
+
+// 面向水面时关闭菜单并钓鱼，没有宝可梦上钩时提示
+func (s *System) fish() error {
+	loc := s.ctx.Localisation()
+	if !s.world.CanFish(s.self.Direction()) {
+		s.bagScreen.ShowMessage(loc.Get("bag.cant_use"))
+		return nil
+	}
+	s.bagScreen.Close()
+	s.startMenu.Close()
+	bite, err := s.world.Fish(s.self.Direction())
+	if err != nil {
+		return err
+	} else if !bite {
+		s.showLabel(loc.Get("bag.fish_nothing"))
+	}
+	return nil
+}
+
+// 拾取地图上的道具，放不下时不拾取
+func (s *System) pickUp(target sprite.Sprite) error {
+	itemSprite, ok := target.(itemsprite.Item)
+	if !ok {
+		return nil
+	}
+	id, count := itemSprite.PickUp()
+	it, err := item.GetItem(id)
+	if err != nil {
+		return err
+	}
+	loc := s.ctx.Localisation()
+	added, err := s.bag.Add(id, count)
+	if err != nil {
+		return err
+	} else if added < count {
+		s.bag.Remove(id, added)
+		s.showLabel(fmt.Sprintf(loc.Get("bag.pocket_full"), it.Name(loc)))
+		return nil
+	}
+	s.ctx.State().SetFlag(itemSprite.CollectFlag(), true)
+	s.showLabel(fmt.Sprintf(loc.Get("bag.picked_up"), it.Name(loc), count))
+	return nil
+}
+
+func (s *System) OnBattleEnd(result battle.Result) error {
+	s.battleResult = result
+	if result == battle.ResultEnum.Catch {
+		s.ctx.State().SetFlag(menu.CaughtFlag(s.encounter), true)
+	}
+	if s.opponent != nil {
+		if result == battle.ResultEnum.Win {
+			s.ctx.State().SetFlag(s.opponent.DefeatedFlag(), true)
 		}
+		s.opponent.SetMovable(true)
+		s.opponent = nil
+	}
+	if result == battle.ResultEnum.Lose {
+		return s.whiteout()
+	}
+	return nil
+}
 
-		// 对话
-		err = s.dialogue.OnDraw(drawer)
+// 战斗失败后队伍全部回复，回到新游戏的初始位置
+func (s *System) whiteout() error {
+	s.party.HealAll()
+	cfg := s.ctx.Config()
+	err := s.world.Warp([]sprite.Sprite{s.self}, cfg.StartMap, cfg.StartPos[0], cfg.StartPos[1])
+	if err != nil {
+		return err
+	}
+	s.self.SetDirection(util.DirectionEnum.Down)
+	s.showLabel(s.ctx.Localisation().Get("battle.whiteout"))
+	return nil
+}
+
+// 登记图鉴，获得的宝可梦同时记为遇见过和捕获过
+func (s *System) registerCaught(species int16) {
+	s.ctx.State().SetFlag(menu.SeenFlag(species), true)
+	s.ctx.State().SetFlag(menu.CaughtFlag(species), true)
+}
+
+// SaveGame 保存游戏到存档槽
+func (s *System) SaveGame(slot int) error {
+	data := s.Snapshot()
+	data.SavedAt = time.Now()
+	return save.Save(slot, data)
+}
+
+// Snapshot 当前的游戏状态，即存档的内容，不含保存时间
+func (s *System) Snapshot() *save.Data {
+	x, y := s.self.Position()
+	return &save.Data{
+		Map:       s.world.CurrentMap().ID(),
+		X:         x,
+		Y:         y,
+		Direction: s.self.Direction(),
+		Party:     stlslices.Map(s.party.Members(), func(_ int, p *pokemon.Pokemon) save.Pokemon { return save.NewPokemon(p) }),
+		Bag:       s.bag.Counts(),
+		Flags:     s.ctx.State().Flags(),
+		Vars:      s.ctx.State().Vars(),
+		Money:     s.money,
+		PlayTime:  s.playTime,
+		Time:      s.time,
+	}
+}
+
+// LoadGame 从存档槽读取游戏
+func (s *System) LoadGame(slot int) error {
+	data, err := save.Load(slot)
+	if err != nil {
+		return err
+	}
+	return s.LoadData(data)
+}
+
+// LoadData 恢复存档内容
+func (s *System) LoadData(data *save.Data) error {
+	members := make([]*pokemon.Pokemon, 0, len(data.Party))
+	for _, p := range data.Party {
+		pok, err := p.Pokemon()
 		if err != nil {
 			return err
 		}
-		return nil
+		members = append(members, pok)
 	}
-}
-
-func (s *System) OnBattleStart(site string) error {
-	return s.battle.StartOneBattle(site)
+	inventory, err := item.NewBagFromCounts(data.Bag)
+	if err != nil {
+		return err
+	}
+	err = s.world.Warp([]sprite.Sprite{s.self}, data.Map, data.X, data.Y)
+	if err != nil {
+		return err
+	}
+	s.self.SetDirection(data.Direction)
+	s.party = pokemon.NewParty(members...)
+	s.bag = inventory
+	s.ctx.State().Reset(data.Flags, data.Vars)
+	s.money = data.Money
+	s.playTime = data.PlayTime
+	s.time = data.Time
+	return nil
 }
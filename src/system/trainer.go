@@ -0,0 +1,220 @@
+package system
+
+import (
+	"github.com/tnnmigga/enum"
+
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/pokemon"
+	"github.com/kkkunny/pokemon/src/system/battle"
+	"github.com/kkkunny/pokemon/src/system/context"
+	"github.com/kkkunny/pokemon/src/system/menu"
+	"github.com/kkkunny/pokemon/src/system/world"
+	"github.com/kkkunny/pokemon/src/system/world/sprite/person"
+	"github.com/kkkunny/pokemon/src/util"
+	"github.com/kkkunny/pokemon/src/util/draw"
+)
+
+// 训练师挑战的阶段
+type challengeStage uint8
+
+const (
+	challengeStageEmote    challengeStage = iota // 头顶显示惊叹号
+	challengeStageApproach                       // 走到主角面前
+	challengeStageTalk                           // 战斗前对话
+)
+
+const (
+	challengeEmoteTicks  = 40  // 惊叹号显示帧数
+	approachMaxPathNodes = 256 // 走向主角时寻路最多展开的地块数
+	approachMaxWaitTicks = 60  // 走向主角时无路可走的最多等待帧数，超过后原地对话
+)
+
+// 进行中的训练师挑战
+type challenge struct {
+	trainer person.Trainer
+	stage   challengeStage
+	counter int
+}
+
+// 训练师挑战，覆盖在地图上，期间主角不能行动
+type challengeScreen struct {
+	*System
+}
+
+func (s challengeScreen) Active() bool {
+	return s.challenge != nil
+}
+
+func (s challengeScreen) Overlay() bool {
+	return true
+}
+
+func (s challengeScreen) OnAction(_ input.KeyInputAction) error {
+	return nil
+}
+
+func (s challengeScreen) OnUpdate() error {
+	err := worldScreen{s.System}.OnUpdate()
+	if err != nil || s.challenge == nil {
+		return err
+	}
+
+	c := s.challenge
+	switch c.stage {
+	case challengeStageEmote:
+		c.counter++
+		if c.counter < challengeEmoteTicks {
+			return nil
+		}
+		c.trainer.SetEmote(false)
+		c.stage, c.counter = challengeStageApproach, 0
+	case challengeStageApproach:
+		if c.trainer.Busying() || s.self.Busying() {
+			return nil
+		}
+		d, ok := s.facingSelf(c.trainer)
+		if !ok {
+			if s.approachSelf(c.trainer) {
+				c.counter = 0
+				return nil
+			}
+			// 一直走不过去时不再靠近，在原地面向主角
+			if c.counter++; c.counter < approachMaxWaitTicks {
+				return nil
+			}
+			x, y := c.trainer.Position()
+			selfX, selfY := s.self.Position()
+			d = util.DirectionOf(selfX-x, selfY-y)
+		}
+		c.trainer.SetDirection(d)
+		s.self.SetDirection(-d)
+		c.stage = challengeStageTalk
+		if text := c.trainer.GetText(); text != "" {
+			// 对话结束后回到此界面开始战斗
+			s.showDialogue(s.ctx.Localisation().Get(text))
+		}
+	case challengeStageTalk:
+		s.challenge = nil
+		return s.startTrainerBattle(c.trainer)
+	}
+	return nil
+}
+
+func (s challengeScreen) OnDraw(_ draw.OptionDrawer) error {
+	return nil
+}
+
+// 训练师和主角相邻时，返回训练师面向主角的方向
+func (s *System) facingSelf(trainer person.Trainer) (util.Direction, bool) {
+	x, y := trainer.Position()
+	selfX, selfY := s.self.Position()
+	for _, d := range enum.Values[util.Direction](util.DirectionEnum) {
+		if dx, dy := d.Offset(); x+dx == selfX && y+dy == selfY {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// 训练师向主角走一步，被其他精灵挡住时绕路
+// @return: 是否走出了一步，没有路线时返回false
+func (s *System) approachSelf(trainer person.Trainer) bool {
+	x, y := trainer.Position()
+	selfX, selfY := s.self.Position()
+	path, ok := s.world.FindPath([2]int{x, y}, [2]int{selfX, selfY}, world.PathOptions{MaxNodes: approachMaxPathNodes, StopAdjacent: true})
+	if !ok || len(path) == 0 {
+		return false
+	}
+	nextX, nextY := person.GetNextPositionByDirection(path[0], x, y)
+	return !s.world.CheckCollision(path[0], nextX, nextY) && trainer.SetNextStepDirection(path[0])
+}
+
+// 开始训练师挑战，spotted为是否由训练师发现主角
+func (s *System) startChallenge(trainer person.Trainer, spotted bool) {
+	// 挑战期间训练师停止自身的移动，战斗结束后恢复
+	trainer.SetMovable(false)
+	s.challenge = &challenge{trainer: trainer, stage: challengeStageApproach}
+	if spotted {
+		s.challenge.stage = challengeStageEmote
+		trainer.SetEmote(true)
+	}
+	s.screens.Push(challengeScreen{s})
+}
+
+// 主角和训练师对话，未战败时直接开始挑战
+func (s *System) talkToTrainer(trainer person.Trainer) {
+	trainer.SetDirection(-s.self.Direction())
+	if !s.ctx.State().Flag(trainer.DefeatedFlag()) && !s.party.AllFainted() {
+		s.startChallenge(trainer, false)
+		return
+	}
+	if text := trainer.DefeatedText(); text != "" {
+		// 对话结束后恢复移动
+		trainer.SetMovable(false)
+		s.self.SetActionSprite(trainer)
+		s.showDialogue(s.ctx.Localisation().Get(text))
+	}
+}
+
+// 检查主角是否进入了未战败训练师的视线，只在地图界面位于栈顶时检查
+func (s *System) checkTrainerSight() {
+	if top, _ := s.screens.Top(); top != (worldScreen{s}) {
+		return
+	}
+	if s.self.Busying() || s.script.Running() || s.party.AllFainted() {
+		return
+	}
+	selfX, selfY := s.self.Position()
+	for _, sp := range s.world.CurrentMap().Sprites() {
+		trainer, ok := sp.(person.Trainer)
+		if !ok || trainer.Busying() || s.ctx.State().Flag(trainer.DefeatedFlag()) {
+			continue
+		}
+		if s.inTrainerSight(trainer, selfX, selfY) {
+			s.startChallenge(trainer, true)
+			return
+		}
+	}
+}
+
+// 目标是否在训练师面朝方向的视野内且中间没有阻挡
+func (s *System) inTrainerSight(trainer person.Trainer, targetX, targetY int) bool {
+	d := trainer.Direction()
+	x, y := trainer.Position()
+	for range trainer.Sight() {
+		x, y = person.GetNextPositionByDirection(d, x, y)
+		if x == targetX && y == targetY {
+			return true
+		}
+		if s.world.CheckCollision(d, x, y) {
+			return false
+		}
+	}
+	return false
+}
+
+// 开始和训练师的战斗
+func (s *System) startTrainerBattle(trainer person.Trainer) error {
+	team := make([]*pokemon.Pokemon, 0, len(trainer.Team()))
+	for _, member := range trainer.Team() {
+		race, err := pokemon.GetPokemonRace(member.Species)
+		if err != nil {
+			return err
+		}
+		s.ctx.State().SetFlag(menu.SeenFlag(member.Species), true)
+		team = append(team, pokemon.NewPokemon(race, member.Level, s.ctx.Rand(context.RandStreamEnum.Pokemon)))
+	}
+	name := trainer.Name()
+	if name == "" {
+		name = "battle.trainer"
+	}
+
+	s.battleResult = battle.ResultEnum.None
+	s.opponent = trainer
+	err := s.battle.StartTrainerBattle(s.party, s.bag, trainer.Site(), s.ctx.Localisation().Get(name), team)
+	if err != nil {
+		return err
+	}
+	s.screens.Push(s.battle)
+	return nil
+}
@@ -0,0 +1,182 @@
+package system
+
+import (
+	"errors"
+	"image/color"
+	"io/fs"
+
+	"github.com/kkkunny/pokemon/src/input"
+	"github.com/kkkunny/pokemon/src/system/world"
+	"github.com/kkkunny/pokemon/src/system/world/sprite"
+	"github.com/kkkunny/pokemon/src/system/world/sprite/person"
+	"github.com/kkkunny/pokemon/src/util/draw"
+)
+
+// 传送的阶段
+type warpStage uint8
+
+const (
+	warpStageDoorOpen  warpStage = iota // 打开出发处的门
+	warpStageFadeOut                    // 淡出
+	warpStageFadeIn                     // 切换地图后淡入
+	warpStageWalkOut                    // 走出到达处
+	warpStageDoorClose                  // 关上到达处的门
+)
+
+const (
+	warpFadeTicks = 20 // 淡出或淡入的帧数
+	warpDoorTicks = 12 // 开门或关门的帧数
+)
+
+// 进行中的传送
+type warping struct {
+	warp    *world.Warp
+	stage   warpStage
+	counter int
+	walked  bool   // 是否已开始走出
+	door    [2]int // 到达处的门
+	hasDoor bool   // 到达处是否为门
+}
+
+func (w *warping) setStage(stage warpStage) {
+	w.stage, w.counter = stage, 0
+}
+
+// 传送过渡，覆盖在地图上，期间不接受按键
+type warpScreen struct {
+	*System
+}
+
+func (s warpScreen) Active() bool {
+	return s.warping != nil
+}
+
+func (s warpScreen) Overlay() bool {
+	return true
+}
+
+func (s warpScreen) OnAction(_ input.KeyInputAction) error {
+	return nil
+}
+
+func (s warpScreen) OnUpdate() error {
+	err := worldScreen{s.System}.OnUpdate()
+	if err != nil || s.warping == nil {
+		return err
+	}
+
+	w := s.warping
+	w.counter++
+	switch w.stage {
+	case warpStageDoorOpen:
+		x, y := s.self.Position()
+		s.world.SetDoor(x, y, float64(w.counter)/warpDoorTicks)
+		if w.counter >= warpDoorTicks {
+			w.setStage(warpStageFadeOut)
+		}
+	case warpStageFadeOut:
+		if w.counter >= warpFadeTicks {
+			return s.arriveWarp()
+		}
+	case warpStageFadeIn:
+		if w.counter >= warpFadeTicks {
+			w.setStage(warpStageWalkOut)
+		}
+	case warpStageWalkOut:
+		if s.self.Busying() {
+			return nil
+		}
+		if w.warp.WalkOut && !w.walked {
+			w.walked = true
+			d := s.self.Direction()
+			x, y := s.self.Position()
+			if x, y = person.GetNextPositionByDirection(d, x, y); !s.world.CheckCollision(d, x, y) {
+				s.self.SetNextStepDirection(d)
+				return nil
+			}
+		}
+		if !w.hasDoor {
+			s.warping = nil
+			return nil
+		}
+		w.setStage(warpStageDoorClose)
+	case warpStageDoorClose:
+		s.world.SetDoor(w.door[0], w.door[1], 1-float64(w.counter)/warpDoorTicks)
+		if w.counter >= warpDoorTicks {
+			s.warping = nil
+		}
+	}
+	return nil
+}
+
+func (s warpScreen) OnDraw(drawer draw.OptionDrawer) error {
+	if s.warping == nil {
+		return nil
+	}
+	var dark float64
+	switch s.warping.stage {
+	case warpStageFadeOut:
+		dark = float64(s.warping.counter) / warpFadeTicks
+	case warpStageFadeIn:
+		dark = 1 - float64(s.warping.counter)/warpFadeTicks
+	default:
+		return nil
+	}
+	draw.OverlayColor(drawer, color.NRGBA{A: uint8(min(max(dark, 0), 1) * 255)})
+	return nil
+}
+
+// OnWarp 主角走上传送点，开始传送过渡
+func (s *System) OnWarp(warp *world.Warp) error {
+	s.warping = &warping{warp: warp}
+	s.screens.Push(warpScreen{s})
+
+	err := s.playWarpSound(warp.Sound)
+	if err != nil {
+		return err
+	}
+	switch warp.Transition {
+	case world.WarpTransitionEnum.None:
+		return s.arriveWarp()
+	case world.WarpTransitionEnum.Door:
+		s.warping.setStage(warpStageDoorOpen)
+	default:
+		s.warping.setStage(warpStageFadeOut)
+	}
+	return nil
+}
+
+// 切换到目标地图，到达处为门且需要走出时先打开门
+func (s *System) arriveWarp() error {
+	w := s.warping
+	s.world.SetDoor(0, 0, 0)
+	err := s.world.Warp([]sprite.Sprite{s.self}, w.warp.Map, w.warp.ToX, w.warp.ToY)
+	if err != nil {
+		return err
+	}
+	if w.warp.Facing != 0 {
+		s.self.SetDirection(w.warp.Facing)
+	}
+	if arrival, ok := s.world.CurrentMap().GetWarp(w.warp.ToX, w.warp.ToY); ok && w.warp.WalkOut && arrival.Transition == world.WarpTransitionEnum.Door {
+		w.door, w.hasDoor = [2]int{w.warp.ToX, w.warp.ToY}, true
+		s.world.SetDoor(w.warp.ToX, w.warp.ToY, 1)
+	}
+	if w.warp.Transition == world.WarpTransitionEnum.None {
+		w.setStage(warpStageWalkOut)
+	} else {
+		w.setStage(warpStageFadeIn)
+	}
+	return nil
+}
+
+// 播放传送音效，音效文件不存在时不播放
+func (s *System) playWarpSound(name string) error {
+	if name == "" {
+		return nil
+	}
+	err := s.PlaySound(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
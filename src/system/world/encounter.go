@@ -0,0 +1,186 @@
+package world
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lafriks/go-tiled"
+	"github.com/tnnmigga/enum"
+
+	"github.com/kkkunny/pokemon/src/config"
+)
+
+// 未配置时每走一步的遭遇概率（百分比）
+const defaultEncounterRate = 10
+
+// EncounterMethod 遭遇方式
+type EncounterMethod string
+
+var EncounterMethodEnum = enum.New[struct {
+	Grass   EncounterMethod `enum:"grass"`   // 草丛
+	Surf    EncounterMethod `enum:"surf"`    // 冲浪
+	Fishing EncounterMethod `enum:"fishing"` // 钓鱼
+}]()
+
+// TimeOfDay 时段
+type TimeOfDay string
+
+var TimeOfDayEnum = enum.New[struct {
+	Any     TimeOfDay `enum:"any"`     // 任意时段
+	Morning TimeOfDay `enum:"morning"` // 早晨 4:00~10:00
+	Day     TimeOfDay `enum:"day"`     // 白天 10:00~18:00
+	Night   TimeOfDay `enum:"night"`   // 夜晚 18:00~4:00
+}]()
+
+// GetTimeOfDay 获取某时刻所处的时段
+func GetTimeOfDay(t time.Time) TimeOfDay {
+	switch hour := t.Hour(); {
+	case 4 <= hour && hour < 10:
+		return TimeOfDayEnum.Morning
+	case 10 <= hour && hour < 18:
+		return TimeOfDayEnum.Day
+	default:
+		return TimeOfDayEnum.Night
+	}
+}
+
+// EncounterEntry 遭遇表中的一项
+type EncounterEntry struct {
+	Method   EncounterMethod
+	Time     TimeOfDay
+	Species  int16
+	MinLevel int
+	MaxLevel int
+	Weight   int
+}
+
+// Encounter 一次遭遇的结果
+type Encounter struct {
+	Site    string // 战斗场地
+	Species int16  // 图鉴编号
+	Level   int    // 等级
+}
+
+// EncounterTable 遭遇表
+// 在Tiled中通过对象或地图属性声明：
+//   - encounter_rate: 每走一步的遭遇概率（百分比），为0时走路不会遭遇，但仍可以钓鱼
+//   - encounters: 以分号分隔的多项，每项格式为 `方式 时段 图鉴编号 最低等级-最高等级 权重`，如 `grass any 1 2-5 50`
+type EncounterTable struct {
+	Rate    int
+	Entries []EncounterEntry
+}
+
+func parseEncounterEntry(s string) (EncounterEntry, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 5 {
+		return EncounterEntry{}, fmt.Errorf("expect 5 fields, got %d", len(fields))
+	}
+	entry := EncounterEntry{
+		Method: EncounterMethod(fields[0]),
+		Time:   TimeOfDay(fields[1]),
+	}
+	if !slices.Contains(enum.Values[EncounterMethod](EncounterMethodEnum), entry.Method) {
+		return EncounterEntry{}, fmt.Errorf("unknown encounter method `%s`", fields[0])
+	}
+	if !slices.Contains(enum.Values[TimeOfDay](TimeOfDayEnum), entry.Time) {
+		return EncounterEntry{}, fmt.Errorf("unknown time of day `%s`", fields[1])
+	}
+	species, err := strconv.ParseInt(fields[2], 10, 16)
+	if err != nil || species <= 0 {
+		return EncounterEntry{}, fmt.Errorf("invalid species `%s`", fields[2])
+	}
+	entry.Species = int16(species)
+	minLevel, maxLevel, ok := strings.Cut(fields[3], "-")
+	if !ok {
+		maxLevel = minLevel
+	}
+	entry.MinLevel, err = strconv.Atoi(minLevel)
+	if err != nil {
+		return EncounterEntry{}, fmt.Errorf("invalid level `%s`", fields[3])
+	}
+	entry.MaxLevel, err = strconv.Atoi(maxLevel)
+	if err != nil || entry.MinLevel < 1 || entry.MaxLevel < entry.MinLevel {
+		return EncounterEntry{}, fmt.Errorf("invalid level `%s`", fields[3])
+	}
+	entry.Weight, err = strconv.Atoi(fields[4])
+	if err != nil || entry.Weight <= 0 {
+		return EncounterEntry{}, fmt.Errorf("invalid weight `%s`", fields[4])
+	}
+	return entry, nil
+}
+
+// ParseEncounterTable 从对象属性和地图属性中解析遭遇表，对象属性优先
+func ParseEncounterTable(props ...tiled.Properties) (*EncounterTable, error) {
+	table := &EncounterTable{Rate: defaultEncounterRate}
+	for i := len(props) - 1; i >= 0; i-- {
+		if values := props[i].Get("encounter_rate"); len(values) > 0 {
+			rate, err := strconv.Atoi(values[0])
+			if err != nil || rate < 0 || rate > 100 {
+				return nil, fmt.Errorf("encounter_rate: expect 0~100, got `%s`", values[0])
+			}
+			table.Rate = rate
+		}
+		s := props[i].GetString("encounters")
+		if s == "" {
+			continue
+		}
+		table.Entries = table.Entries[:0]
+		for _, item := range strings.Split(s, ";") {
+			if strings.TrimSpace(item) == "" {
+				continue
+			}
+			entry, err := parseEncounterEntry(item)
+			if err != nil {
+				return nil, fmt.Errorf("encounters `%s`: %w", strings.TrimSpace(item), err)
+			}
+			table.Entries = append(table.Entries, entry)
+		}
+	}
+	return table, nil
+}
+
+// Roll 按权重抽取一项，没有符合条件的项时返回false
+func (t *EncounterTable) Roll(r *rand.Rand, method EncounterMethod, tod TimeOfDay) (int16, int, bool) {
+	candidates := make([]EncounterEntry, 0, len(t.Entries))
+	var totalWeight int
+	for _, entry := range t.Entries {
+		if entry.Method != method || (entry.Time != TimeOfDayEnum.Any && entry.Time != tod) {
+			continue
+		}
+		candidates = append(candidates, entry)
+		totalWeight += entry.Weight
+	}
+	if totalWeight == 0 {
+		return 0, 0, false
+	}
+	n := r.IntN(totalWeight)
+	for _, entry := range candidates {
+		if n < entry.Weight {
+			return entry.Species, entry.MinLevel + r.IntN(entry.MaxLevel-entry.MinLevel+1), true
+		}
+		n -= entry.Weight
+	}
+	return 0, 0, false
+}
+
+// 遭遇区域
+type encounterRegion struct {
+	object *tiled.Object
+	table  *EncounterTable
+}
+
+// 地块是否完整位于区域内
+func (r *encounterRegion) contain(x, y int) bool {
+	px, py := float64(x*config.TileSize), float64(y*config.TileSize)
+	return px >= r.object.X && px+config.TileSize <= r.object.X+r.object.Width &&
+		py >= r.object.Y && py+config.TileSize <= r.object.Y+r.object.Height
+}
+
+// 战斗场地
+func (r *encounterRegion) site() string {
+	return r.object.Properties.GetString("battle_site")
+}
@@ -0,0 +1,217 @@
+package world
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/lafriks/go-tiled"
+
+	"github.com/kkkunny/pokemon/src/util"
+)
+
+func TestParseEncounterEntry(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		entry EncounterEntry
+		err   bool
+	}{
+		{name: "range", s: "grass any 1 2-5 50", entry: EncounterEntry{Method: EncounterMethodEnum.Grass, Time: TimeOfDayEnum.Any, Species: 1, MinLevel: 2, MaxLevel: 5, Weight: 50}},
+		{name: "single_level", s: " fishing night 129 5 10 ", entry: EncounterEntry{Method: EncounterMethodEnum.Fishing, Time: TimeOfDayEnum.Night, Species: 129, MinLevel: 5, MaxLevel: 5, Weight: 10}},
+		{name: "missing_field", s: "grass any 1 2-5", err: true},
+		{name: "extra_field", s: "grass any 1 2-5 50 1", err: true},
+		{name: "unknown_method", s: "headbutt any 1 2-5 50", err: true},
+		{name: "unknown_time", s: "grass noon 1 2-5 50", err: true},
+		{name: "species_zero", s: "grass any 0 2-5 50", err: true},
+		{name: "species_not_number", s: "grass any bulbasaur 2-5 50", err: true},
+		{name: "level_zero", s: "grass any 1 0-5 50", err: true},
+		{name: "level_reversed", s: "grass any 1 5-2 50", err: true},
+		{name: "level_not_number", s: "grass any 1 2-x 50", err: true},
+		{name: "weight_zero", s: "grass any 1 2-5 0", err: true},
+		{name: "weight_not_number", s: "grass any 1 2-5 half", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := parseEncounterEntry(tt.s)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("parsed %+v, want error", entry)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry != tt.entry {
+				t.Fatalf("got %+v, want %+v", entry, tt.entry)
+			}
+		})
+	}
+}
+
+func TestParseEncounterTable(t *testing.T) {
+	props := func(rate, encounters string) tiled.Properties {
+		var p tiled.Properties
+		if rate != "" {
+			p = append(p, &tiled.Property{Name: "encounter_rate", Type: "int", Value: rate})
+		}
+		if encounters != "" {
+			p = append(p, &tiled.Property{Name: "encounters", Value: encounters})
+		}
+		return p
+	}
+	tests := []struct {
+		name             string
+		object, mapProps tiled.Properties
+		rate             int
+		species          []int16 // 各项的图鉴编号
+		err              bool
+	}{
+		{name: "default_rate", object: props("", "grass any 1 2-5 50"), rate: defaultEncounterRate, species: []int16{1}},
+		{name: "map_only", mapProps: props("20", "grass any 1 2-5 50; surf any 7 5 1"), rate: 20, species: []int16{1, 7}},
+		{name: "object_overrides_map", object: props("5", "grass any 16 2 1"), mapProps: props("20", "grass any 1 2-5 50; surf any 7 5 1"), rate: 5, species: []int16{16}},
+		{name: "object_rate_only", object: props("5", ""), mapProps: props("20", "grass any 1 2-5 50"), rate: 5, species: []int16{1}},
+		{name: "object_entries_only", object: props("", "grass any 16 2 1"), mapProps: props("20", "grass any 1 2-5 50"), rate: 20, species: []int16{16}},
+		{name: "empty_items", object: props("", "grass any 1 2 1;; surf any 7 5 1;"), rate: defaultEncounterRate, species: []int16{1, 7}},
+		{name: "rate_zero", object: props("0", "fishing any 129 5 1"), mapProps: props("20", ""), rate: 0, species: []int16{129}},
+		{name: "rate_max", object: props("100", ""), rate: 100},
+		{name: "rate_over", object: props("101", ""), err: true},
+		{name: "rate_negative", object: props("-1", ""), err: true},
+		{name: "rate_not_number", object: props("often", ""), err: true},
+		{name: "bad_entry", object: props("", "grass any 1 2-5 50; grass any 1"), err: true},
+		{name: "bad_map_entry", object: props("", "grass any 1 2-5 50"), mapProps: props("", "grass"), err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := ParseEncounterTable(tt.object, tt.mapProps)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("parsed %+v, want error", table)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if table.Rate != tt.rate {
+				t.Fatalf("rate %d, want %d", table.Rate, tt.rate)
+			}
+			if len(table.Entries) != len(tt.species) {
+				t.Fatalf("entries %+v, want species %v", table.Entries, tt.species)
+			}
+			for i, entry := range table.Entries {
+				if entry.Species != tt.species[i] {
+					t.Fatalf("entries %+v, want species %v", table.Entries, tt.species)
+				}
+			}
+		})
+	}
+}
+
+func TestRoll(t *testing.T) {
+	table := &EncounterTable{Entries: []EncounterEntry{
+		{Method: EncounterMethodEnum.Grass, Time: TimeOfDayEnum.Any, Species: 1, MinLevel: 2, MaxLevel: 4, Weight: 1},
+		{Method: EncounterMethodEnum.Grass, Time: TimeOfDayEnum.Any, Species: 2, MinLevel: 10, MaxLevel: 10, Weight: 3},
+		{Method: EncounterMethodEnum.Grass, Time: TimeOfDayEnum.Night, Species: 3, MinLevel: 10, MaxLevel: 10, Weight: 4},
+		{Method: EncounterMethodEnum.Fishing, Time: TimeOfDayEnum.Any, Species: 129, MinLevel: 5, MaxLevel: 5, Weight: 1},
+	}}
+	tests := []struct {
+		name    string
+		method  EncounterMethod
+		tod     TimeOfDay
+		weights map[int16]int // 各图鉴编号的权重，为空时没有可遭遇的项
+	}{
+		{name: "day", method: EncounterMethodEnum.Grass, tod: TimeOfDayEnum.Day, weights: map[int16]int{1: 1, 2: 3}},
+		{name: "night", method: EncounterMethodEnum.Grass, tod: TimeOfDayEnum.Night, weights: map[int16]int{1: 1, 2: 3, 3: 4}},
+		{name: "fishing", method: EncounterMethodEnum.Fishing, tod: TimeOfDayEnum.Night, weights: map[int16]int{129: 1}},
+		{name: "no_entry", method: EncounterMethodEnum.Surf, tod: TimeOfDayEnum.Day},
+	}
+	const rolls = 8000
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := rand.New(rand.NewPCG(1, 0))
+			counts := make(map[int16]int)
+			levels := make(map[int]bool)
+			for range rolls {
+				species, level, ok := table.Roll(r, tt.method, tt.tod)
+				if !ok {
+					if len(tt.weights) > 0 {
+						t.Fatal("no encounter")
+					}
+					return
+				}
+				counts[species]++
+				if species == 1 {
+					levels[level] = true
+				}
+			}
+			if len(tt.weights) == 0 {
+				t.Fatalf("encountered %v, want none", counts)
+			}
+			var total int
+			for _, w := range tt.weights {
+				total += w
+			}
+			for species, count := range counts {
+				w, ok := tt.weights[species]
+				if !ok {
+					t.Fatalf("encountered species %d, want one of %v", species, tt.weights)
+				}
+				// 允许与期望次数相差5%
+				want := rolls * w / total
+				if diff := count - want; diff < -rolls/20 || diff > rolls/20 {
+					t.Fatalf("species %d encountered %d times, want about %d", species, count, want)
+				}
+			}
+			// 等级在范围内均匀抽取，包含两端
+			if _, ok := counts[1]; ok && (len(levels) != 3 || !levels[2] || !levels[4]) {
+				t.Fatalf("levels %v, want 2~4", levels)
+			}
+		})
+	}
+}
+
+func TestGetTimeOfDay(t *testing.T) {
+	tests := []struct {
+		hour, minute int
+		tod          TimeOfDay
+	}{
+		{hour: 3, minute: 59, tod: TimeOfDayEnum.Night},
+		{hour: 4, tod: TimeOfDayEnum.Morning},
+		{hour: 9, minute: 59, tod: TimeOfDayEnum.Morning},
+		{hour: 10, tod: TimeOfDayEnum.Day},
+		{hour: 18, tod: TimeOfDayEnum.Night},
+		{hour: 0, tod: TimeOfDayEnum.Night},
+	}
+	for _, tt := range tests {
+		if got := GetTimeOfDay(time.Date(2024, 1, 1, tt.hour, tt.minute, 0, 0, time.UTC)); got != tt.tod {
+			t.Errorf("%02d:%02d: got %s, want %s", tt.hour, tt.minute, got, tt.tod)
+		}
+	}
+}
+
+func TestCanFish(t *testing.T) {
+	w := newTestWorld(t, "main", map[string]testMap{"main": {rows: []string{
+		"~~~",
+		"...",
+		"..#",
+	}}})
+	tests := []struct {
+		pos [2]int
+		d   util.Direction
+		ok  bool
+	}{
+		{pos: [2]int{1, 1}, d: util.DirectionEnum.Up, ok: true},
+		{pos: [2]int{1, 1}, d: util.DirectionEnum.Down},
+		{pos: [2]int{1, 2}, d: util.DirectionEnum.Right},
+		// 地图外
+		{pos: [2]int{1, 0}, d: util.DirectionEnum.Up},
+	}
+	for _, tt := range tests {
+		w.selfPos = tt.pos
+		if got := w.CanFish(tt.d); got != tt.ok {
+			t.Errorf("at %v facing %s: got %v, want %v", tt.pos, tt.d, got, tt.ok)
+		}
+	}
+}
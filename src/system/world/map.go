@@ -2,18 +2,24 @@ package world
 
 import (
 	"errors"
+	"fmt"
 	"image"
-	"path/filepath"
+	"path"
+	"strings"
 	"time"
 
 	stlslices "github.com/kkkunny/stl/container/slices"
+	stlval "github.com/kkkunny/stl/value"
 	"github.com/lafriks/go-tiled"
 	"github.com/tnnmigga/enum"
 
+	"github.com/kkkunny/pokemon/src/assets"
 	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/state"
 	"github.com/kkkunny/pokemon/src/system/context"
 	render2 "github.com/kkkunny/pokemon/src/system/world/render"
 	"github.com/kkkunny/pokemon/src/system/world/sprite"
+	"github.com/kkkunny/pokemon/src/system/world/sprite/item"
 	"github.com/kkkunny/pokemon/src/util"
 	"github.com/kkkunny/pokemon/src/util/draw"
 )
@@ -25,6 +31,12 @@ var ObjectLayerTypeEnum = enum.New[struct {
 	Split  ObjectLayerType `enum:"split"`
 }]()
 
+// 可被打败的精灵，如训练师
+type defeatable interface {
+	DefeatedFlag() string
+	SetDefeatedFlag(flag string)
+}
+
 type Map struct {
 	ctx          context.Context
 	id           string
@@ -32,6 +44,11 @@ type Map struct {
 	tileCache    *render2.TileCache
 	songFilepath string
 	sprites      []sprite.Sprite
+	conditions   map[any]state.Condition // 精灵和传送点的出现条件
+	encounters   []*encounterRegion      // 遭遇区域
+	warps        []*Warp                 // 传送点
+	walkGrid     *walkGrid               // 可通行网格，精灵移动后失效
+	world        *World                  // 所在世界，由世界载入时设置
 }
 
 func NewMap(ctx context.Context, tileCache *render2.TileCache, id string) (*Map, error) {
@@ -46,7 +63,7 @@ func newMapWithAdjacent(ctx context.Context, tileCache *render2.TileCache, id st
 	}
 
 	// 地图
-	mapTMX, err := tiled.LoadFile(filepath.Join(config.MapsPath, id+".tmx"))
+	mapTMX, err := tiled.LoadFile(path.Join(config.MapsPath, id+".tmx"), tiled.WithFileSystem(assets.FS()))
 	if err != nil {
 		return nil, err
 	}
@@ -55,10 +72,11 @@ func newMapWithAdjacent(ctx context.Context, tileCache *render2.TileCache, id st
 	}
 
 	curMap = &Map{
-		ctx:       ctx,
-		id:        id,
-		define:    mapTMX,
-		tileCache: tileCache,
+		ctx:        ctx,
+		id:         id,
+		define:     mapTMX,
+		tileCache:  tileCache,
+		conditions: make(map[any]state.Condition),
 	}
 	existMap[id] = curMap
 
@@ -66,7 +84,7 @@ func newMapWithAdjacent(ctx context.Context, tileCache *render2.TileCache, id st
 	if mapTMX.Properties != nil {
 		songFileName := mapTMX.Properties.GetString("song")
 		if songFileName != "" {
-			curMap.songFilepath = filepath.Join(config.VoicePath, "map", songFileName)
+			curMap.songFilepath = path.Join(config.VoicePath, "map", songFileName)
 		}
 	}
 
@@ -83,11 +101,77 @@ func newMapWithAdjacent(ctx context.Context, tileCache *render2.TileCache, id st
 			}
 			spriteObj.SetPosition(x, y)
 			curMap.sprites = append(curMap.sprites, spriteObj)
+			var extra []string
+			if itemSprite, ok := spriteObj.(item.Item); ok && itemSprite.ActionType() == sprite.ActionTypeEnum.PickUp {
+				// 拾取后不再出现
+				if itemSprite.CollectFlag() == "" {
+					itemSprite.SetCollectFlag(fmt.Sprintf("picked.%s.%d", id, object.ID))
+				}
+				extra = append(extra, "!"+itemSprite.CollectFlag())
+			}
+			if trainer, ok := spriteObj.(defeatable); ok && trainer.DefeatedFlag() == "" {
+				// 战败后不再挑战
+				trainer.SetDefeatedFlag(fmt.Sprintf("trainer.%s.%d", id, object.ID))
+			}
+			err = curMap.parseCondition(spriteObj, object, extra...)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, objectGroup := range mapTMX.ObjectGroups {
+		if objectGroup.Class != ObjectLayerTypeEnum.Split {
+			continue
+		}
+		for _, object := range objectGroup.Objects {
+			switch object.Type {
+			case "warp", "hole":
+				// 传送点
+				warp, err := parseWarp(object)
+				if err != nil {
+					return nil, fmt.Errorf("map `%s`: %w", id, err)
+				}
+				curMap.warps = append(curMap.warps, warp)
+				err = curMap.parseCondition(warp, object)
+				if err != nil {
+					return nil, err
+				}
+			case "gunting_ground":
+				// 遭遇区域
+				table, err := ParseEncounterTable(object.Properties, stlval.DerefPtrOr(mapTMX.Properties))
+				if err != nil {
+					return nil, fmt.Errorf("map `%s` object %d: %w", id, object.ID, err)
+				}
+				curMap.encounters = append(curMap.encounters, &encounterRegion{object: object, table: table})
+			}
 		}
 	}
 	return curMap, nil
 }
 
+// 解析对象的出现条件
+// condition 为完整条件，visible_if_flag 为标记名的简写，两者同时存在时都需要满足
+// extra 为额外需要满足的条件项
+func (m *Map) parseCondition(key any, object *tiled.Object, extra ...string) error {
+	terms := append([]string{object.Properties.GetString("condition"), object.Properties.GetString("visible_if_flag")}, extra...)
+	expr := strings.Join(stlslices.Filter(terms, func(_ int, s string) bool { return s != "" }), "&&")
+	cond, err := state.ParseCondition(expr)
+	if err != nil {
+		return fmt.Errorf("map `%s` object %d: %w", m.id, object.ID, err)
+	}
+	if !cond.Empty() {
+		m.conditions[key] = cond
+	}
+	return nil
+}
+
+// 对象当前是否出现
+func (m *Map) visible(key any) bool {
+	cond, ok := m.conditions[key]
+	return !ok || cond.Eval(m.ctx.State())
+}
+
 func (m *Map) getSpriteLayerName() string {
 	var layerName string
 	for _, layer := range m.define.Layers {
@@ -155,7 +239,12 @@ func (m *Map) SongFilepath() (string, bool) {
 }
 
 func (m *Map) CheckCollision(d util.Direction, x, y int) bool {
-	for _, s := range m.sprites {
+	return m.checkCollision(d, x, y, false)
+}
+
+// self为是否为主角移动，部分地形只有主角可以进入
+func (m *Map) checkCollision(d util.Direction, x, y int, self bool) bool {
+	for _, s := range m.Sprites() {
 		if !s.Collision() {
 			continue
 		}
@@ -164,23 +253,62 @@ func (m *Map) CheckCollision(d util.Direction, x, y int) bool {
 			return true
 		}
 	}
-	for _, layer := range m.define.Layers {
-		index := y*m.define.Width + x
-		tile := layer.Tiles[index]
-		if tile.Tileset == nil {
+	return m.checkTileCollision(d, x, y, self)
+}
+
+// 地块本身是否阻挡从方向d进入
+func (m *Map) checkTileCollision(d util.Direction, x, y int, self bool) bool {
+	var collision bool
+	m.foreachTileDef(x, y, func(_ int, tileDef *tiled.TilesetTile) bool {
+		if tileDef.Properties.GetBool("collision") {
+			collision = true
+		} else if terrain, ok := terrains[tileDef.Properties.GetString("terrain")]; ok && !terrain.Passable(m.terrainInfo(d, x, y, tileDef, self)) {
+			collision = true
+		} else if allowDirectionStr := tileDef.Properties.GetString("allow_direction"); allowDirectionStr != "" {
+			collision = d != -util.ParseDirection(allowDirectionStr)
+		} else {
+			return true
+		}
+		return false
+	})
+	return collision
+}
+
+// 遍历地块上各图层的地块定义，fn返回false时停止
+func (m *Map) foreachTileDef(x, y int, fn func(layer int, tileDef *tiled.TilesetTile) bool) {
+	if x < 0 || y < 0 || x >= m.define.Width || y >= m.define.Height {
+		return
+	}
+	for i, layer := range m.define.Layers {
+		tile := layer.Tiles[y*m.define.Width+x]
+		if tile == nil || tile.Tileset == nil {
 			continue
 		}
 		tileDef, err := tile.Tileset.GetTilesetTile(tile.ID)
 		if err != nil {
 			continue
 		}
-		if tileDef.Properties.GetBool("collision") {
-			return true
-		} else if allowDirectionStr := tileDef.Properties.GetString("allow_direction"); allowDirectionStr != "" {
-			return d != -util.ParseDirection(allowDirectionStr)
+		if !fn(i, tileDef) {
+			return
+		}
+	}
+}
+
+// 重新绘制精灵所在或正在走入的地块上遮挡精灵的地形
+func (m *Map) drawTerrainOverlay(drawer draw.OptionDrawer, x, y int, dur time.Duration) error {
+	renderer := render2.NewRenderer(m.define, m.tileCache, dur)
+	objectLayerName := m.getSpriteLayerName()
+	for _, t := range m.getTerrains(0, x, y, false) {
+		overlay, ok := t.terrain.(TerrainOverlay)
+		if !ok || m.define.Layers[t.layer].Name > objectLayerName {
+			continue
+		}
+		err := renderer.RenderTilePart(drawer, t.layer, x, y, overlay.Overlay(t.info))
+		if err != nil {
+			return err
 		}
 	}
-	return false
+	return nil
 }
 
 func (m *Map) AdjacentMaps() map[util.Direction]string {
@@ -195,12 +323,15 @@ func (m *Map) AdjacentMaps() map[util.Direction]string {
 	return maps
 }
 
+// Sprites 当前出现的精灵
 func (m *Map) Sprites() []sprite.Sprite {
-	return m.sprites
+	return stlslices.Filter(m.sprites, func(_ int, s sprite.Sprite) bool {
+		return m.visible(s)
+	})
 }
 
 func (m *Map) GetSpriteByPosition(x, y int) (sprite.Sprite, bool) {
-	for _, s := range m.sprites {
+	for _, s := range m.Sprites() {
 		sx, sy := s.Position()
 		if sx == x && sy == y {
 			return s, true
@@ -209,14 +340,24 @@ func (m *Map) GetSpriteByPosition(x, y int) (sprite.Sprite, bool) {
 	return nil, false
 }
 
-func (m *Map) GetHoles() []*tiled.Object {
-	splitLayers := stlslices.Filter(m.define.ObjectGroups, func(_ int, og *tiled.ObjectGroup) bool {
-		return og.Class == ObjectLayerTypeEnum.Split
+// GetWarps 当前可通过的传送点
+func (m *Map) GetWarps() []*Warp {
+	return stlslices.Filter(m.warps, func(_ int, w *Warp) bool {
+		return m.visible(w)
 	})
-	return stlslices.FlatMap(splitLayers, func(_ int, ob *tiled.ObjectGroup) []*tiled.Object {
-		return stlslices.Filter(ob.Objects, func(_ int, o *tiled.Object) bool {
-			return o.Type == "hole"
-		})
+}
+
+// GetWarp 获取地块上当前可通过的传送点
+func (m *Map) GetWarp(x, y int) (*Warp, bool) {
+	return stlslices.FindFirst(m.GetWarps(), func(_ int, w *Warp) bool {
+		return w.X == x && w.Y == y
+	})
+}
+
+// 获取地块所在的遭遇区域
+func (m *Map) getEncounterRegion(x, y int) (*encounterRegion, bool) {
+	return stlslices.FindFirst(m.encounters, func(_ int, r *encounterRegion) bool {
+		return r.contain(x, y)
 	})
 }
 
@@ -0,0 +1,223 @@
+package world
+
+import (
+	"container/heap"
+	"image"
+	"slices"
+
+	"github.com/kkkunny/pokemon/src/util"
+)
+
+const defaultMaxPathNodes = 4096 // 默认最多展开的地块数
+
+// 寻路时尝试的方向，顺序固定以保证结果确定
+var pathDirections = []util.Direction{util.DirectionEnum.Up, util.DirectionEnum.Down, util.DirectionEnum.Left, util.DirectionEnum.Right}
+
+// 可通行网格
+type walkGrid struct {
+	width, height int
+	tiles         []uint8         // 各地块从各方向进入时是否被阻挡，按方向记录为位
+	sprites       [][2]int        // 计算时精灵的碰撞位置，变化后重新计算
+	occupied      map[[2]int]bool // 被精灵占据的地块
+}
+
+func directionBit(d util.Direction) uint8 {
+	switch d {
+	case util.DirectionEnum.Up:
+		return 1
+	case util.DirectionEnum.Down:
+		return 1 << 1
+	case util.DirectionEnum.Left:
+		return 1 << 2
+	case util.DirectionEnum.Right:
+		return 1 << 3
+	default:
+		return 0
+	}
+}
+
+// 沿方向d进入地块是否被阻挡
+func (g *walkGrid) blocked(d util.Direction, x, y int) bool {
+	if x < 0 || y < 0 || x >= g.width || y >= g.height {
+		return true
+	}
+	return g.tiles[y*g.width+x]&directionBit(d) != 0 || g.occupied[[2]int{x, y}]
+}
+
+// 当前出现的精灵的碰撞位置
+func (m *Map) spriteCollisionPositions() [][2]int {
+	var positions [][2]int
+	for _, s := range m.Sprites() {
+		if !s.Collision() {
+			continue
+		}
+		x, y := s.CollisionPosition()
+		positions = append(positions, [2]int{x, y})
+	}
+	return positions
+}
+
+// 获取可通行网格，地块部分只计算一次，精灵移动或出现情况变化后重新计算精灵部分
+func (m *Map) getWalkGrid() *walkGrid {
+	if m.walkGrid == nil {
+		width, height := m.Size()
+		grid := &walkGrid{width: width, height: height, tiles: make([]uint8, width*height)}
+		for y := range height {
+			for x := range width {
+				for _, d := range pathDirections {
+					if m.checkTileCollision(d, x, y, false) {
+						grid.tiles[y*width+x] |= directionBit(d)
+					}
+				}
+			}
+		}
+		m.walkGrid = grid
+	}
+
+	positions := m.spriteCollisionPositions()
+	if m.walkGrid.occupied == nil || !slices.Equal(positions, m.walkGrid.sprites) {
+		m.walkGrid.sprites = positions
+		m.walkGrid.occupied = make(map[[2]int]bool, len(positions))
+		for _, pos := range positions {
+			m.walkGrid.occupied[pos] = true
+		}
+	}
+	return m.walkGrid
+}
+
+// WalkGridVersion 可通行网格的版本，变化后之前寻得的路线需要重新计算
+// 精灵的位置不计入版本，由寻路方在下一步被阻挡时重新寻路
+func (w *World) WalkGridVersion() int {
+	return w.gridVersion
+}
+
+// PathOptions 寻路设置
+type PathOptions struct {
+	Bounds       image.Rectangle // 限制在该范围内寻路，坐标相对当前地图，为空时不限制
+	MaxNodes     int             // 最多展开的地块数，为0时使用默认值
+	StopAdjacent bool            // 走到终点相邻的地块即可，用于走向人物
+}
+
+// FindPath 寻找从from走到to的路线，坐标为相对当前地图的地块坐标，可以经过已载入的相邻地图
+// 主角所在地块视为阻挡，起点除外
+func (w *World) FindPath(from, to [2]int, opts PathOptions) ([]util.Direction, bool) {
+	maxNodes := opts.MaxNodes
+	if maxNodes <= 0 {
+		maxNodes = defaultMaxPathNodes
+	}
+	goal := func(pos [2]int) bool {
+		if !opts.StopAdjacent {
+			return pos == to
+		}
+		return abs(pos[0]-to[0])+abs(pos[1]-to[1]) == 1
+	}
+	if goal(from) {
+		return nil, true
+	}
+
+	grids := make(map[*Map]*walkGrid)
+	blocked := func(d util.Direction, pos [2]int) bool {
+		if !opts.Bounds.Empty() && !image.Pt(pos[0], pos[1]).In(opts.Bounds) {
+			return true
+		} else if pos == w.selfPos {
+			return true
+		}
+		m, x, y, ok := w.GetActualPosition(pos[0], pos[1])
+		if !ok {
+			return true
+		}
+		grid, ok := grids[m]
+		if !ok {
+			grid = m.getWalkGrid()
+			grids[m] = grid
+		}
+		return grid.blocked(d, x, y)
+	}
+	heuristic := func(pos [2]int) int {
+		return abs(pos[0]-to[0]) + abs(pos[1]-to[1])
+	}
+
+	type step struct {
+		prev      [2]int
+		direction util.Direction
+	}
+	came := make(map[[2]int]step)
+	cost := map[[2]int]int{from: 0}
+	open := &pathQueue{}
+	heap.Push(open, &pathNode{pos: from, f: heuristic(from)})
+
+	for expanded := 0; open.Len() > 0 && expanded < maxNodes; expanded++ {
+		node := heap.Pop(open).(*pathNode)
+		if node.g > cost[node.pos] {
+			continue
+		}
+		if goal(node.pos) {
+			var path []util.Direction
+			for pos := node.pos; pos != from; pos = came[pos].prev {
+				path = append(path, came[pos].direction)
+			}
+			slices.Reverse(path)
+			return path, true
+		}
+		for _, d := range pathDirections {
+			dx, dy := d.Offset()
+			next := [2]int{node.pos[0] + dx, node.pos[1] + dy}
+			if blocked(d, next) {
+				continue
+			}
+			g := node.g + 1
+			if old, ok := cost[next]; ok && old <= g {
+				continue
+			}
+			cost[next] = g
+			came[next] = step{prev: node.pos, direction: d}
+			heap.Push(open, &pathNode{pos: next, g: g, f: g + heuristic(next), seq: open.seq})
+		}
+	}
+	return nil, false
+}
+
+// 寻路节点
+type pathNode struct {
+	pos [2]int
+	g   int // 起点到此的步数
+	f   int // 估计的总步数
+	seq int // 入队顺序，估计相同时先入队的优先
+}
+
+// 按估计步数排序的优先队列
+type pathQueue struct {
+	nodes []*pathNode
+	seq   int
+}
+
+func (q *pathQueue) Len() int {
+	return len(q.nodes)
+}
+
+func (q *pathQueue) Less(i, j int) bool {
+	a, b := q.nodes[i], q.nodes[j]
+	if a.f != b.f {
+		return a.f < b.f
+	}
+	return a.seq < b.seq
+}
+
+func (q *pathQueue) Swap(i, j int) {
+	q.nodes[i], q.nodes[j] = q.nodes[j], q.nodes[i]
+}
+
+func (q *pathQueue) Push(x any) {
+	q.nodes = append(q.nodes, x.(*pathNode))
+	q.seq++
+}
+
+func (q *pathQueue) Pop() any {
+	node := q.nodes[len(q.nodes)-1]
+	q.nodes = q.nodes[:len(q.nodes)-1]
+	return node
+}
+
+func abs(n int) int {
+	return max(n, -n)
+}
@@ -0,0 +1,217 @@
+package world
+
+import (
+	"image"
+	"testing"
+
+	"github.com/lafriks/go-tiled"
+
+	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/util"
+)
+
+// 测试用地块：墙、只能向右走入、只能向下走入、水面、向下跳的断崖、冰面、向右和向下的旋转地块
+var testTileset = &tiled.Tileset{Tiles: []*tiled.TilesetTile{
+	{ID: 0, Properties: tiled.Properties{{Name: "collision", Type: "bool", Value: "true"}}},
+	{ID: 1, Properties: tiled.Properties{{Name: "allow_direction", Value: "left"}}},
+	{ID: 2, Properties: tiled.Properties{{Name: "allow_direction", Value: "up"}}},
+	{ID: 3, Properties: tiled.Properties{{Name: "terrain", Value: "water"}}},
+	{ID: 4, Properties: tiled.Properties{{Name: "terrain", Value: "ledge"}, {Name: "direction", Value: "down"}}},
+	{ID: 5, Properties: tiled.Properties{{Name: "terrain", Value: "ice"}}},
+	{ID: 6, Properties: tiled.Properties{{Name: "terrain", Value: "spin"}, {Name: "direction", Value: "right"}}},
+	{ID: 7, Properties: tiled.Properties{{Name: "terrain", Value: "spin"}, {Name: "direction", Value: "down"}}},
+}}
+
+// 地图字符对应的地块，其他字符为空地
+var testTileIDs = map[rune]uint32{'#': 0, '<': 1, '^': 2, '~': 3, 'v': 4, '*': 5, 'R': 6, 'D': 7}
+
+// 测试地图，rows为各行地块，adjacent为相邻地图
+type testMap struct {
+	rows     []string
+	adjacent map[util.Direction]string
+}
+
+func newTestMap(t *testing.T, id string, tm testMap) *Map {
+	t.Helper()
+	width, height := len(tm.rows[0]), len(tm.rows)
+	layer := &tiled.Layer{Name: "0", Tiles: make([]*tiled.LayerTile, width*height)}
+	for y, row := range tm.rows {
+		if len(row) != width {
+			t.Fatalf("map %s: row %d has %d tiles, want %d", id, y, len(row), width)
+		}
+		for x, c := range row {
+			if tileID, ok := testTileIDs[c]; ok {
+				layer.Tiles[y*width+x] = &tiled.LayerTile{ID: tileID, Tileset: testTileset}
+			} else {
+				layer.Tiles[y*width+x] = &tiled.LayerTile{Nil: true}
+			}
+		}
+	}
+	properties := tiled.Properties{}
+	for d, adjacentID := range tm.adjacent {
+		properties = append(properties, &tiled.Property{Name: d.String(), Value: adjacentID})
+	}
+	return &Map{
+		id: id,
+		define: &tiled.Map{
+			Width:      width,
+			Height:     height,
+			TileWidth:  config.TileSize,
+			TileHeight: config.TileSize,
+			Properties: &properties,
+			Layers:     []*tiled.Layer{layer},
+		},
+	}
+}
+
+// 只载入了maps中的地图，当前地图为current，主角不在任何地图上
+func newTestWorld(t *testing.T, current string, maps map[string]testMap) *World {
+	t.Helper()
+	w := &World{mapCache: make(map[string]*Map), selfPos: [2]int{-1000, -1000}}
+	for id, tm := range maps {
+		m := newTestMap(t, id, tm)
+		m.world = w
+		w.mapCache[id] = m
+	}
+	w.currentMap = w.mapCache[current]
+	return w
+}
+
+func TestFindPath(t *testing.T) {
+	right := map[util.Direction]string{util.DirectionEnum.Right: "east"}
+	tests := []struct {
+		name     string
+		maps     map[string]testMap
+		from, to [2]int
+		opts     PathOptions
+		steps    int // 最短步数，-1为无法到达
+	}{
+		{
+			name: "straight",
+			maps: map[string]testMap{"main": {rows: []string{"....."}}},
+			from: [2]int{0, 0}, to: [2]int{4, 0},
+			steps: 4,
+		},
+		{
+			name: "detour",
+			maps: map[string]testMap{"main": {rows: []string{
+				".#.",
+				".#.",
+				"...",
+			}}},
+			from: [2]int{0, 0}, to: [2]int{2, 0},
+			steps: 6,
+		},
+		{
+			// allow_direction为left的地块只能向右走入
+			name: "one_way_allowed",
+			maps: map[string]testMap{"main": {rows: []string{
+				".<.",
+				"...",
+			}}},
+			from: [2]int{0, 0}, to: [2]int{2, 0},
+			steps: 2,
+		},
+		{
+			name: "one_way_detour",
+			maps: map[string]testMap{"main": {rows: []string{
+				".<.",
+				"...",
+			}}},
+			from: [2]int{2, 0}, to: [2]int{0, 0},
+			steps: 4,
+		},
+		{
+			// allow_direction为up的地块只能向下走入，无法从下方进入
+			name: "one_way_blocked",
+			maps: map[string]testMap{"main": {rows: []string{
+				"#.#",
+				"#^#",
+				"#.#",
+			}}},
+			from: [2]int{1, 2}, to: [2]int{1, 0},
+			steps: -1,
+		},
+		{
+			name: "adjacent_map",
+			maps: map[string]testMap{
+				"main": {rows: []string{"...", "..."}, adjacent: right},
+				"east": {rows: []string{"#..", "..."}},
+			},
+			from: [2]int{0, 0}, to: [2]int{5, 0},
+			steps: 7,
+		},
+		{
+			name: "adjacent_map_not_loaded",
+			maps: map[string]testMap{"main": {rows: []string{"..."}, adjacent: right}},
+			from: [2]int{0, 0}, to: [2]int{4, 0},
+			steps: -1,
+		},
+		{
+			name: "bounds",
+			maps: map[string]testMap{
+				"main": {rows: []string{"...", "..."}, adjacent: right},
+				"east": {rows: []string{"...", "..."}},
+			},
+			from: [2]int{0, 0}, to: [2]int{4, 0},
+			opts:  PathOptions{Bounds: image.Rect(0, 0, 3, 2)},
+			steps: -1,
+		},
+		{
+			name: "unreachable",
+			maps: map[string]testMap{"main": {rows: []string{
+				"....",
+				".###",
+				".#..",
+				".###",
+			}}},
+			from: [2]int{0, 0}, to: [2]int{3, 2},
+			steps: -1,
+		},
+		{
+			name: "max_nodes",
+			maps: map[string]testMap{"main": {rows: []string{"........"}}},
+			from: [2]int{0, 0}, to: [2]int{7, 0},
+			opts:  PathOptions{MaxNodes: 3},
+			steps: -1,
+		},
+		{
+			name: "stop_adjacent",
+			maps: map[string]testMap{"main": {rows: []string{"....#"}}},
+			from: [2]int{0, 0}, to: [2]int{4, 0},
+			opts:  PathOptions{StopAdjacent: true},
+			steps: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t, "main", tt.maps)
+			path, ok := w.FindPath(tt.from, tt.to, tt.opts)
+			if tt.steps < 0 {
+				if ok {
+					t.Fatalf("found path %v, want none", path)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("no path found")
+			}
+			if len(path) != tt.steps {
+				t.Fatalf("path %v has %d steps, want %d", path, len(path), tt.steps)
+			}
+			// 沿路线逐步走，每一步都不能被阻挡
+			pos := tt.from
+			for i, d := range path {
+				dx, dy := d.Offset()
+				pos = [2]int{pos[0] + dx, pos[1] + dy}
+				if w.CheckCollision(d, pos[0], pos[1]) {
+					t.Fatalf("step %d (%s) into %v is blocked", i, d, pos)
+				}
+			}
+			dist := abs(pos[0]-tt.to[0]) + abs(pos[1]-tt.to[1])
+			if (tt.opts.StopAdjacent && dist != 1) || (!tt.opts.StopAdjacent && dist != 0) {
+				t.Fatalf("path ends at %v, target %v", pos, tt.to)
+			}
+		})
+	}
+}
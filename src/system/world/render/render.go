@@ -80,6 +80,21 @@ func (r *Renderer) getTileImage(tile *tiled.LayerTile) (imgutil.Image, error) {
 	return img, nil
 }
 
+// 动画地块当前帧对应的地块
+func (r *Renderer) currentFrame(layerTile *tiled.LayerTile) *tiled.LayerTile {
+	if layerTile.Tileset == nil {
+		return layerTile
+	}
+	tileDef, err := layerTile.Tileset.GetTilesetTile(layerTile.ID)
+	if err != nil || tileDef == nil || len(tileDef.Animation) == 0 {
+		return layerTile
+	}
+	index := int(r.dur/(time.Millisecond*time.Duration(tileDef.Animation[0].Duration))) % len(tileDef.Animation)
+	newLayerTile := *layerTile
+	newLayerTile.ID = tileDef.Animation[index].TileID
+	return &newLayerTile
+}
+
 func (r *Renderer) renderLayer(drawer draw.OptionDrawer, layer *tiled.Layer, rect image.Rectangle) error {
 	// TODO: 可优化，foreach函数里直接不遍历rect外的坐标
 	var retErr error
@@ -88,19 +103,7 @@ func (r *Renderer) renderLayer(drawer draw.OptionDrawer, layer *tiled.Layer, rec
 			return true
 		}
 		err := func() error {
-			// 动画
-			if layerTile.Tileset != nil {
-				tileDef, err := layerTile.Tileset.GetTilesetTile(layerTile.ID)
-				if err == nil && tileDef != nil && len(tileDef.Animation) > 0 {
-					index := int(r.dur/(time.Millisecond*time.Duration(tileDef.Animation[0].Duration))) % len(tileDef.Animation)
-					thisFrame := tileDef.Animation[index]
-					newLayerTile := *layerTile
-					newLayerTile.ID = thisFrame.TileID
-					layerTile = &newLayerTile
-				}
-			}
-
-			img, err := r.getTileImage(layerTile)
+			img, err := r.getTileImage(r.currentFrame(layerTile))
 			if err != nil {
 				return err
 			}
@@ -131,3 +134,25 @@ func (r *Renderer) RenderRectLayer(drawer draw.OptionDrawer, id int, rect image.
 	}
 	return r.renderLayer(drawer, r.m.Layers[id], rect)
 }
+
+// RenderTilePart 绘制图层中一个地块的一部分，part为相对地块左上角的像素范围
+func (r *Renderer) RenderTilePart(drawer draw.OptionDrawer, id int, x, y int, part image.Rectangle) error {
+	if id >= len(r.m.Layers) || x < 0 || y < 0 || x >= r.m.Width || y >= r.m.Height {
+		return render.ErrOutOfBounds
+	}
+	layerTile := r.m.Layers[id].Tiles[y*r.m.Width+x]
+	if layerTile == nil || layerTile.IsNil() {
+		return nil
+	}
+	img, err := r.getTileImage(r.currentFrame(layerTile))
+	if err != nil {
+		return err
+	}
+	bounds := img.Bounds()
+	part = part.Add(bounds.Min).Intersect(bounds)
+	if part.Empty() {
+		return nil
+	}
+	draw.PrepareDrawImage(drawer, img.SubImage(part)).Move(x*r.m.TileWidth+part.Min.X-bounds.Min.X, y*r.m.TileHeight+part.Min.Y-bounds.Min.Y).Draw()
+	return nil
+}
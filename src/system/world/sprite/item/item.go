@@ -21,13 +21,21 @@ func init() {
 
 type Item interface {
 	sprite.Sprite
+	// PickUp 拾取得到的道具id和数量
+	PickUp() (string, int)
+	// CollectFlag 拾取后设置的标记，设置后不再出现
+	CollectFlag() string
+	SetCollectFlag(flag string)
 }
 
 type _Item struct {
-	pos        [2]int            // 位置
-	actionType sprite.ActionType // 交互类型
-	script     string            // 脚本id
-	text       string            // 对话文本
+	pos         [2]int            // 位置
+	actionType  sprite.ActionType // 交互类型
+	script      string            // 脚本id
+	text        string            // 对话文本
+	item        string            // 拾取得到的道具id
+	count       int               // 拾取得到的道具数量
+	collectFlag string            // 拾取后设置的标记
 }
 
 func NewItem() (Item, error) {
@@ -36,9 +44,12 @@ func NewItem() (Item, error) {
 
 func NewItemByTile(object *tiled.Object) (Item, error) {
 	return &_Item{
-		actionType: sprite.ActionType(object.Properties.GetString("action_type")),
-		script:     object.Properties.GetString("script"),
-		text:       object.Properties.GetString("text"),
+		actionType:  sprite.ActionType(object.Properties.GetString("action_type")),
+		script:      object.Properties.GetString("script"),
+		text:        object.Properties.GetString("text"),
+		item:        object.Properties.GetString("item"),
+		count:       max(object.Properties.GetInt("count"), 1),
+		collectFlag: object.Properties.GetString("flag"),
 	}, nil
 }
 
@@ -81,3 +92,15 @@ func (i *_Item) Draw(_ context.Context, _ draw.OptionDrawer) error {
 func (i *_Item) GetText() string {
 	return i.text
 }
+
+func (i *_Item) PickUp() (string, int) {
+	return i.item, i.count
+}
+
+func (i *_Item) CollectFlag() string {
+	return i.collectFlag
+}
+
+func (i *_Item) SetCollectFlag(flag string) {
+	i.collectFlag = flag
+}
@@ -0,0 +1,239 @@
+package person
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/lafriks/go-tiled"
+	"github.com/tnnmigga/enum"
+
+	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/system/context"
+	"github.com/kkkunny/pokemon/src/system/world"
+	"github.com/kkkunny/pokemon/src/util"
+)
+
+// MovementMode 人物的移动方式
+type MovementMode string
+
+var MovementModeEnum = enum.New[struct {
+	Stationary MovementMode `enum:"stationary"`  // 原地不动
+	FaceRandom MovementMode `enum:"face_random"` // 原地随机转向
+	Wander     MovementMode `enum:"wander"`      // 在范围内随机走动
+	Patrol     MovementMode `enum:"patrol"`      // 沿折线或多边形巡逻
+	Schedule   MovementMode `enum:"schedule"`    // 按时段前往不同地点
+}]()
+
+const (
+	patrolWaitTicks    = 30 // 到达巡逻点后停留的帧数
+	pathRetryWaitTicks = 60 // 寻路失败后再次寻路前等待的帧数
+)
+
+// 人物的移动设置和状态
+type movement struct {
+	mode     MovementMode
+	area     image.Rectangle            // 走动范围，为空时为整张地图
+	route    [][2]int                   // 巡逻路线
+	loop     bool                       // 路线首尾相连
+	schedule map[world.TimeOfDay][2]int // 各时段所在地点
+	index    int                        // 当前前往的巡逻点
+	step     int                        // 巡逻方向，折线往返时为1或-1
+	wait     int                        // 剩余停留帧数
+
+	// 缓存的路线，起点、终点或可通行网格变化以及下一步被阻挡时重新寻路
+	path        []util.Direction
+	pathFrom    [2]int // 路线的起点，即下一步出发的地块
+	pathTarget  [2]int
+	pathVersion int // 寻路时可通行网格的版本
+	pathWait    int // 寻路失败后剩余的等待帧数
+}
+
+// 解析tiled对象上的移动设置，mode为空时使用defaultMode
+// wander 时对象为矩形则在矩形内走动，否则在 range 格内走动，都没有时在整张地图内走动
+// patrol 时对象需要为折线或多边形，路线首点为起始位置
+// schedule 格式为 "时段 x,y; 时段 x,y"
+func parseMovement(object *tiled.Object, defaultMode MovementMode) (*movement, error) {
+	m := &movement{mode: MovementMode(object.Properties.GetString("movement")), step: 1}
+	if m.mode == "" {
+		m.mode = defaultMode
+	}
+	startX, startY := int(object.X)/config.TileSize, int(object.Y)/config.TileSize
+
+	switch m.mode {
+	case MovementModeEnum.Stationary, MovementModeEnum.FaceRandom:
+	case MovementModeEnum.Wander:
+		if object.Width > 0 && object.Height > 0 {
+			m.area = image.Rect(startX, startY, int(math.Ceil((object.X+object.Width)/config.TileSize)), int(math.Ceil((object.Y+object.Height)/config.TileSize)))
+		} else if r := object.Properties.GetInt("range"); r > 0 {
+			m.area = image.Rect(startX-r, startY-r, startX+r+1, startY+r+1)
+		}
+	case MovementModeEnum.Patrol:
+		var points []*tiled.Point
+		if len(object.PolyLines) > 0 && object.PolyLines[0].Points != nil {
+			points = *object.PolyLines[0].Points
+		} else if len(object.Polygons) > 0 && object.Polygons[0].Points != nil {
+			points, m.loop = *object.Polygons[0].Points, true
+		}
+		for _, p := range points {
+			m.route = append(m.route, [2]int{int(object.X+p.X) / config.TileSize, int(object.Y+p.Y) / config.TileSize})
+		}
+		if len(m.route) < 2 {
+			return nil, fmt.Errorf("object %d: patrol needs a polyline or polygon with at least 2 points", object.ID)
+		}
+		for i := 1; i < len(m.route); i++ {
+			if m.route[i][0] != m.route[i-1][0] && m.route[i][1] != m.route[i-1][1] {
+				return nil, fmt.Errorf("object %d: patrol segment %d is not horizontal or vertical", object.ID, i)
+			}
+		}
+		m.index = 1
+	case MovementModeEnum.Schedule:
+		schedule, err := parseSchedule(object.Properties.GetString("schedule"))
+		if err != nil {
+			return nil, fmt.Errorf("object %d: %w", object.ID, err)
+		}
+		m.schedule = schedule
+	default:
+		return nil, fmt.Errorf("object %d: unknown movement `%s`", object.ID, m.mode)
+	}
+	return m, nil
+}
+
+func parseSchedule(s string) (map[world.TimeOfDay][2]int, error) {
+	schedule := make(map[world.TimeOfDay][2]int)
+	for _, entry := range strings.Split(s, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		} else if len(fields) != 2 {
+			return nil, fmt.Errorf("schedule entry `%s`: expect `time x,y`", strings.TrimSpace(entry))
+		}
+		timeOfDay := world.TimeOfDay(fields[0])
+		if !enum.Contains(world.TimeOfDayEnum, timeOfDay) {
+			return nil, fmt.Errorf("schedule entry `%s`: unknown time `%s`", strings.TrimSpace(entry), fields[0])
+		}
+		xStr, yStr, ok := strings.Cut(fields[1], ",")
+		if !ok {
+			return nil, fmt.Errorf("schedule entry `%s`: expect `time x,y`", strings.TrimSpace(entry))
+		}
+		x, xErr := strconv.Atoi(xStr)
+		y, yErr := strconv.Atoi(yStr)
+		if xErr != nil || yErr != nil {
+			return nil, fmt.Errorf("schedule entry `%s`: invalid position", strings.TrimSpace(entry))
+		}
+		schedule[timeOfDay] = [2]int{x, y}
+	}
+	if len(schedule) == 0 {
+		return nil, fmt.Errorf("schedule is empty")
+	}
+	return schedule, nil
+}
+
+// 空闲时按移动方式决定下一步
+func (p *_Person) updateMovement(ctx context.Context, w *world.World) {
+	m := p.movement
+	switch m.mode {
+	case MovementModeEnum.FaceRandom:
+		p.faceRandom(ctx)
+	case MovementModeEnum.Wander:
+		d, ok := randomDirection(ctx)
+		if !ok {
+			return
+		}
+		if p.direction != d && ctx.Rand(context.RandStreamEnum.Npc).IntN(500) > 250 {
+			p.Turn(d)
+		} else if p.canStep(w, d) {
+			p.SetNextStepDirection(d)
+		}
+	case MovementModeEnum.Patrol:
+		if m.wait > 0 {
+			m.wait--
+			return
+		}
+		if p.pos != m.route[m.index] {
+			p.stepToward(w, m.route[m.index])
+			return
+		}
+		// 到达巡逻点，前往下一个
+		m.wait = patrolWaitTicks
+		if m.loop {
+			m.index = (m.index + 1) % len(m.route)
+		} else {
+			if m.index+m.step < 0 || m.index+m.step >= len(m.route) {
+				m.step = -m.step
+			}
+			m.index += m.step
+		}
+	case MovementModeEnum.Schedule:
+		target, ok := m.schedule[world.GetTimeOfDay(w.Now())]
+		if !ok {
+			target, ok = m.schedule[world.TimeOfDayEnum.Any]
+		}
+		if ok && p.pos != target {
+			p.stepToward(w, target)
+		} else {
+			p.faceRandom(ctx)
+		}
+	}
+}
+
+// 随机选取一个方向，大部分时候不动
+func randomDirection(ctx context.Context) (util.Direction, bool) {
+	n := ctx.Rand(context.RandStreamEnum.Npc).IntN(500)
+	if n >= 499 {
+		return util.DirectionEnum.Up, true
+	} else if n >= 498 {
+		return util.DirectionEnum.Down, true
+	} else if n >= 497 {
+		return util.DirectionEnum.Left, true
+	} else if n >= 496 {
+		return util.DirectionEnum.Right, true
+	}
+	return 0, false
+}
+
+func (p *_Person) faceRandom(ctx context.Context) {
+	if d, ok := randomDirection(ctx); ok && d != p.direction {
+		p.Turn(d)
+	}
+}
+
+// 沿寻路得到的路线向目标前进一步，不会离开所在地图
+func (p *_Person) stepToward(w *world.World, target [2]int) {
+	m := p.movement
+	if m.pathWait > 0 {
+		m.pathWait--
+		return
+	}
+	cached := len(m.path) > 0 && m.pathFrom == p.pos && m.pathTarget == target && m.pathVersion == w.WalkGridVersion()
+	if !cached || !p.canStep(w, m.path[0]) {
+		width, height := w.CurrentMap().Size()
+		path, ok := w.FindPath(p.pos, target, world.PathOptions{Bounds: image.Rect(0, 0, width, height)})
+		if !ok || len(path) == 0 || !p.canStep(w, path[0]) {
+			m.path = nil
+			m.pathWait = pathRetryWaitTicks
+			return
+		}
+		m.path, m.pathFrom, m.pathTarget, m.pathVersion = path, p.pos, target, w.WalkGridVersion()
+	}
+	if !p.SetNextStepDirection(m.path[0]) {
+		return
+	}
+	m.pathFrom = p.nextStepPos
+	m.path = m.path[1:]
+}
+
+// 是否可以向某方向走一步，不能离开所在地图和走动范围
+func (p *_Person) canStep(w *world.World, d util.Direction) bool {
+	x, y := GetNextPositionByDirection(d, p.pos[0], p.pos[1])
+	width, height := w.CurrentMap().Size()
+	if x < 0 || y < 0 || x >= width || y >= height {
+		return false
+	}
+	if area := p.movement.area; !area.Empty() && !image.Pt(x, y).In(area) {
+		return false
+	}
+	return !w.CheckCollision(d, x, y)
+}
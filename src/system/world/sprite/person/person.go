@@ -2,7 +2,9 @@ package person
 
 import (
 	"errors"
-	"math/rand/v2"
+	"image"
+	"image/color"
+	"math"
 
 	stlmaps "github.com/kkkunny/stl/container/maps"
 	stlval "github.com/kkkunny/stl/value"
@@ -30,8 +32,14 @@ func init() {
 
 type Person interface {
 	sprite.MovableSprite
+	SetDirection(d util.Direction)
+	SetNextStepDirection(d util.Direction) bool
+	Jump(d util.Direction) bool
+	Busying() bool
 }
 
+const jumpHeight = 8 // 跳跃的最大高度
+
 type _Person struct {
 	item.Item
 	// 静态资源
@@ -46,6 +54,8 @@ type _Person struct {
 	pos               [2]int         // 当前地块位置
 	nextStepPos       [2]int         // 下一步预期所处的地块位置，用于移动
 	moveCounter       int            // 移动时的计数器，用于显示动画
+	jumping           bool           // 是否正在跳跃，跳跃时一步前进两格
+	movement          *movement      // 空闲时的移动方式
 }
 
 func NewPerson(name string) (Person, error) {
@@ -67,10 +77,15 @@ func NewPerson(name string) (Person, error) {
 		nextStepDirection:  util.DirectionEnum.Down,
 		moveStartingFoot:   FootEnum.Left,
 		speed:              1,
+		movement:           &movement{mode: MovementModeEnum.Stationary},
 	}, nil
 }
 
 func NewPersonByTile(object *tiled.Object) (Person, error) {
+	return newPersonByTile(object, MovementModeEnum.Wander)
+}
+
+func newPersonByTile(object *tiled.Object, defaultMovement MovementMode) (*_Person, error) {
 	imgName := object.Properties.GetString("image")
 	behaviorAnimations, err := loadPersonAnimations(imgName, sprite.BehaviorEnum.Walk)
 	if err != nil {
@@ -82,14 +97,25 @@ func NewPersonByTile(object *tiled.Object) (Person, error) {
 		return nil, err
 	}
 
+	movement, err := parseMovement(object, defaultMovement)
+	if err != nil {
+		return nil, err
+	}
+
+	direction := util.DirectionEnum.Down
+	if d := object.Properties.GetString("direction"); d != "" {
+		direction = util.ParseDirection(d)
+	}
+
 	return &_Person{
 		Item:               itemSprite,
 		behaviorAnimations: behaviorAnimations,
-		direction:          util.DirectionEnum.Down,
+		direction:          direction,
 		movable:            true,
-		nextStepDirection:  util.DirectionEnum.Down,
+		nextStepDirection:  direction,
 		moveStartingFoot:   FootEnum.Left,
 		speed:              1,
+		movement:           movement,
 	}, nil
 }
 
@@ -142,6 +168,39 @@ func (p *_Person) SetNextStepDirection(d util.Direction) bool {
 	return true
 }
 
+// Jump 沿方向跳过一格，落在其后一格，不会校验落点是否可移动
+func (p *_Person) Jump(d util.Direction) bool {
+	if p.Busying() {
+		return false
+	}
+	p.direction, p.nextStepDirection = d, d
+	x, y := GetNextPositionByDirection(d, p.pos[0], p.pos[1])
+	p.nextStepPos[0], p.nextStepPos[1] = GetNextPositionByDirection(d, x, y)
+	p.jumping = true
+	return true
+}
+
+// 一步的像素长度
+func (p *_Person) stepLength() int {
+	return stlval.Ternary(p.jumping, 2*config.TileSize, config.TileSize)
+}
+
+// 跳跃时离地的高度
+func (p *_Person) jumpOffset() float64 {
+	if !p.jumping {
+		return 0
+	}
+	return jumpHeight * math.Sin(math.Pi*float64(p.moveCounter)/float64(p.stepLength()))
+}
+
+// 跳跃时在脚下绘制影子
+func (p *_Person) drawJumpShadow(drawer draw.OptionDrawer, bounds image.Rectangle) {
+	if !p.jumping {
+		return
+	}
+	draw.PrepareDrawRect(drawer, bounds.Dx()-4, 4, color.NRGBA{A: 96}).SetRadius(2).Move(2, bounds.Dy()-3).Draw()
+}
+
 func (p *_Person) OnAction(_ context.Context, _ input.KeyInputAction, _ sprite.UpdateInfo) error {
 	return nil
 }
@@ -186,39 +245,21 @@ func (p *_Person) Update(ctx context.Context, info sprite.UpdateInfo) error {
 		}
 	} else if p.Moving() {
 		a := p.behaviorAnimations[sprite.BehaviorEnum.Walk][p.nextStepDirection][p.moveStartingFoot]
-		a.SetFrameTime(config.TileSize / p.speed / a.FrameCount())
+		a.SetFrameTime(p.stepLength() / p.speed / a.FrameCount())
 		a.Update()
 
-		diff := config.TileSize - p.moveCounter
+		diff := p.stepLength() - p.moveCounter
 		if diff > p.speed {
 			p.moveCounter += p.speed
 		} else {
 			p.moveCounter = 0
-			_, targetX, targetY, _ := updateInfo.World.GetActualPosition(p.nextStepPos[0], p.nextStepPos[1])
-			p.nextStepPos = [2]int{targetX, targetY}
+			p.jumping = false
 			p.pos = p.nextStepPos
 			p.moveStartingFoot = -p.moveStartingFoot
 			a.Reset()
 		}
 	} else if p.movable {
-		var nextStepDirection util.Direction
-		n := rand.IntN(500)
-		if n >= 499 {
-			nextStepDirection = util.DirectionEnum.Up
-		} else if n >= 498 {
-			nextStepDirection = util.DirectionEnum.Down
-		} else if n >= 497 {
-			nextStepDirection = util.DirectionEnum.Left
-		} else if n >= 496 {
-			nextStepDirection = util.DirectionEnum.Right
-		} else {
-			return nil
-		}
-		if p.direction != nextStepDirection && rand.IntN(500) > 250 {
-			p.nextStepDirection = nextStepDirection
-		} else if x, y := GetNextPositionByDirection(nextStepDirection, p.pos[0], p.pos[1]); !updateInfo.World.CheckCollision(p.direction, x, y) {
-			p.SetNextStepDirection(nextStepDirection)
-		}
+		p.updateMovement(ctx, updateInfo.World)
 	}
 	return nil
 }
@@ -226,6 +267,9 @@ func (p *_Person) Update(ctx context.Context, info sprite.UpdateInfo) error {
 func (p *_Person) Draw(ctx context.Context, drawer draw.OptionDrawer) error {
 	x, y := p.PixelPosition()
 	drawer = drawer.Move(int(x), int(y))
+	bounds := p.behaviorAnimations[sprite.BehaviorEnum.Walk][p.nextStepDirection][p.moveStartingFoot].GetFrameImage(0).Bounds()
+	p.drawJumpShadow(drawer, bounds)
+	drawer = drawer.Move(0, -int(p.jumpOffset()))
 
 	if p.Turning() {
 		if p.direction == -p.nextStepDirection {
@@ -5,13 +5,10 @@ import (
 
 	stlmaps "github.com/kkkunny/stl/container/maps"
 	stlval "github.com/kkkunny/stl/value"
-	lua "github.com/yuin/gopher-lua"
 
 	"github.com/kkkunny/pokemon/src/config"
 	"github.com/kkkunny/pokemon/src/input"
-	"github.com/kkkunny/pokemon/src/script"
 	"github.com/kkkunny/pokemon/src/system/context"
-	"github.com/kkkunny/pokemon/src/system/world"
 	"github.com/kkkunny/pokemon/src/system/world/sprite"
 	"github.com/kkkunny/pokemon/src/util"
 	"github.com/kkkunny/pokemon/src/util/draw"
@@ -28,12 +25,20 @@ type Self interface {
 	Person
 	ActionSprite() sprite.Sprite
 	SetActionSprite(sp sprite.Sprite)
+	SetRunning(running bool)
+	Running() bool
 }
 
+const (
+	walkSpeed = 1 // 行走速度
+	runSpeed  = 2 // 跑步速度
+)
+
 type _Self struct {
 	_Person
 
 	actionSprite sprite.Sprite
+	running      bool // 是否按住了跑步键
 }
 
 func NewSelf(name string) (Self, error) {
@@ -71,7 +76,9 @@ func (s *_Self) OnAction(_ context.Context, action input.KeyInputAction, info sp
 		if ok {
 			if s.direction != nextStepDirection {
 				s.nextStepDirection = nextStepDirection
-			} else if x, y := GetNextPositionByDirection(nextStepDirection, s.pos[0], s.pos[1]); !updateInfo.World.CheckCollision(s.direction, x, y) {
+			} else if x, y := GetNextPositionByDirection(nextStepDirection, s.pos[0], s.pos[1]); updateInfo.World.CheckJump(s.direction, x, y) {
+				s.Jump(nextStepDirection)
+			} else if !updateInfo.World.CheckSelfCollision(s.direction, x, y) {
 				s.SetNextStepDirection(nextStepDirection)
 			}
 		}
@@ -83,7 +90,7 @@ func (s *_Self) OnAction(_ context.Context, action input.KeyInputAction, info sp
 // 主角放于屏幕正中间时，相对于屏幕左上角的像素位置，考虑放大倍数
 func (s *_Self) PixelPosition(cfg *config.Config) (x, y float64) {
 	bounds := stlmaps.First(stlmaps.First(s.behaviorAnimations[sprite.BehaviorEnum.Walk]).E2()).E2().GetFrameImage(0).Bounds()
-	return float64(cfg.ScreenWidth)/2 - float64(bounds.Dx()*config.Scale)/2, float64(cfg.ScreenHeight)/2 - float64(bounds.Dy()*config.Scale)/2
+	return float64(cfg.ScreenWidth)/2 - float64(bounds.Dx()*cfg.Scale)/2, float64(cfg.ScreenHeight)/2 - float64(bounds.Dy()*cfg.Scale)/2
 }
 
 func (s *_Self) Update(ctx context.Context, info sprite.UpdateInfo) error {
@@ -104,15 +111,20 @@ func (s *_Self) Update(ctx context.Context, info sprite.UpdateInfo) error {
 			s.moveStartingFoot = -s.moveStartingFoot
 		}
 	} else if s.Moving() {
-		a := s.behaviorAnimations[sprite.BehaviorEnum.Walk][s.nextStepDirection][s.moveStartingFoot]
-		a.SetFrameTime(config.TileSize / s.speed / a.FrameCount())
+		// 只在每一步开始时切换速度，保证停在地块上
+		if s.moveCounter == 0 {
+			s.speed = stlval.Ternary(s.running, runSpeed, walkSpeed)
+		}
+		a := s.behaviorAnimations[s.behavior()][s.nextStepDirection][s.moveStartingFoot]
+		a.SetFrameTime(s.stepLength() / s.speed / a.FrameCount())
 		a.Update()
 
-		diff := config.TileSize - s.moveCounter
+		diff := s.stepLength() - s.moveCounter
 		if diff > s.speed {
 			s.moveCounter += s.speed
 		} else {
 			s.moveCounter = 0
+			s.jumping = false
 			targetMap, targetX, targetY, _ := updateInfo.World.GetActualPosition(s.nextStepPos[0], s.nextStepPos[1])
 			err := updateInfo.World.MoveTo(targetMap.ID())
 			if err != nil {
@@ -128,14 +140,19 @@ func (s *_Self) Update(ctx context.Context, info sprite.UpdateInfo) error {
 	// 更新地图位置
 	pixX, pixY := s._Person.PixelPosition()
 	selfPixX, selfPixY := s.PixelPosition(ctx.Config())
-	mapPixX, mapPixY := (selfPixX-pixX*config.Scale)/config.Scale, (selfPixY-pixY*config.Scale)/config.Scale
+	scale := float64(ctx.Config().Scale)
+	mapPixX, mapPixY := (selfPixX-pixX*scale)/scale, (selfPixY-pixY*scale)/scale
 	updateInfo.World.MovePixelPosTo(int(mapPixX), int(mapPixY))
 	return nil
 }
 
 func (s *_Self) Draw(ctx context.Context, drawer draw.OptionDrawer) error {
 	x, y := s.PixelPosition(ctx.Config())
-	drawer = drawer.MoveTo(int(x/config.Scale), int(y/config.Scale))
+	scale := float64(ctx.Config().Scale)
+	drawer = drawer.MoveTo(int(x/scale), int(y/scale))
+	bounds := s.behaviorAnimations[sprite.BehaviorEnum.Walk][s.nextStepDirection][s.moveStartingFoot].GetFrameImage(0).Bounds()
+	s.drawJumpShadow(drawer, bounds)
+	drawer = drawer.Move(0, -int(s.jumpOffset()))
 
 	if s.Turning() {
 		if s.direction == -s.nextStepDirection {
@@ -152,68 +169,32 @@ func (s *_Self) Draw(ctx context.Context, drawer draw.OptionDrawer) error {
 		a := s.behaviorAnimations[sprite.BehaviorEnum.Walk][s.nextStepDirection][s.moveStartingFoot]
 		draw.PrepareDrawImage(drawer, a.GetFrameImage(1)).Draw()
 	} else {
-		a := s.behaviorAnimations[sprite.BehaviorEnum.Walk][s.nextStepDirection][s.moveStartingFoot]
+		a := s.behaviorAnimations[s.behavior()][s.nextStepDirection][s.moveStartingFoot]
 		draw.PrepareDrawImage(drawer, a.GetCurrentFrameImage()).Draw()
 	}
 	return nil
 }
 
-func (s *_Self) ActionSprite() sprite.Sprite {
-	return s.actionSprite
+// 当前行为，跑步中使用跑步动画
+func (s *_Self) behavior() sprite.Behavior {
+	if s.Moving() && s.speed == runSpeed {
+		return sprite.BehaviorEnum.Run
+	}
+	return sprite.BehaviorEnum.Walk
 }
 
-func (s *_Self) SetActionSprite(sp sprite.Sprite) {
-	s.actionSprite = sp
+func (s *_Self) SetRunning(running bool) {
+	s.running = running
 }
 
-var luaModuleToGo = map[string]map[string]lua.LGFunction{
-	"sprite": {
-		"set_movable": func(rt *lua.LState) int {
-			param1 := rt.CheckUserData(1)
-			s, ok := param1.Value.(sprite.MovableSprite)
-			if !ok {
-				return 1
-			}
-			movable := rt.CheckBool(2)
-			s.SetMovable(movable)
-			return 0
-		},
-	},
-	"game": {
-		"display_message": func(rt *lua.LState) int {
-			param1 := rt.CheckUserData(1)
-			s, ok := param1.Value.(sprite.MovableSprite)
-			if !ok {
-				return 1
-			}
-			movable := rt.CheckBool(2)
-			s.SetMovable(movable)
-			return 0
-		},
-	},
+func (s *_Self) Running() bool {
+	return s.running
 }
 
-func loadScriptFileWithSelf(w *world.World, this sprite.Sprite, master *_Self, name string) (rt *lua.LState, err error) {
-	rt, err = script.LoadScriptFile(name)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err != nil {
-			rt.Close()
-		}
-	}()
-
-	err = rt.PCall(0, lua.MultRet, nil)
-	if err != nil {
-		return nil, err
-	}
-	for moduleName, luaFuncToGo := range luaModuleToGo {
-		rt.PreloadModule(moduleName, func(rt *lua.LState) int {
-			rt.Push(rt.SetFuncs(rt.NewTable(), luaFuncToGo))
-			return 1
-		})
-	}
+func (s *_Self) ActionSprite() sprite.Sprite {
+	return s.actionSprite
+}
 
-	return rt, nil
+func (s *_Self) SetActionSprite(sp sprite.Sprite) {
+	s.actionSprite = sp
 }
@@ -0,0 +1,174 @@
+package person
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/lafriks/go-tiled"
+
+	"github.com/kkkunny/pokemon/src/system/context"
+	"github.com/kkkunny/pokemon/src/system/world/sprite"
+	"github.com/kkkunny/pokemon/src/util"
+	"github.com/kkkunny/pokemon/src/util/draw"
+)
+
+func init() {
+	sprite.RegisterCreateFunc([]string{"trainer"}, func(object *tiled.Object) (sprite.Sprite, error) {
+		trainer, err := NewTrainerByTile(object)
+		if err != nil {
+			return nil, err
+		}
+		return trainer, nil
+	})
+}
+
+const defaultTrainerSight = 4 // 默认视野格数
+
+// TrainerPokemon 训练师队伍中的宝可梦
+type TrainerPokemon struct {
+	Species int16
+	Level   int
+}
+
+type Trainer interface {
+	Person
+	// Name 训练师名的本地化键
+	Name() string
+	// Sight 视野格数
+	Sight() int
+	Team() []TrainerPokemon
+	// Site 战斗场地
+	Site() string
+	// DefeatedText 战败后对话文本的本地化键，战斗前的对话文本为GetText
+	DefeatedText() string
+	// DefeatedFlag 战败后设置的标记，设置后不再挑战
+	DefeatedFlag() string
+	SetDefeatedFlag(flag string)
+	// SetEmote 是否在头顶显示惊叹号
+	SetEmote(emote bool)
+}
+
+type _Trainer struct {
+	*_Person
+	name         string
+	sight        int
+	team         []TrainerPokemon
+	site         string
+	defeatedText string
+	defeatedFlag string
+	emote        bool
+}
+
+func NewTrainerByTile(object *tiled.Object) (Trainer, error) {
+	// 训练师默认站在原地等待挑战
+	person, err := newPersonByTile(object, MovementModeEnum.Stationary)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := ParseTrainerTeam(object.Properties.GetString("team"))
+	if err != nil {
+		return nil, fmt.Errorf("trainer %d: %w", object.ID, err)
+	}
+	sight := object.Properties.GetInt("sight")
+	if sight <= 0 {
+		sight = defaultTrainerSight
+	}
+	site := object.Properties.GetString("battle_site")
+	if site == "" {
+		site = "grassland"
+	}
+	return &_Trainer{
+		_Person:      person,
+		name:         object.Properties.GetString("name"),
+		sight:        sight,
+		team:         team,
+		site:         site,
+		defeatedText: object.Properties.GetString("defeated_text"),
+		defeatedFlag: object.Properties.GetString("defeated_flag"),
+	}, nil
+}
+
+// ParseTrainerTeam 解析训练师队伍，格式为 "种族:等级,种族:等级"
+func ParseTrainerTeam(s string) ([]TrainerPokemon, error) {
+	var team []TrainerPokemon
+	for _, member := range strings.Split(s, ",") {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		speciesStr, levelStr, ok := strings.Cut(member, ":")
+		if !ok {
+			return nil, fmt.Errorf("team member `%s`: expect `species:level`", member)
+		}
+		species, err := strconv.ParseInt(strings.TrimSpace(speciesStr), 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("team member `%s`: %w", member, err)
+		}
+		level, err := strconv.Atoi(strings.TrimSpace(levelStr))
+		if err != nil || level < 1 || level > 100 {
+			return nil, fmt.Errorf("team member `%s`: level must be between 1 and 100", member)
+		}
+		team = append(team, TrainerPokemon{Species: int16(species), Level: level})
+	}
+	if len(team) == 0 {
+		return nil, fmt.Errorf("team is empty")
+	}
+	if len(team) > 6 {
+		return nil, fmt.Errorf("team has %d pokemon, at most 6", len(team))
+	}
+	return team, nil
+}
+
+func (t *_Trainer) ActionType() sprite.ActionType {
+	return sprite.ActionTypeEnum.Trainer
+}
+
+func (t *_Trainer) Name() string {
+	return t.name
+}
+
+func (t *_Trainer) Sight() int {
+	return t.sight
+}
+
+func (t *_Trainer) Team() []TrainerPokemon {
+	return t.team
+}
+
+func (t *_Trainer) Site() string {
+	return t.site
+}
+
+func (t *_Trainer) DefeatedText() string {
+	return t.defeatedText
+}
+
+func (t *_Trainer) DefeatedFlag() string {
+	return t.defeatedFlag
+}
+
+func (t *_Trainer) SetDefeatedFlag(flag string) {
+	t.defeatedFlag = flag
+}
+
+func (t *_Trainer) SetEmote(emote bool) {
+	t.emote = emote
+}
+
+func (t *_Trainer) Draw(ctx context.Context, drawer draw.OptionDrawer) error {
+	err := t._Person.Draw(ctx, drawer)
+	if err != nil || !t.emote {
+		return err
+	}
+
+	// 头顶的惊叹号
+	x, y := t.PixelPosition()
+	bubble := drawer.Move(int(x)+3, int(y)-12)
+	draw.PrepareDrawRect(bubble, 10, 12, color.Black).SetRadius(3).Draw()
+	draw.PrepareDrawRect(bubble, 8, 10, color.White).Move(1, 1).SetRadius(2).Draw()
+	draw.PrepareDrawText(bubble, "!", util.GetFont(util.FontTypeEnum.Normal, 10), util.NewNRGBColor(216, 40, 40)).Move(3, 0).Draw()
+	return nil
+}
@@ -3,8 +3,7 @@ package person
 import (
 	"fmt"
 	"image"
-	"os"
-	"path/filepath"
+	"path"
 
 	"github.com/tnnmigga/enum"
 
@@ -14,6 +13,7 @@ import (
 	"github.com/kkkunny/pokemon/src/util/draw"
 	"github.com/kkkunny/pokemon/src/util/image"
 
+	"github.com/kkkunny/pokemon/src/assets"
 	"github.com/kkkunny/pokemon/src/config"
 )
 
@@ -26,8 +26,8 @@ var FootEnum = enum.New[struct {
 
 // 载入人类动画
 func loadPersonAnimations(name string, behaviors ...sprite.Behavior) (map[sprite.Behavior]map[util.Direction]map[Foot]*animation.Animation, error) {
-	dirpath := filepath.Join(config.GFXMapPath, "people", name)
-	dirinfo, err := os.Stat(dirpath)
+	dirpath := path.Join(config.GFXMapPath, "people", name)
+	dirinfo, err := assets.Stat(dirpath)
 	if err != nil {
 		return nil, err
 	} else if !dirinfo.IsDir() {
@@ -36,7 +36,7 @@ func loadPersonAnimations(name string, behaviors ...sprite.Behavior) (map[sprite
 
 	behaviorAnimations := make(map[sprite.Behavior]map[util.Direction]map[Foot]*animation.Animation, len(behaviors))
 	for _, behavior := range behaviors {
-		behaviorImgSheetRect, err := imgutil.NewImageFromFile(filepath.Join(dirpath, string(behavior)+".png"))
+		behaviorImgSheetRect, err := imgutil.NewImageFromFile(path.Join(dirpath, string(behavior)+".png"))
 		if err != nil {
 			return nil, err
 		}
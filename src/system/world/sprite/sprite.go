@@ -28,6 +28,8 @@ var ActionTypeEnum = enum.New[struct {
 	Script   ActionType `enum:"script"`
 	Label    ActionType `enum:"label"`
 	Dialogue ActionType `enum:"dialogue"`
+	PickUp   ActionType `enum:"pick_up"` // 拾取道具
+	Trainer  ActionType `enum:"trainer"` // 训练师对战
 }]()
 
 type UpdateInfo interface {
@@ -0,0 +1,106 @@
+package world
+
+import (
+	"image"
+
+	"github.com/lafriks/go-tiled"
+
+	"github.com/kkkunny/pokemon/src/util"
+	"github.com/kkkunny/pokemon/src/util/draw"
+)
+
+// TerrainInfo 判定地形时的信息
+type TerrainInfo struct {
+	World     *World // 所在世界，地图单独载入时为nil
+	Map       *Map
+	X, Y      int                // 地块位置，相对所在地图
+	Direction util.Direction     // 进入地块或在地块上移动的方向
+	Tile      *tiled.TilesetTile // 带有terrain属性的地块定义
+	Self      bool               // 是否为主角
+}
+
+// Terrain 地形行为，由地块的terrain属性指定
+type Terrain interface {
+	// Passable 能否沿方向进入地块
+	Passable(info TerrainInfo) bool
+}
+
+// TerrainJump 可以越过的地形，如断崖
+type TerrainJump interface {
+	Terrain
+	// Jump 能否沿方向越过地块，落在其后一格
+	Jump(info TerrainInfo) bool
+}
+
+// TerrainStep 主角走完一步到达时触发的地形，如冰面和旋转地块
+type TerrainStep interface {
+	Terrain
+	// OnStep 到达地块时调用，返回被强制前进的方向
+	OnStep(info TerrainInfo) (util.Direction, bool)
+}
+
+// TerrainOverlay 遮挡站在上面的精灵的地形，如草丛
+type TerrainOverlay interface {
+	Terrain
+	// Overlay 地块绘制在精灵之上的部分，相对地块左上角
+	Overlay(info TerrainInfo) image.Rectangle
+}
+
+// TerrainEncounter 决定遭遇方式的地形，如水面
+type TerrainEncounter interface {
+	Terrain
+	EncounterMethod() EncounterMethod
+}
+
+var terrains = make(map[string]Terrain)
+
+// RegisterTerrain 注册地形，地块的terrain属性为name时生效
+func RegisterTerrain(name string, terrain Terrain) {
+	terrains[name] = terrain
+}
+
+// 地块上某一图层的地形
+type layerTerrain struct {
+	layer   int
+	terrain Terrain
+	info    TerrainInfo
+}
+
+// 获取地块上各图层的地形
+func (m *Map) getTerrains(d util.Direction, x, y int, self bool) []layerTerrain {
+	var result []layerTerrain
+	m.foreachTileDef(x, y, func(layer int, tileDef *tiled.TilesetTile) bool {
+		terrain, ok := terrains[tileDef.Properties.GetString("terrain")]
+		if ok {
+			result = append(result, layerTerrain{layer: layer, terrain: terrain, info: m.terrainInfo(d, x, y, tileDef, self)})
+		}
+		return true
+	})
+	return result
+}
+
+func (m *Map) terrainInfo(d util.Direction, x, y int, tileDef *tiled.TilesetTile, self bool) TerrainInfo {
+	return TerrainInfo{
+		World:     m.world,
+		Map:       m,
+		X:         x,
+		Y:         y,
+		Direction: d,
+		Tile:      tileDef,
+		Self:      self,
+	}
+}
+
+// TileEffect 地块上的短暂效果，如草丛晃动
+type TileEffect interface {
+	// Update 推进一帧，返回false时效果结束
+	Update() bool
+	// Draw 绘制效果，drawer已移动到地块左上角
+	Draw(drawer draw.OptionDrawer)
+}
+
+// 当前地图上的地块效果
+type tileEffect struct {
+	x, y   int
+	effect TileEffect
+}
@@ -0,0 +1,204 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/kkkunny/pokemon/src/system/world/sprite"
+	"github.com/kkkunny/pokemon/src/util"
+)
+
+func TestCheckJump(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []string
+		pos  [2]int // 主角位置
+		d    util.Direction
+		jump bool
+	}{
+		{name: "down", rows: []string{".", "v", "."}, pos: [2]int{0, 0}, d: util.DirectionEnum.Down, jump: true},
+		{name: "up", rows: []string{".", "v", "."}, pos: [2]int{0, 2}, d: util.DirectionEnum.Up},
+		{name: "side", rows: []string{".v."}, pos: [2]int{0, 0}, d: util.DirectionEnum.Right},
+		{name: "landing_blocked", rows: []string{".", "v", "#"}, pos: [2]int{0, 0}, d: util.DirectionEnum.Down},
+		{name: "landing_outside", rows: []string{".", "v"}, pos: [2]int{0, 0}, d: util.DirectionEnum.Down},
+		{name: "not_ledge", rows: []string{".", "*", "."}, pos: [2]int{0, 0}, d: util.DirectionEnum.Down},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t, "main", map[string]testMap{"main": {rows: tt.rows}})
+			w.selfPos = tt.pos
+			dx, dy := tt.d.Offset()
+			x, y := tt.pos[0]+dx, tt.pos[1]+dy
+			if got := w.CheckJump(tt.d, x, y); got != tt.jump {
+				t.Fatalf("jump %s over %v: got %v, want %v", tt.d, [2]int{x, y}, got, tt.jump)
+			}
+			// 断崖只能跳过，不能走入
+			if tt.rows[y][x] == 'v' && (!w.CheckSelfCollision(tt.d, x, y) || !w.CheckCollision(tt.d, x, y)) {
+				t.Fatal("walked into ledge")
+			}
+		})
+	}
+}
+
+// 测试用主角，记录被地形推动的方向
+type testSelf struct {
+	sprite.Sprite
+	pos    [2]int
+	dir    util.Direction
+	next   util.Direction
+	forced bool
+}
+
+func (s *testSelf) Position() (int, int)          { return s.pos[0], s.pos[1] }
+func (s *testSelf) Collision() bool               { return true }
+func (s *testSelf) CollisionPosition() (int, int) { return s.pos[0], s.pos[1] }
+func (s *testSelf) Direction() util.Direction     { return s.dir }
+
+func (s *testSelf) SetNextStepDirection(d util.Direction) bool {
+	s.next, s.forced = d, true
+	return true
+}
+
+func TestForcedStep(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []string
+		from [2]int
+		d    util.Direction
+		path [][2]int // 依次到达的地块，第一个为主动走入的
+	}{
+		{
+			name: "plain",
+			rows: []string{"..."},
+			from: [2]int{0, 0}, d: util.DirectionEnum.Right,
+			path: [][2]int{{1, 0}},
+		},
+		{
+			name: "ice_until_wall",
+			rows: []string{".***#"},
+			from: [2]int{0, 0}, d: util.DirectionEnum.Right,
+			path: [][2]int{{1, 0}, {2, 0}, {3, 0}},
+		},
+		{
+			name: "ice_onto_ground",
+			rows: []string{".**.."},
+			from: [2]int{0, 0}, d: util.DirectionEnum.Right,
+			path: [][2]int{{1, 0}, {2, 0}, {3, 0}},
+		},
+		{
+			name: "ice_until_edge",
+			rows: []string{".", "*", "*"},
+			from: [2]int{0, 0}, d: util.DirectionEnum.Down,
+			path: [][2]int{{0, 1}, {0, 2}},
+		},
+		{
+			name: "ice_keeps_direction",
+			rows: []string{
+				"...",
+				"***",
+				"...",
+			},
+			from: [2]int{1, 0}, d: util.DirectionEnum.Down,
+			path: [][2]int{{1, 1}, {1, 2}},
+		},
+		{
+			name: "ice_blocked_at_once",
+			rows: []string{".*#"},
+			from: [2]int{0, 0}, d: util.DirectionEnum.Right,
+			path: [][2]int{{1, 0}},
+		},
+		{
+			name: "spin_redirect",
+			rows: []string{
+				".D..",
+				"....",
+			},
+			from: [2]int{0, 0}, d: util.DirectionEnum.Right,
+			path: [][2]int{{1, 0}, {1, 1}},
+		},
+		{
+			name: "spin_chain",
+			rows: []string{
+				".D..",
+				".R..",
+			},
+			from: [2]int{0, 0}, d: util.DirectionEnum.Right,
+			path: [][2]int{{1, 0}, {1, 1}, {2, 1}},
+		},
+		{
+			name: "spin_blocked",
+			rows: []string{
+				".D.",
+				".#.",
+			},
+			from: [2]int{0, 0}, d: util.DirectionEnum.Right,
+			path: [][2]int{{1, 0}},
+		},
+		{
+			// 旋转地块推上冰面后沿新方向滑行
+			name: "spin_onto_ice",
+			rows: []string{
+				"..",
+				"R*",
+				"..",
+			},
+			from: [2]int{0, 0}, d: util.DirectionEnum.Down,
+			path: [][2]int{{0, 1}, {1, 1}},
+		},
+		{
+			name: "ice_into_spin",
+			rows: []string{
+				".*D.",
+				"....",
+			},
+			from: [2]int{0, 0}, d: util.DirectionEnum.Right,
+			path: [][2]int{{1, 0}, {2, 0}, {2, 1}},
+		},
+		{
+			// 被推向水面时停下
+			name: "spin_into_water",
+			rows: []string{
+				".R~",
+			},
+			from: [2]int{0, 0}, d: util.DirectionEnum.Right,
+			path: [][2]int{{1, 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld(t, "main", map[string]testMap{"main": {rows: tt.rows}})
+			w.stepPos = tt.from
+			self := &testSelf{pos: tt.from}
+			var path [][2]int
+			for d, forced := tt.d, true; forced; {
+				if len(path) > 10 {
+					t.Fatalf("still pushed after %v", path)
+				}
+				dx, dy := d.Offset()
+				self.pos, self.dir, self.forced = [2]int{self.pos[0] + dx, self.pos[1] + dy}, d, false
+				path = append(path, self.pos)
+				err := w.Update(nil, []sprite.Sprite{self}, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				d, forced = self.next, self.forced
+			}
+			if len(path) != len(tt.path) {
+				t.Fatalf("path %v, want %v", path, tt.path)
+			}
+			for i := range path {
+				if path[i] != tt.path[i] {
+					t.Fatalf("path %v, want %v", path, tt.path)
+				}
+			}
+
+			// 原地更新时不再触发地形
+			err := w.Update(nil, []sprite.Sprite{self}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if self.forced {
+				t.Fatal("terrain triggered again without moving")
+			}
+		})
+	}
+}
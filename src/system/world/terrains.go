@@ -0,0 +1,109 @@
+package world
+
+import (
+	"image"
+
+	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/util"
+	"github.com/kkkunny/pokemon/src/util/draw"
+)
+
+func init() {
+	RegisterTerrain("ledge", ledgeTerrain{})
+	RegisterTerrain("tall_grass", tallGrassTerrain{})
+	RegisterTerrain("water", waterTerrain{})
+	RegisterTerrain("ice", iceTerrain{})
+	RegisterTerrain("spin", spinTerrain{})
+}
+
+// 地块的direction属性
+func terrainDirection(info TerrainInfo) (util.Direction, bool) {
+	d := info.Tile.Properties.GetString("direction")
+	return util.ParseDirection(d), d != ""
+}
+
+// 断崖，只能由主角沿direction方向跳下
+type ledgeTerrain struct{}
+
+func (ledgeTerrain) Passable(_ TerrainInfo) bool {
+	return false
+}
+
+func (ledgeTerrain) Jump(info TerrainInfo) bool {
+	d, ok := terrainDirection(info)
+	return ok && info.Self && info.Direction == d
+}
+
+// 草丛，遮住站在里面的精灵的下半身，走过时晃动
+type tallGrassTerrain struct{}
+
+func (tallGrassTerrain) Passable(_ TerrainInfo) bool {
+	return true
+}
+
+func (tallGrassTerrain) Overlay(_ TerrainInfo) image.Rectangle {
+	return image.Rect(0, config.TileSize/2, config.TileSize, config.TileSize)
+}
+
+func (tallGrassTerrain) OnStep(info TerrainInfo) (util.Direction, bool) {
+	if info.World != nil {
+		info.World.AddTileEffect(info.X, info.Y, &grassRustle{})
+	}
+	return 0, false
+}
+
+func (tallGrassTerrain) EncounterMethod() EncounterMethod {
+	return EncounterMethodEnum.Grass
+}
+
+const grassRustleTicks = 16 // 草丛晃动的帧数
+
+// 草丛晃动，草叶从脚下向两侧散开
+type grassRustle struct {
+	counter int
+}
+
+func (e *grassRustle) Update() bool {
+	e.counter++
+	return e.counter < grassRustleTicks
+}
+
+func (e *grassRustle) Draw(drawer draw.OptionDrawer) {
+	offset := e.counter * 4 / grassRustleTicks
+	c := util.NewNRGBColor(64, 136, 64)
+	draw.PrepareDrawRect(drawer, 2, 3, c).Move(4-offset, config.TileSize-4-offset).Draw()
+	draw.PrepareDrawRect(drawer, 2, 3, c).Move(config.TileSize-6+offset, config.TileSize-4-offset).Draw()
+}
+
+// 水面，只有能冲浪时主角才能进入
+type waterTerrain struct{}
+
+func (waterTerrain) Passable(info TerrainInfo) bool {
+	return info.Self && info.World != nil && info.World.CanSurf()
+}
+
+func (waterTerrain) EncounterMethod() EncounterMethod {
+	return EncounterMethodEnum.Surf
+}
+
+// 冰面，主角沿原方向滑行直到被阻挡
+type iceTerrain struct{}
+
+func (iceTerrain) Passable(_ TerrainInfo) bool {
+	return true
+}
+
+func (iceTerrain) OnStep(info TerrainInfo) (util.Direction, bool) {
+	return info.Direction, true
+}
+
+// 旋转地块，主角被推向direction方向
+type spinTerrain struct{}
+
+func (spinTerrain) Passable(_ TerrainInfo) bool {
+	return true
+}
+
+func (spinTerrain) OnStep(info TerrainInfo) (util.Direction, bool) {
+	return terrainDirection(info)
+}
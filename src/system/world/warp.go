@@ -0,0 +1,63 @@
+package world
+
+import (
+	"fmt"
+
+	"github.com/lafriks/go-tiled"
+	"github.com/tnnmigga/enum"
+
+	"github.com/kkkunny/pokemon/src/config"
+	"github.com/kkkunny/pokemon/src/util"
+)
+
+// WarpTransition 传送时的过渡效果
+type WarpTransition string
+
+var WarpTransitionEnum = enum.New[struct {
+	None   WarpTransition `enum:"none"`   // 直接切换
+	Fade   WarpTransition `enum:"fade"`   // 淡出淡入
+	Door   WarpTransition `enum:"door"`   // 开门后淡出淡入，从此处到达时开门走出再关门
+	Stairs WarpTransition `enum:"stairs"` // 播放上下楼梯音效并淡出淡入
+}]()
+
+const defaultStairsSound = "stairs"
+
+// Warp 传送点，主角走到所在地块时传送到目标地图
+type Warp struct {
+	X, Y       int            // 所在地块
+	Map        string         // 目标地图
+	ToX, ToY   int            // 目标地块
+	Facing     util.Direction // 到达后的朝向，为0时保持原朝向
+	Transition WarpTransition
+	Sound      string // 传送时播放的音效
+	WalkOut    bool   // 到达后朝朝向走出一步，没有朝向时沿原朝向
+}
+
+// 解析tiled对象中的传送点，hole为旧的传送点类型，只有目标位置
+func parseWarp(object *tiled.Object) (*Warp, error) {
+	warp := &Warp{
+		X:          int(object.X+object.Width/2) / config.TileSize,
+		Y:          int(object.Y+object.Height/2) / config.TileSize,
+		Map:        object.Properties.GetString("to_map"),
+		ToX:        object.Properties.GetInt("to_x"),
+		ToY:        object.Properties.GetInt("to_y"),
+		Transition: WarpTransition(object.Properties.GetString("transition")),
+		Sound:      object.Properties.GetString("sound"),
+		WalkOut:    object.Properties.GetBool("walk_out"),
+	}
+	if warp.Map == "" {
+		return nil, fmt.Errorf("warp %d: to_map is empty", object.ID)
+	}
+	if facing := object.Properties.GetString("facing"); facing != "" {
+		warp.Facing = util.ParseDirection(facing)
+	}
+	if warp.Transition == "" {
+		warp.Transition = WarpTransitionEnum.Fade
+	} else if !enum.Contains(WarpTransitionEnum, warp.Transition) {
+		return nil, fmt.Errorf("warp %d: unknown transition `%s`", object.ID, warp.Transition)
+	}
+	if warp.Transition == WarpTransitionEnum.Stairs && warp.Sound == "" {
+		warp.Sound = defaultStairsSound
+	}
+	return warp, nil
+}
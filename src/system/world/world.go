@@ -5,8 +5,10 @@ import (
 	"image/color"
 	"time"
 
+	"github.com/hajimehoshi/ebiten/v2"
 	stlmaps "github.com/kkkunny/stl/container/maps"
 	"github.com/kkkunny/stl/container/pqueue"
+	stlslices "github.com/kkkunny/stl/container/slices"
 	"golang.org/x/image/font"
 
 	"github.com/kkkunny/pokemon/src/config"
@@ -19,21 +21,37 @@ import (
 )
 
 type World struct {
-	ctx             context.Context
-	tileCache       *render.TileCache
-	mapCache        map[string]*Map
-	currentMap      *Map
-	pixPos          [2]int
-	firstRenderTime time.Time
+	ctx        context.Context
+	tileCache  *render.TileCache
+	mapCache   map[string]*Map
+	currentMap *Map
+	pixPos     [2]int
+	animTime   time.Duration // 地块动画的播放时间，按帧累计
 
 	// 地图名
 	nameMoveSpeed   int // 地图名移动速度
 	nameMoveCounter int // 地图名移动计数器
 
 	// 地图碰撞缓存
-	selfPos [2]int // 主角所在当前地图位置
+	selfPos     [2]int // 主角所在当前地图位置
+	stepPos     [2]int // 主角上次更新时所在的地块，用于判断是否走完一步
+	gridVersion int    // 可通行网格的版本，载入或切换地图后增加
 
-	onBattleStart func(site string) error // 战斗开始回调
+	clock         func() time.Time      // 当前游戏时间，用于判断时段
+	onBattleStart func(Encounter) error // 战斗开始回调
+	onWarp        func(*Warp) error     // 走上传送点回调
+	canSurf       func() bool           // 能否在水面上移动
+
+	door     [2]int  // 正在开关的门所在地块
+	doorOpen float64 // 门的打开程度，0~1，为0时不绘制
+
+	effects []tileEffect // 当前地图上的地块效果
+}
+
+// 可被地形推动的精灵，如主角
+type terrainMover interface {
+	Direction() util.Direction
+	SetNextStepDirection(d util.Direction) bool
 }
 
 func NewWorld(ctx context.Context, initMapName string) (*World, error) {
@@ -43,17 +61,46 @@ func NewWorld(ctx context.Context, initMapName string) (*World, error) {
 		tileCache:     tileCache,
 		mapCache:      make(map[string]*Map),
 		nameMoveSpeed: 1,
+		clock:         time.Now,
 	}
 	return w, w.MoveTo(initMapName)
 }
 
-func (w *World) SetOnBattleStart(f func(site string) error) {
+func (w *World) SetOnBattleStart(f func(Encounter) error) {
 	w.onBattleStart = f
 }
 
+// SetOnWarp 设置走上传送点时的回调，未设置时直接传送
+func (w *World) SetOnWarp(f func(*Warp) error) {
+	w.onWarp = f
+}
+
+// SetCanSurf 设置能否在水面上移动的判定，未设置时不能进入水面
+func (w *World) SetCanSurf(f func() bool) {
+	w.canSurf = f
+}
+
+// CanSurf 能否在水面上移动
+func (w *World) CanSurf() bool {
+	return w.canSurf != nil && w.canSurf()
+}
+
+// SetClock 设置游戏时间来源
+func (w *World) SetClock(f func() time.Time) {
+	w.clock = f
+}
+
+// Now 当前游戏时间
+func (w *World) Now() time.Time {
+	return w.clock()
+}
+
 func (w *World) Update(ctx context.Context, sprites []sprite.Sprite, info sprite.UpdateInfo) error {
+	w.animTime += time.Second / time.Duration(ebiten.TPS())
+
 	// 全局精灵
 	var selfX, selfY int
+	var self terrainMover
 	for _, s := range sprites {
 		if !s.Collision() {
 			continue
@@ -61,6 +108,7 @@ func (w *World) Update(ctx context.Context, sprites []sprite.Sprite, info sprite
 		selfX, selfY = s.Position()
 		x, y := s.CollisionPosition()
 		w.selfPos = [2]int{x, y}
+		self, _ = s.(terrainMover)
 	}
 	// 地图精灵
 	for _, s := range w.CurrentMap().Sprites() {
@@ -70,44 +118,93 @@ func (w *World) Update(ctx context.Context, sprites []sprite.Sprite, info sprite
 		}
 	}
 
-	// 洞
-	for _, hole := range w.currentMap.GetHoles() {
-		x, y := int(hole.X+hole.Width/2)/config.TileSize, int(hole.Y+hole.Height/2)/config.TileSize
-		if selfX != x || selfY != y {
-			continue
-		}
-		toMap, toX, toY := hole.Properties.GetString("to_map"), hole.Properties.GetInt("to_x"), hole.Properties.GetInt("to_y")
-		err := w.MoveTo(toMap)
-		if err != nil {
-			return err
-		}
-		for _, s := range sprites {
-			s.SetPosition(toX, toY)
-		}
+	// 地块效果
+	w.effects = stlslices.Filter(w.effects, func(_ int, e tileEffect) bool {
+		return e.effect.Update()
+	})
+
+	// 只在走完一步时触发传送、地形和遭遇
+	stepped := w.stepPos != [2]int{selfX, selfY}
+	w.stepPos = [2]int{selfX, selfY}
+	if !stepped {
 		return nil
 	}
-
-	for _, objectLayer := range w.currentMap.define.ObjectGroups {
-		if objectLayer.Class != ObjectLayerTypeEnum.Split {
-			continue
+	if warp, ok := w.currentMap.GetWarp(selfX, selfY); ok {
+		if w.onWarp == nil {
+			return w.Warp(sprites, warp.Map, warp.ToX, warp.ToY)
 		}
-		for _, object := range objectLayer.Objects {
-			if object.Type != "gunting_ground" {
+		return w.onWarp(warp)
+	}
+	method := EncounterMethodEnum.Grass
+	if self != nil {
+		for _, t := range w.currentMap.getTerrains(self.Direction(), selfX, selfY, true) {
+			if encounter, ok := t.terrain.(TerrainEncounter); ok {
+				method = encounter.EncounterMethod()
+			}
+			step, ok := t.terrain.(TerrainStep)
+			if !ok {
 				continue
 			}
-			if float64(selfX*config.TileSize) >= object.X && float64(selfX+1*config.TileSize) <= object.X+object.Width &&
-				float64(selfY*config.TileSize) >= object.Y && float64(selfY+1*config.TileSize) <= object.Y+object.Width {
-				return w.onBattleStart(object.Properties.GetString("battle_site"))
+			// 被地形推动时继续前进，前方被阻挡时停下
+			d, forced := step.OnStep(t.info)
+			dx, dy := d.Offset()
+			if forced && !w.CheckSelfCollision(d, selfX+dx, selfY+dy) {
+				self.SetNextStepDirection(d)
+				return nil
 			}
 		}
 	}
-	return nil
+	return w.TryEncounter(method)
+}
+
+// TryEncounter 按遭遇率尝试在主角所在地块遭遇野生宝可梦
+func (w *World) TryEncounter(method EncounterMethod) error {
+	region, ok := w.currentMap.getEncounterRegion(w.stepPos[0], w.stepPos[1])
+	if !ok || w.ctx.Rand(context.RandStreamEnum.Encounter).IntN(100) >= region.table.Rate {
+		return nil
+	}
+	_, err := w.encounter(region, method)
+	return err
+}
+
+// CanFish 主角面前是否是水面
+func (w *World) CanFish(d util.Direction) bool {
+	dx, dy := d.Offset()
+	return stlslices.Any(w.currentMap.getTerrains(d, w.selfPos[0]+dx, w.selfPos[1]+dy, true), func(_ int, t layerTerrain) bool {
+		encounter, ok := t.terrain.(TerrainEncounter)
+		return ok && encounter.EncounterMethod() == EncounterMethodEnum.Surf
+	})
+}
+
+// Fish 向主角面前的水面钓鱼，不判定遭遇率
+// @return: 是否有宝可梦上钩
+func (w *World) Fish(d util.Direction) (bool, error) {
+	if !w.CanFish(d) {
+		return false, nil
+	}
+	dx, dy := d.Offset()
+	region, ok := w.currentMap.getEncounterRegion(w.selfPos[0]+dx, w.selfPos[1]+dy)
+	if !ok {
+		return false, nil
+	}
+	return w.encounter(region, EncounterMethodEnum.Fishing)
+}
+
+// 从区域的遭遇表中抽取并开始战斗
+// @return: 是否遭遇
+func (w *World) encounter(region *encounterRegion, method EncounterMethod) (bool, error) {
+	species, level, ok := region.table.Roll(w.ctx.Rand(context.RandStreamEnum.Encounter), method, GetTimeOfDay(w.clock()))
+	if !ok || w.onBattleStart == nil {
+		return false, nil
+	}
+	return true, w.onBattleStart(Encounter{Site: region.site(), Species: species, Level: level})
 }
 
 // 获取需要绘制的地图信息（参数、范围）
 func (w *World) getNeedDrawMap() (map[*Map]image.Point, map[*Map]image.Rectangle, error) {
 	map2Pos := make(map[*Map]image.Point, 5)
 	map2Rect := make(map[*Map]image.Rectangle, 5)
+	cfg := w.ctx.Config()
 
 	var loopFn func(m *Map, pixX, pixY int) error
 	loopFn = func(m *Map, pixX, pixY int) error {
@@ -115,7 +212,7 @@ func (w *World) getNeedDrawMap() (map[*Map]image.Point, map[*Map]image.Rectangle
 		x0, y0 := max(0-pixX, 0)/config.TileSize, max(0-pixY, 0)/config.TileSize
 		mapPixWidth, mapPixHeight := m.PixelSize()
 		mapWidth, mapHeight := m.Size()
-		x1, y1 := mapWidth-max((pixX+mapPixWidth)*config.Scale-w.ctx.Config().ScreenWidth, 0)/(config.TileSize*config.Scale), mapHeight-max((pixY+mapPixHeight)*config.Scale-w.ctx.Config().ScreenHeight, 0)/(config.TileSize*config.Scale)
+		x1, y1 := mapWidth-max((pixX+mapPixWidth)*cfg.Scale-cfg.ScreenWidth, 0)/(config.TileSize*cfg.Scale), mapHeight-max((pixY+mapPixHeight)*cfg.Scale-cfg.ScreenHeight, 0)/(config.TileSize*cfg.Scale)
 		map2Rect[m] = image.Rect(x0, y0, x1, y1)
 
 		needDrawAdjacentMaps := stlmaps.Filter(m.AdjacentMaps(), func(d util.Direction, id string) bool {
@@ -123,11 +220,11 @@ func (w *World) getNeedDrawMap() (map[*Map]image.Point, map[*Map]image.Rectangle
 			case util.DirectionEnum.Up:
 				return pixY > 0
 			case util.DirectionEnum.Down:
-				return (pixY+mapPixHeight)*config.Scale < w.ctx.Config().ScreenHeight
+				return (pixY+mapPixHeight)*cfg.Scale < cfg.ScreenHeight
 			case util.DirectionEnum.Left:
 				return pixX > 0
 			case util.DirectionEnum.Right:
-				return (pixX+mapPixWidth)*config.Scale < w.ctx.Config().ScreenWidth
+				return (pixX+mapPixWidth)*cfg.Scale < cfg.ScreenWidth
 			default:
 				return false
 			}
@@ -165,12 +262,6 @@ func (w *World) getNeedDrawMap() (map[*Map]image.Point, map[*Map]image.Rectangle
 }
 
 func (w *World) OnDraw(drawer draw.OptionDrawer, sprites []sprite.Sprite) error {
-	now := time.Now()
-	var defaultTime time.Time
-	if w.firstRenderTime == defaultTime {
-		w.firstRenderTime = now
-	}
-
 	map2Pos, map2Rect, err := w.getNeedDrawMap()
 	if err != nil {
 		return err
@@ -178,34 +269,45 @@ func (w *World) OnDraw(drawer draw.OptionDrawer, sprites []sprite.Sprite) error
 
 	// 背景
 	for drawMap, pos := range map2Pos {
-		err = drawMap.DrawBackground(drawer.Move(pos.X, pos.Y), map2Rect[drawMap], now.Sub(w.firstRenderTime))
+		err = drawMap.DrawBackground(drawer.Move(pos.X, pos.Y), map2Rect[drawMap], w.animTime)
 		if err != nil {
 			return err
 		}
 	}
+	// 门，打开时露出门内的黑暗，位于精灵之下
+	currentMapPos := map2Pos[w.currentMap]
+	if w.doorOpen > 0 {
+		doorHeight := int(float64(config.TileSize) * w.doorOpen)
+		draw.PrepareDrawRect(drawer, config.TileSize, doorHeight, color.Black).Move(currentMapPos.X+w.door[0]*config.TileSize, currentMapPos.Y+w.door[1]*config.TileSize).Draw()
+	}
 	// 精灵
 	drawSprites := pqueue.AnyWith[int, sprite.Sprite]()
-	// 全局精灵
-	for _, s := range sprites {
-		_, y := s.Position()
-		drawSprites.Push(y, s)
-	}
-	// 地图精灵
-	for _, s := range w.currentMap.sprites {
+	// 全局精灵和地图精灵
+	for _, s := range append(stlslices.Clone(sprites), w.currentMap.Sprites()...) {
 		_, y := s.Position()
 		drawSprites.Push(y, s)
 	}
 	spritePairs := drawSprites.ToSlice()
-	currentMapPos := map2Pos[w.currentMap]
 	for i := len(spritePairs) - 1; i >= 0; i-- {
-		err = spritePairs[i].E2().Draw(w.ctx, drawer.Move(currentMapPos.X, currentMapPos.Y))
+		sp := spritePairs[i].E2()
+		err = sp.Draw(w.ctx, drawer.Move(currentMapPos.X, currentMapPos.Y))
+		if err != nil {
+			return err
+		}
+		// 遮挡精灵的地形，如草丛遮住下半身，走进时就开始遮挡，位于之后绘制的精灵之下
+		x, y := sp.CollisionPosition()
+		err = w.currentMap.drawTerrainOverlay(drawer.Move(currentMapPos.X, currentMapPos.Y), x, y, w.animTime)
 		if err != nil {
 			return err
 		}
 	}
+	// 地块效果
+	for _, e := range w.effects {
+		e.effect.Draw(drawer.Move(currentMapPos.X+e.x*config.TileSize, currentMapPos.Y+e.y*config.TileSize))
+	}
 	// 前景
 	for drawMap, pos := range map2Pos {
-		err = drawMap.DrawForeground(drawer.Move(pos.X, pos.Y), map2Rect[drawMap], now.Sub(w.firstRenderTime))
+		err = drawMap.DrawForeground(drawer.Move(pos.X, pos.Y), map2Rect[drawMap], w.animTime)
 		if err != nil {
 			return err
 		}
@@ -225,7 +327,9 @@ func (w *World) loadMap(id string) (*Map, error) {
 	if err != nil {
 		return nil, err
 	}
+	targetMap.world = w
 	w.mapCache[id] = targetMap
+	w.gridVersion++
 	return targetMap, nil
 }
 
@@ -238,10 +342,31 @@ func (w *World) MoveTo(id string) error {
 		return err
 	}
 	w.currentMap = targetMap
+	w.gridVersion++
 	w.nameMoveCounter = 0
+	w.effects = nil
+	return nil
+}
+
+// Warp 将精灵移动到目标地图的地块，不会触发到达地块上的传送点和遭遇
+func (w *World) Warp(sprites []sprite.Sprite, mapID string, x, y int) error {
+	err := w.MoveTo(mapID)
+	if err != nil {
+		return err
+	}
+	for _, s := range sprites {
+		s.SetPosition(x, y)
+	}
+	w.stepPos = [2]int{x, y}
 	return nil
 }
 
+// SetDoor 设置当前地图上正在开关的门，open为打开程度0~1，为0时关闭
+func (w *World) SetDoor(x, y int, open float64) {
+	w.door = [2]int{x, y}
+	w.doorOpen = min(max(open, 0), 1)
+}
+
 func (w *World) GetActualPosition(x, y int) (*Map, int, int, bool) {
 	curMap := w.currentMap
 	for {
@@ -292,6 +417,15 @@ func (w *World) CurrentMap() *Map {
 }
 
 func (w *World) CheckCollision(d util.Direction, x, y int) bool {
+	return w.checkCollision(d, x, y, false)
+}
+
+// CheckSelfCollision 主角沿方向d进入地块是否被阻挡，部分地形只有主角可以进入
+func (w *World) CheckSelfCollision(d util.Direction, x, y int) bool {
+	return w.checkCollision(d, x, y, true)
+}
+
+func (w *World) checkCollision(d util.Direction, x, y int, self bool) bool {
 	if [2]int{x, y} == w.selfPos {
 		return true
 	}
@@ -299,7 +433,26 @@ func (w *World) CheckCollision(d util.Direction, x, y int) bool {
 	if !ok {
 		return true
 	}
-	return targetMap.CheckCollision(d, x, y)
+	return targetMap.checkCollision(d, x, y, self)
+}
+
+// CheckJump 主角能否沿方向d越过地块，落在其后一格
+func (w *World) CheckJump(d util.Direction, x, y int) bool {
+	targetMap, tx, ty, ok := w.GetActualPosition(x, y)
+	if !ok {
+		return false
+	}
+	jumpable := stlslices.Any(targetMap.getTerrains(d, tx, ty, true), func(_ int, t layerTerrain) bool {
+		jump, ok := t.terrain.(TerrainJump)
+		return ok && jump.Jump(t.info)
+	})
+	dx, dy := d.Offset()
+	return jumpable && !w.CheckSelfCollision(d, x+dx, y+dy)
+}
+
+// AddTileEffect 在当前地图的地块上添加短暂效果，切换地图时清除
+func (w *World) AddTileEffect(x, y int, effect TileEffect) {
+	w.effects = append(w.effects, tileEffect{x: x, y: y, effect: effect})
 }
 
 // DrawMapName 绘制地图名
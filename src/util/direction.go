@@ -40,3 +40,29 @@ func (d Direction) String() string {
 		return ""
 	}
 }
+
+// Offset 朝该方向走一格时的坐标变化
+func (d Direction) Offset() (dx, dy int) {
+	switch d {
+	case DirectionEnum.Up, DirectionEnum.Down:
+		return 0, int(d)
+	case DirectionEnum.Left, DirectionEnum.Right:
+		return int(d) / 3, 0
+	default:
+		return 0, 0
+	}
+}
+
+// DirectionOf 坐标变化为(dx, dy)时大致的方向，横向和纵向相同时取横向
+func DirectionOf(dx, dy int) Direction {
+	switch {
+	case dx*dx >= dy*dy && dx > 0:
+		return DirectionEnum.Right
+	case dx*dx >= dy*dy && dx < 0:
+		return DirectionEnum.Left
+	case dy < 0:
+		return DirectionEnum.Up
+	default:
+		return DirectionEnum.Down
+	}
+}
@@ -0,0 +1,109 @@
+package draw
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/kkkunny/pokemon/src/util/draw/option"
+)
+
+// 以下为不依赖GPU的实现，用于无窗口运行
+
+// 去掉绘制参数的包装，得到实际的图像
+func unwrapDrawer(drawer draw.Image) draw.Image {
+	if d, ok := drawer.(_optionDrawer); ok {
+		return unwrapDrawer(d.Image)
+	}
+	return drawer
+}
+
+func drawSoftwareImage(drawer draw.Image, opts option.DrawImageOptions) {
+	globalOpts := getDrawOptions(drawer)
+	opts.ScaleX *= globalOpts.scaleX
+	opts.ScaleY *= globalOpts.scaleY
+	opts.X = int(float64(opts.X)*globalOpts.scaleX + float64(globalOpts.x))
+	opts.Y = int(float64(opts.Y)*globalOpts.scaleY + float64(globalOpts.y))
+
+	src := opts.Image.Bounds()
+	dst := image.Rect(0, 0, int(float64(src.Dx())*opts.ScaleX), int(float64(src.Dy())*opts.ScaleY)).Add(image.Pt(opts.X, opts.Y))
+	xdraw.NearestNeighbor.Scale(unwrapDrawer(drawer), dst, opts.Image, src, draw.Over, nil)
+}
+
+// 字体均由util.GetFont创建，只支持GoXFace
+func drawSoftwareText(drawer draw.Image, opts option.DrawTextOptions) {
+	face, ok := opts.Font.(*text.GoXFace)
+	if !ok {
+		panic("unsupported font face")
+	}
+	innerFace := face.UnsafeInternal()
+	metrics := innerFace.Metrics()
+
+	// 先按原大小绘制到临时图像，缩放交给图像绘制
+	lines := strings.Split(opts.Text, "\n")
+	var width fixed.Int26_6
+	for _, line := range lines {
+		width = max(width, font.MeasureString(innerFace, line))
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width.Ceil(), metrics.Height.Ceil()*len(lines)))
+	d := font.Drawer{Dst: img, Src: image.NewUniform(opts.Color), Face: innerFace}
+	for i, line := range lines {
+		d.Dot = fixed.Point26_6{Y: metrics.Ascent + metrics.Height*fixed.Int26_6(i)}
+		d.DrawString(line)
+	}
+
+	imgOpts := option.NewDrawImageOptions(img, nil).Move(opts.X, opts.Y).Scale(opts.ScaleX, opts.ScaleY)
+	drawSoftwareImage(drawer, imgOpts)
+}
+
+// 圆角矩形的遮罩，inset为向内收缩的距离
+func roundRectMask(w, h, radius, inset int) *image.Alpha {
+	mask := image.NewAlpha(image.Rect(0, 0, w, h))
+	w, h, radius = w-inset*2, h-inset*2, max(radius-inset, 0)
+	r := float64(radius)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// 像素中心到最近圆角圆心的距离
+			px, py := float64(x)+0.5, float64(y)+0.5
+			cx, cy := min(max(px, r), float64(w)-r), min(max(py, r), float64(h)-r)
+			if dx, dy := px-cx, py-cy; dx*dx+dy*dy <= r*r {
+				mask.SetAlpha(x+inset, y+inset, color.Alpha{A: 0xff})
+			}
+		}
+	}
+	return mask
+}
+
+func drawSoftwareRect(drawer draw.Image, opts option.DrawRectOptions) {
+	globalOpts := getDrawOptions(drawer)
+	opts.Width = int(float64(opts.Width) * globalOpts.scaleX)
+	opts.Height = int(float64(opts.Height) * globalOpts.scaleY)
+	opts.X = int(float64(opts.X)*globalOpts.scaleX + float64(globalOpts.x))
+	opts.Y = int(float64(opts.Y)*globalOpts.scaleY + float64(globalOpts.y))
+	opts.BorderWidth = int(float64(opts.BorderWidth) * globalOpts.scaleX)
+	opts.Radius = int(float64(opts.Radius) * globalOpts.scaleX)
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return
+	}
+
+	dst := unwrapDrawer(drawer)
+	rect := image.Rect(opts.X, opts.Y, opts.X+opts.Width, opts.Y+opts.Height)
+	radius := min(min(opts.Width/2, opts.Height/2), opts.Radius)
+	outer := roundRectMask(opts.Width, opts.Height, radius, 0)
+	if opts.Color != nil {
+		draw.DrawMask(dst, rect, image.NewUniform(opts.Color), image.Point{}, outer, image.Point{}, draw.Over)
+	}
+	if opts.BorderWidth > 0 && opts.BorderColor != nil {
+		inner := roundRectMask(opts.Width, opts.Height, radius, opts.BorderWidth)
+		for i := range outer.Pix {
+			outer.Pix[i] -= inner.Pix[i]
+		}
+		draw.DrawMask(dst, rect, image.NewUniform(opts.BorderColor), image.Point{}, outer, image.Point{}, draw.Over)
+	}
+}
@@ -28,8 +28,7 @@ func PrepareDrawImage(drawer draw.Image, img image.Image) option.DrawImageOption
 				return
 			}
 		}
-		// TODO: 其他类型的实现
-		panic("todo")
+		drawSoftwareImage(drawer, opts)
 	})
 }
 
@@ -43,8 +42,7 @@ func PrepareDrawText(drawer draw.Image, text string, font text.Face, c color.Col
 				return
 			}
 		}
-		// TODO: 其他类型的实现
-		panic("todo")
+		drawSoftwareText(drawer, opts)
 	})
 }
 
@@ -58,8 +56,7 @@ func PrepareDrawRect(drawer draw.Image, w, h int, c color.Color) option.DrawRect
 				return
 			}
 		}
-		// TODO: 其他类型的实现
-		panic("todo")
+		drawSoftwareRect(drawer, opts)
 	})
 }
 
@@ -1,8 +1,8 @@
 package util
 
 import (
-	"os"
-	"path/filepath"
+	"fmt"
+	"path"
 	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
@@ -11,6 +11,7 @@ import (
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
 
+	"github.com/kkkunny/pokemon/src/assets"
 	"github.com/kkkunny/pokemon/src/config"
 )
 
@@ -21,25 +22,32 @@ var FontTypeEnum = enum.New[struct {
 	Emoji  FontType
 }]()
 
-var innerFontCache = make(map[FontType]*opentype.Font)
+var innerFontCache map[FontType]*opentype.Font
 var fontFaceCache = make(map[tuple.Tuple2[FontType, int]]*text.GoXFace)
 
-func init() {
+// LoadFonts 从数据目录读取字体，需在挂载资源后调用
+func LoadFonts() error {
 	fontNames := enum.Keys(FontTypeEnum)
 	fontTypeEnums := enum.Values[FontType](FontTypeEnum)
+	fonts := make(map[FontType]*opentype.Font, len(fontNames))
 	for i, fontName := range fontNames {
-		fontData, err := os.ReadFile(filepath.Join(config.FontsPath, strings.ToLower(fontName)+".ttf"))
+		fontPath := path.Join(config.FontsPath, strings.ToLower(fontName)+".ttf")
+		fontData, err := assets.ReadFile(fontPath)
 		if err != nil {
-			panic(err)
+			return err
 		}
 		fontInst, err := opentype.Parse(fontData)
 		if err != nil {
-			panic(err)
+			return fmt.Errorf("%s: %w", fontPath, err)
 		}
-		innerFontCache[fontTypeEnums[i]] = fontInst
+		fonts[fontTypeEnums[i]] = fontInst
 	}
+	innerFontCache = fonts
+	clear(fontFaceCache)
+	return nil
 }
 
+// GetFont 获取某字号的字体，需先调用LoadFonts
 func GetFont(fontType FontType, size int) *text.GoXFace {
 	fontFace, ok := fontFaceCache[tuple.Pack2(fontType, size)]
 	if ok {
@@ -47,7 +55,7 @@ func GetFont(fontType FontType, size int) *text.GoXFace {
 	}
 	innerFont, ok := innerFontCache[fontType]
 	if !ok {
-		panic("unknown font type")
+		panic("font not loaded")
 	}
 	stdFontFace, err := opentype.NewFace(innerFont, &opentype.FaceOptions{
 		Size:    float64(size),
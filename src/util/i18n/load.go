@@ -1,14 +1,16 @@
 package i18n
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
-	"os"
-	"path/filepath"
+	"maps"
+	"path"
 
 	"github.com/tnnmigga/enum"
 	"gopkg.in/yaml.v3"
 
+	"github.com/kkkunny/pokemon/src/assets"
 	"github.com/kkkunny/pokemon/src/config"
 )
 
@@ -18,8 +20,8 @@ var LanguageEnum = enum.New[struct {
 	ZH_CN Language `enum:"zh_cn"`
 }]()
 
-func loadLocalisationFile(path string) (map[string]string, error) {
-	file, err := os.Open(path)
+func loadLocalisationFile(fsys fs.FS, path string) (map[string]string, error) {
+	file, err := fsys.Open(path)
 	if err != nil {
 		return nil, err
 	}
@@ -29,34 +31,57 @@ func loadLocalisationFile(path string) (map[string]string, error) {
 	locs := make(map[string]string)
 	err = decoder.Decode(&locs)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %w", path, err)
 	}
 	return locs, nil
 }
 
-func LoadLocalisation(lang Language) (*Localisation, error) {
-	dirpath := filepath.Join(config.LocalisationPath, string(lang))
-	dirinfo, err := os.Stat(dirpath)
+// LoadKeys 载入一层资源中指定语言的所有翻译，没有该语言时返回fs.ErrNotExist
+func LoadKeys(fsys fs.FS, lang Language) (map[string]string, error) {
+	dirpath := path.Join(config.LocalisationPath, string(lang))
+	dirinfo, err := fs.Stat(fsys, dirpath)
 	if err != nil {
 		return nil, err
 	} else if !dirinfo.IsDir() {
 		return nil, fmt.Errorf("%s is not a localisation directory", dirpath)
 	}
 
-	loc := NewLocalisation()
-	err = filepath.WalkDir(dirpath, func(path string, d fs.DirEntry, err error) error {
-		if filepath.Ext(path) != ".yml" {
+	kvs := make(map[string]string)
+	err = fs.WalkDir(fsys, dirpath, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if path.Ext(name) != ".yml" {
 			return nil
 		}
-		kvs, err := loadLocalisationFile(path)
+		fileKvs, err := loadLocalisationFile(fsys, name)
 		if err != nil {
 			return err
 		}
-		loc.MultiAdd(kvs)
+		maps.Copy(kvs, fileKvs)
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	return kvs, nil
+}
+
+// LoadLocalisation 载入指定语言的翻译，资源有多层时逐层载入，后面的层覆盖前面的同名键
+func LoadLocalisation(lang Language) (*Localisation, error) {
+	loc := NewLocalisation()
+	var found bool
+	for _, layer := range assets.Layers() {
+		kvs, err := LoadKeys(layer.FS, lang)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %w", layer.Name, err)
+		}
+		found = true
+		loc.MultiAdd(kvs)
+	}
+	if !found {
+		return nil, fmt.Errorf("no localisation for `%s`", lang)
+	}
 	return loc, nil
 }
@@ -5,14 +5,13 @@ import (
 	"image/color"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
 type ebitenImage struct {
 	Image *ebiten.Image
 }
 
-func WrapImage(img image.Image) *ebitenImage {
+func wrapEbitenImage(img image.Image) *ebitenImage {
 	if imgInst, ok := img.(*ebitenImage); ok {
 		return imgInst
 	} else if imgInst, ok := img.(*ebiten.Image); ok {
@@ -22,16 +21,8 @@ func WrapImage(img image.Image) *ebitenImage {
 	}
 }
 
-func NewImageFromFile(path string) (*ebitenImage, error) {
-	img, _, err := ebitenutil.NewImageFromFile(path)
-	if err != nil {
-		return nil, err
-	}
-	return WrapImage(img), nil
-}
-
-func NewImage(w, h int) *ebitenImage {
-	return WrapImage(ebiten.NewImage(w, h))
+func newEbitenImage(w, h int) *ebitenImage {
+	return wrapEbitenImage(ebiten.NewImage(w, h))
 }
 
 func (i *ebitenImage) EbitenImage() *ebiten.Image {
@@ -63,7 +54,7 @@ func (i *ebitenImage) SetRGBA64(x, y int, c color.RGBA64) {
 }
 
 func (i *ebitenImage) SubImage(r image.Rectangle) Image {
-	return WrapImage(i.Image.SubImage(r))
+	return wrapEbitenImage(i.Image.SubImage(r))
 }
 
 func (i *ebitenImage) Fill(c color.Color) {
@@ -71,7 +62,7 @@ func (i *ebitenImage) Fill(c color.Color) {
 }
 
 func (i *ebitenImage) Scale(x, y float64) Image {
-	newImg := NewImage(int(float64(i.Bounds().Dx())*x), int(float64(i.Bounds().Dy())*y))
+	newImg := newEbitenImage(int(float64(i.Bounds().Dx())*x), int(float64(i.Bounds().Dy())*y))
 	var opts ebiten.DrawImageOptions
 	opts.GeoM.Scale(x, y)
 	newImg.Image.DrawImage(i.Image, &opts)
@@ -1,9 +1,15 @@
 package imgutil
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
+	_ "image/png"
+
+	"github.com/tnnmigga/enum"
+
+	"github.com/kkkunny/pokemon/src/assets"
 )
 
 type Image interface {
@@ -12,3 +18,47 @@ type Image interface {
 	Fill(c color.Color)
 	Scale(x, y float64) Image
 }
+
+// Backend 图像的实现
+type Backend uint8
+
+var BackendEnum = enum.New[struct {
+	Ebiten Backend // 使用GPU，需要在ebiten.RunGame中使用
+	RGBA   Backend // 纯内存实现，用于无窗口运行
+}]()
+
+var backend = BackendEnum.Ebiten
+
+// SetBackend 设置新建图像使用的实现，需要在载入任何资源之前调用
+func SetBackend(b Backend) {
+	backend = b
+}
+
+// WrapImage 将图像包装为当前实现的图像
+func WrapImage(img image.Image) Image {
+	if backend == BackendEnum.RGBA {
+		return wrapRGBAImage(img)
+	}
+	return wrapEbitenImage(img)
+}
+
+// NewImageFromFile 从资源中载入图像
+func NewImageFromFile(path string) (Image, error) {
+	file, err := assets.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return WrapImage(img), nil
+}
+
+func NewImage(w, h int) Image {
+	if backend == BackendEnum.RGBA {
+		return newRGBAImage(w, h)
+	}
+	return newEbitenImage(w, h)
+}
@@ -0,0 +1,44 @@
+package imgutil
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+type rgbaImage struct {
+	*image.RGBA
+}
+
+func wrapRGBAImage(img image.Image) *rgbaImage {
+	switch img := img.(type) {
+	case *rgbaImage:
+		return img
+	case *image.RGBA:
+		return &rgbaImage{RGBA: img}
+	default:
+		rgba := image.NewRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		return &rgbaImage{RGBA: rgba}
+	}
+}
+
+func newRGBAImage(w, h int) *rgbaImage {
+	return &rgbaImage{RGBA: image.NewRGBA(image.Rect(0, 0, w, h))}
+}
+
+func (i *rgbaImage) SubImage(r image.Rectangle) Image {
+	return &rgbaImage{RGBA: i.RGBA.SubImage(r).(*image.RGBA)}
+}
+
+func (i *rgbaImage) Fill(c color.Color) {
+	draw.Draw(i.RGBA, i.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func (i *rgbaImage) Scale(x, y float64) Image {
+	newImg := newRGBAImage(int(float64(i.Bounds().Dx())*x), int(float64(i.Bounds().Dy())*y))
+	xdraw.NearestNeighbor.Scale(newImg.RGBA, newImg.Bounds(), i.RGBA, i.Bounds(), draw.Src, nil)
+	return newImg
+}